@@ -0,0 +1,183 @@
+// Package ai talks to the large language model behind the AI chat. The
+// rest of the app only sees the Provider interface; which backend serves
+// it is chosen by configuration.
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fitnesscoach/config"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Roles of the participants in a chat
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is one turn of a chat
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Options override the configured model settings for one call. Zero
+// values keep the provider defaults.
+type Options struct {
+	Model       string
+	MaxTokens   int
+	Temperature *float64
+	// JSON asks for the answer to be a single JSON object
+	JSON bool
+}
+
+// Provider generates text from a prompt or a chat history
+type Provider interface {
+	// Name identifies the backend in logs
+	Name() string
+	// Generate completes a single prompt
+	Generate(ctx context.Context, prompt string, opts Options) (string, error)
+	// Chat answers the last message of a conversation. A leading system
+	// message, if any, is passed as the provider's instructions.
+	Chat(ctx context.Context, messages []Message, opts Options) (string, error)
+	// Stream is Chat that calls onDelta with each piece of the answer as it
+	// arrives and returns the full text. Returning an error from onDelta
+	// stops the stream.
+	Stream(ctx context.Context, messages []Message, opts Options, onDelta func(string) error) (string, error)
+}
+
+// ErrEmptyResponse is returned when the provider answered without any text
+var ErrEmptyResponse = errors.New("ai: empty response")
+
+// APIError is a non-2xx answer from a provider's HTTP API
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ai: %s returned %d: %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// New returns the provider selected by cfg.Provider
+func New(cfg config.AIConfig) (Provider, error) {
+	client := &http.Client{Timeout: cfg.Timeout}
+	// A client's Timeout covers reading the body too, which would cut long
+	// streamed answers short. Streams only bound the wait for the response
+	// headers; the request context ends them when the caller goes away.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = cfg.Timeout
+	stream := &http.Client{Transport: transport}
+	switch cfg.Provider {
+	case "cohere":
+		return newCohere(cfg, client, stream), nil
+	case "openai":
+		return newOpenAI(cfg, client, stream), nil
+	case "fake":
+		return NewFake(), nil
+	default:
+		return nil, fmt.Errorf("ai: unknown provider %q", cfg.Provider)
+	}
+}
+
+// settings are the resolved model settings for one call
+type settings struct {
+	model       string
+	maxTokens   int
+	temperature float64
+}
+
+// responseFormat is the response_format field both HTTP APIs accept
+type responseFormat struct {
+	Type string `json:"type"`
+}
+
+func jsonFormat(opts Options) *responseFormat {
+	if !opts.JSON {
+		return nil
+	}
+	return &responseFormat{Type: "json_object"}
+}
+
+func resolve(cfg config.AIConfig, opts Options) settings {
+	s := settings{model: cfg.Model, maxTokens: cfg.MaxTokens, temperature: cfg.Temperature}
+	if opts.Model != "" {
+		s.model = opts.Model
+	}
+	if opts.MaxTokens > 0 {
+		s.maxTokens = opts.MaxTokens
+	}
+	if opts.Temperature != nil {
+		s.temperature = *opts.Temperature
+	}
+	return s
+}
+
+// splitSystem separates leading system messages from the conversation
+func splitSystem(messages []Message) (string, []Message) {
+	var system []string
+	for len(messages) > 0 && messages[0].Role == RoleSystem {
+		system = append(system, messages[0].Content)
+		messages = messages[1:]
+	}
+	return strings.Join(system, "\n\n"), messages
+}
+
+// postJSON sends body to url and returns the response, turning non-2xx
+// statuses into an *APIError
+func postJSON(ctx context.Context, client *http.Client, provider, url, apiKey string, body any) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ai: %s request failed: %w", provider, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return nil, &APIError{Provider: provider, StatusCode: resp.StatusCode, Message: errorMessage(raw)}
+	}
+	return resp, nil
+}
+
+// errorMessage extracts the message from the error bodies the supported
+// APIs return, falling back to the raw body
+func errorMessage(raw []byte) string {
+	var body struct {
+		Message string `json:"message"`
+		Error   any    `json:"error"`
+	}
+	if json.Unmarshal(raw, &body) == nil {
+		if body.Message != "" {
+			return body.Message
+		}
+		switch e := body.Error.(type) {
+		case string:
+			return e
+		case map[string]any:
+			if msg, ok := e["message"].(string); ok {
+				return msg
+			}
+		}
+	}
+	return strings.TrimSpace(string(raw))
+}
@@ -0,0 +1,147 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fitnesscoach/config"
+	"net/http"
+	"strings"
+)
+
+const (
+	cohereBaseURL = "https://api.cohere.ai/v1"
+	cohereModel   = "command"
+)
+
+// cohere uses Cohere's v1 chat API
+type cohere struct {
+	cfg     config.AIConfig
+	client  *http.Client
+	stream  *http.Client
+	baseURL string
+}
+
+type cohereTurn struct {
+	Role    string `json:"role"`
+	Message string `json:"message"`
+}
+
+type cohereChatRequest struct {
+	Message     string       `json:"message"`
+	ChatHistory []cohereTurn `json:"chat_history,omitempty"`
+	Preamble    string       `json:"preamble,omitempty"`
+	Model       string       `json:"model,omitempty"`
+	MaxTokens   int          `json:"max_tokens,omitempty"`
+	Temperature float64      `json:"temperature"`
+	Stream      bool         `json:"stream,omitempty"`
+
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+type cohereChatResponse struct {
+	Text string `json:"text"`
+}
+
+type cohereStreamEvent struct {
+	EventType string `json:"event_type"`
+	Text      string `json:"text"`
+}
+
+func newCohere(cfg config.AIConfig, client, stream *http.Client) *cohere {
+	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
+	// Older configs pointed at the legacy generate endpoint itself
+	baseURL = strings.TrimSuffix(baseURL, "/generate")
+	if baseURL == "" {
+		baseURL = cohereBaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = cohereModel
+	}
+	return &cohere{cfg: cfg, client: client, stream: stream, baseURL: baseURL}
+}
+
+func (c *cohere) Name() string { return "cohere" }
+
+func (c *cohere) Generate(ctx context.Context, prompt string, opts Options) (string, error) {
+	return c.Chat(ctx, []Message{{Role: RoleUser, Content: prompt}}, opts)
+}
+
+func (c *cohere) Chat(ctx context.Context, messages []Message, opts Options) (string, error) {
+	resp, err := postJSON(ctx, c.client, c.Name(), c.baseURL+"/chat", c.cfg.APIKey, c.request(messages, opts, false))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var out cohereChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(out.Text) == "" {
+		return "", ErrEmptyResponse
+	}
+	return out.Text, nil
+}
+
+// Stream reads Cohere's newline-delimited JSON events
+func (c *cohere) Stream(ctx context.Context, messages []Message, opts Options, onDelta func(string) error) (string, error) {
+	resp, err := postJSON(ctx, c.stream, c.Name(), c.baseURL+"/chat", c.cfg.APIKey, c.request(messages, opts, true))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		var event cohereStreamEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		if event.EventType == "stream-end" {
+			break
+		}
+		if event.EventType != "text-generation" || event.Text == "" {
+			continue
+		}
+		text.WriteString(event.Text)
+		if err := onDelta(event.Text); err != nil {
+			return text.String(), err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return text.String(), err
+	}
+	if strings.TrimSpace(text.String()) == "" {
+		return "", ErrEmptyResponse
+	}
+	return text.String(), nil
+}
+
+// request maps a conversation onto Cohere's message, history and preamble
+func (c *cohere) request(messages []Message, opts Options, stream bool) cohereChatRequest {
+	s := resolve(c.cfg, opts)
+	preamble, messages := splitSystem(messages)
+	req := cohereChatRequest{
+		Preamble:    preamble,
+		Model:       s.model,
+		MaxTokens:   s.maxTokens,
+		Temperature: s.temperature,
+		Stream:      stream,
+
+		ResponseFormat: jsonFormat(opts),
+	}
+	if n := len(messages); n > 0 {
+		req.Message = messages[n-1].Content
+		for _, m := range messages[:n-1] {
+			role := "USER"
+			if m.Role == RoleAssistant {
+				role = "CHATBOT"
+			}
+			req.ChatHistory = append(req.ChatHistory, cohereTurn{Role: role, Message: m.Content})
+		}
+	}
+	return req
+}
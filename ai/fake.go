@@ -0,0 +1,88 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Fake is a deterministic provider for tests and offline development. It
+// answers with Reply, or a canned reply quoting the last message. With
+// Record set it remembers every conversation it was given, which tests
+// read back through Calls. Asked for JSON without a Reply, it answers with
+// the first JSON object in the system instructions, so prompts that show
+// an example get a well-formed answer.
+type Fake struct {
+	Reply  func(messages []Message) string
+	Record bool
+
+	mu    sync.Mutex
+	calls [][]Message
+}
+
+// NewFake returns a fake provider with the default reply
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+func (f *Fake) Name() string { return "fake" }
+
+func (f *Fake) Generate(ctx context.Context, prompt string, opts Options) (string, error) {
+	return f.Chat(ctx, []Message{{Role: RoleUser, Content: prompt}}, opts)
+}
+
+func (f *Fake) Chat(ctx context.Context, messages []Message, opts Options) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return f.reply(messages, opts), nil
+}
+
+// Stream sends the reply one word at a time
+func (f *Fake) Stream(ctx context.Context, messages []Message, opts Options, onDelta func(string) error) (string, error) {
+	text := f.reply(messages, opts)
+	var sent strings.Builder
+	for _, word := range strings.SplitAfter(text, " ") {
+		if err := ctx.Err(); err != nil {
+			return sent.String(), err
+		}
+		sent.WriteString(word)
+		if err := onDelta(word); err != nil {
+			return sent.String(), err
+		}
+	}
+	return text, nil
+}
+
+// Calls returns the conversations the fake has answered, oldest first.
+// It is empty unless Record is set.
+func (f *Fake) Calls() [][]Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]Message(nil), f.calls...)
+}
+
+func (f *Fake) reply(messages []Message, opts Options) string {
+	if f.Record {
+		f.mu.Lock()
+		f.calls = append(f.calls, append([]Message(nil), messages...))
+		f.mu.Unlock()
+	}
+
+	if f.Reply != nil {
+		return f.Reply(messages)
+	}
+	if opts.JSON {
+		system, _ := splitSystem(messages)
+		if example, err := ExtractJSON(system); err == nil {
+			return example
+		}
+		return "{}"
+	}
+	var last string
+	if n := len(messages); n > 0 {
+		last = messages[n-1].Content
+	}
+	return fmt.Sprintf("Offline coach here. You said: %q. Keep training consistently, eat enough protein and get some sleep.", last)
+}
@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"context"
+	"strings"
+)
+
+// EstimateTokens approximates the token count of text. Providers tokenize
+// differently; four characters per token is close enough for budgeting.
+func EstimateTokens(text string) int {
+	return (len(text)+3)/4 + 4 // plus a little per-message overhead
+}
+
+// Window splits a conversation, oldest first, into the older turns that
+// no longer fit in budget tokens and the recent turns that do. The last
+// message is always kept, even when it alone exceeds the budget.
+func Window(messages []Message, budget int) (older, recent []Message) {
+	used := 0
+	start := len(messages)
+	for i := len(messages) - 1; i >= 0; i-- {
+		used += EstimateTokens(messages[i].Content)
+		if used > budget && i < len(messages)-1 {
+			break
+		}
+		start = i
+	}
+	return messages[:start], messages[start:]
+}
+
+const summaryInstructions = `You maintain the running summary of a conversation between a member and
+their AI fitness coach. Merge the previous summary with the new turns into
+one short summary of at most 150 words. Keep facts about the member (goals,
+injuries, preferences, equipment, schedule) and any advice or plans that
+were agreed. Reply with the summary only.`
+
+// Summarize folds turns into the previous running summary
+func Summarize(ctx context.Context, p Provider, previous string, turns []Message) (string, error) {
+	var b strings.Builder
+	if previous != "" {
+		b.WriteString("Previous summary:\n" + previous + "\n\n")
+	}
+	b.WriteString("New turns:\n")
+	for _, m := range turns {
+		b.WriteString(m.Role + ": " + m.Content + "\n")
+	}
+
+	summary, err := p.Chat(ctx, []Message{
+		{Role: RoleSystem, Content: summaryInstructions},
+		{Role: RoleUser, Content: b.String()},
+	}, Options{MaxTokens: 300})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(summary), nil
+}
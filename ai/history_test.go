@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWindow(t *testing.T) {
+	// Twelve characters estimate to 7 tokens
+	turn := func(content string) Message { return Message{Role: RoleUser, Content: content} }
+	a, b, c := turn("aaaaaaaaaaaa"), turn("bbbbbbbbbbbb"), turn("cccccccccccc")
+
+	tests := []struct {
+		name          string
+		messages      []Message
+		budget        int
+		older, recent []Message
+	}{
+		{"empty", nil, 100, nil, nil},
+		{"everything fits", []Message{a, b, c}, 21, nil, []Message{a, b, c}},
+		{"oldest dropped", []Message{a, b, c}, 20, []Message{a}, []Message{b, c}},
+		{"only the last fits", []Message{a, b, c}, 13, []Message{a, b}, []Message{c}},
+		{"last kept over budget", []Message{a, b, c}, 0, []Message{a, b}, []Message{c}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			older, recent := Window(tt.messages, tt.budget)
+			if !sameMessages(older, tt.older) || !sameMessages(recent, tt.recent) {
+				t.Errorf("Window(budget %d) = %v, %v; want %v, %v", tt.budget, older, recent, tt.older, tt.recent)
+			}
+		})
+	}
+}
+
+func sameMessages(got, want []Message) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSummarize(t *testing.T) {
+	turns := []Message{
+		{Role: RoleUser, Content: "My knee hurts on squats"},
+		{Role: RoleAssistant, Content: "Try box squats for now"},
+	}
+	tests := []struct {
+		name, previous string
+		want, absent   []string
+	}{
+		{
+			name:   "first summary",
+			want:   []string{"New turns:\nuser: My knee hurts on squats\nassistant: Try box squats for now\n"},
+			absent: []string{"Previous summary"},
+		},
+		{
+			name:     "folds in the previous summary",
+			previous: "Trains three times a week.",
+			want:     []string{"Previous summary:\nTrains three times a week.\n\nNew turns:\nuser: My knee hurts on squats\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &Fake{Record: true, Reply: replies("  Knee pain on squats; switched to box squats.\n")}
+
+			summary, err := Summarize(context.Background(), fake, tt.previous, turns)
+			if err != nil {
+				t.Fatalf("Summarize: %v", err)
+			}
+			if summary != "Knee pain on squats; switched to box squats." {
+				t.Errorf("summary = %q", summary)
+			}
+
+			calls := fake.Calls()
+			if len(calls) != 1 || len(calls[0]) != 2 {
+				t.Fatalf("calls = %+v, want one call with two messages", calls)
+			}
+			system, prompt := calls[0][0], calls[0][1]
+			if system.Role != RoleSystem || system.Content != summaryInstructions {
+				t.Errorf("system message = %+v", system)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(prompt.Content, s) {
+					t.Errorf("prompt %q does not contain %q", prompt.Content, s)
+				}
+			}
+			for _, s := range tt.absent {
+				if strings.Contains(prompt.Content, s) {
+					t.Errorf("prompt %q contains %q", prompt.Content, s)
+				}
+			}
+		})
+	}
+}
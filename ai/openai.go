@@ -0,0 +1,131 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fitnesscoach/config"
+	"net/http"
+	"strings"
+)
+
+const (
+	openAIBaseURL = "https://api.openai.com/v1"
+	openAIModel   = "gpt-4o-mini"
+)
+
+// openAI speaks the chat completions API, which OpenAI and most local
+// model servers (llama.cpp, Ollama, vLLM, LM Studio) implement
+type openAI struct {
+	cfg     config.AIConfig
+	client  *http.Client
+	stream  *http.Client
+	baseURL string
+}
+
+type openAIRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Temperature float64   `json:"temperature"`
+	Stream      bool      `json:"stream,omitempty"`
+
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message Message `json:"message"`
+		Delta   Message `json:"delta"`
+	} `json:"choices"`
+}
+
+func newOpenAI(cfg config.AIConfig, client, stream *http.Client) *openAI {
+	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = openAIBaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = openAIModel
+	}
+	return &openAI{cfg: cfg, client: client, stream: stream, baseURL: baseURL}
+}
+
+func (o *openAI) Name() string { return "openai" }
+
+func (o *openAI) Generate(ctx context.Context, prompt string, opts Options) (string, error) {
+	return o.Chat(ctx, []Message{{Role: RoleUser, Content: prompt}}, opts)
+}
+
+func (o *openAI) Chat(ctx context.Context, messages []Message, opts Options) (string, error) {
+	resp, err := postJSON(ctx, o.client, o.Name(), o.baseURL+"/chat/completions", o.cfg.APIKey, o.request(messages, opts, false))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var out openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	if len(out.Choices) == 0 || strings.TrimSpace(out.Choices[0].Message.Content) == "" {
+		return "", ErrEmptyResponse
+	}
+	return out.Choices[0].Message.Content, nil
+}
+
+// Stream reads the server-sent events of a streamed completion
+func (o *openAI) Stream(ctx context.Context, messages []Message, opts Options, onDelta func(string) error) (string, error) {
+	resp, err := postJSON(ctx, o.stream, o.Name(), o.baseURL+"/chat/completions", o.cfg.APIKey, o.request(messages, opts, true))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		data, ok := bytes.CutPrefix(scanner.Bytes(), []byte("data:"))
+		if !ok {
+			continue
+		}
+		data = bytes.TrimSpace(data)
+		if string(data) == "[DONE]" {
+			break
+		}
+		var chunk openAIResponse
+		if err := json.Unmarshal(data, &chunk); err != nil || len(chunk.Choices) == 0 {
+			continue
+		}
+		delta := chunk.Choices[0].Delta.Content
+		if delta == "" {
+			continue
+		}
+		text.WriteString(delta)
+		if err := onDelta(delta); err != nil {
+			return text.String(), err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return text.String(), err
+	}
+	if strings.TrimSpace(text.String()) == "" {
+		return "", ErrEmptyResponse
+	}
+	return text.String(), nil
+}
+
+func (o *openAI) request(messages []Message, opts Options, stream bool) openAIRequest {
+	s := resolve(o.cfg, opts)
+	return openAIRequest{
+		Model:       s.model,
+		Messages:    messages,
+		MaxTokens:   s.maxTokens,
+		Temperature: s.temperature,
+		Stream:      stream,
+
+		ResponseFormat: jsonFormat(opts),
+	}
+}
@@ -0,0 +1,89 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoJSON is returned when an answer contains no JSON object
+var ErrNoJSON = errors.New("ai: answer contains no JSON object")
+
+// InvalidOutputError is returned by ChatJSON when no attempt produced an
+// acceptable answer. Problems are those of the last attempt.
+type InvalidOutputError struct {
+	Attempts int
+	Problems []string
+}
+
+func (e *InvalidOutputError) Error() string {
+	return fmt.Sprintf("ai: no valid answer after %d attempts: %s", e.Attempts, strings.Join(e.Problems, "; "))
+}
+
+// ExtractJSON returns the first complete JSON object in text, skipping the
+// Markdown fences and chatter models like to wrap around it
+func ExtractJSON(text string) (string, error) {
+	start := strings.Index(text, "{")
+	if start < 0 {
+		return "", ErrNoJSON
+	}
+	depth, inString, escaped := 0, false, false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return text[start : i+1], nil
+			}
+		}
+	}
+	return "", ErrNoJSON
+}
+
+// ChatJSON asks p for a JSON object and decodes it into a new T. An answer
+// that does not decode, or that check rejects, is sent back with the
+// problems found so the model can repair it, up to attempts times in all.
+func ChatJSON[T any](ctx context.Context, p Provider, messages []Message, opts Options, attempts int, check func(*T) []string) (*T, error) {
+	opts.JSON = true
+	messages = append([]Message(nil), messages...)
+
+	var problems []string
+	for attempt := 0; attempt < attempts; attempt++ {
+		reply, err := p.Chat(ctx, messages, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		v := new(T)
+		problems = nil
+		if raw, err := ExtractJSON(reply); err != nil {
+			problems = []string{"the answer must be a single JSON object"}
+		} else if err := json.Unmarshal([]byte(raw), v); err != nil {
+			problems = []string{"the JSON does not match the schema: " + err.Error()}
+		} else if check != nil {
+			problems = check(v)
+		}
+		if len(problems) == 0 {
+			return v, nil
+		}
+
+		messages = append(messages,
+			Message{Role: RoleAssistant, Content: reply},
+			Message{Role: RoleUser, Content: "That answer is invalid:\n- " + strings.Join(problems, "\n- ") +
+				"\nReply with the corrected JSON object only."},
+		)
+	}
+	return nil, &InvalidOutputError{Attempts: attempts, Problems: problems}
+}
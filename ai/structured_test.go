@@ -0,0 +1,140 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+		err              error
+	}{
+		{"bare", `{"a":1}`, `{"a":1}`, nil},
+		{"fenced", "Here you go:\n```json\n{\"a\":1}\n```\nEnjoy!", `{"a":1}`, nil},
+		{"nested", `x {"a":{"b":[1,2]}} {"c":3}`, `{"a":{"b":[1,2]}}`, nil},
+		{"braces in strings", `{"a":"}{","b":"\"}"}`, `{"a":"}{","b":"\"}"}`, nil},
+		{"no object", "sorry, I can't", "", ErrNoJSON},
+		{"unterminated", `{"a":{"b":1}`, "", ErrNoJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractJSON(tt.text)
+			if !errors.Is(err, tt.err) || got != tt.want {
+				t.Errorf("ExtractJSON(%q) = %q, %v; want %q, %v", tt.text, got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+type plan struct {
+	Reps int `json:"reps"`
+}
+
+func checkPlan(p *plan) []string {
+	if p.Reps <= 0 {
+		return []string{"reps must be positive"}
+	}
+	return nil
+}
+
+// replies returns a Reply func answering with each reply in turn
+func replies(answers ...string) func([]Message) string {
+	n := 0
+	return func([]Message) string {
+		answer := answers[min(n, len(answers)-1)]
+		n++
+		return answer
+	}
+}
+
+func TestChatJSONRepairs(t *testing.T) {
+	answers := []string{
+		"I think five reps",
+		`{"reps": "five"}`,
+		`{"reps": 0}`,
+		"```json\n{\"reps\": 5}\n```",
+	}
+	fake := &Fake{Record: true, Reply: replies(answers...)}
+	messages := []Message{{Role: RoleUser, Content: "plan my set"}}
+
+	got, err := ChatJSON(context.Background(), fake, messages, Options{}, 4, checkPlan)
+	if err != nil {
+		t.Fatalf("ChatJSON: %v", err)
+	}
+	if got.Reps != 5 {
+		t.Errorf("Reps = %d, want 5", got.Reps)
+	}
+	if len(messages) != 1 {
+		t.Errorf("caller's messages grew to %d", len(messages))
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 4 {
+		t.Fatalf("got %d calls, want 4", len(calls))
+	}
+	// Each retry adds the rejected answer and what was wrong with it
+	wantProblems := []string{
+		"the answer must be a single JSON object",
+		"the JSON does not match the schema",
+		"reps must be positive",
+	}
+	for i, problem := range wantProblems {
+		call := calls[i+1]
+		if len(call) != 1+2*(i+1) {
+			t.Fatalf("call %d has %d messages, want %d", i+1, len(call), 1+2*(i+1))
+		}
+		answer, repair := call[len(call)-2], call[len(call)-1]
+		if answer.Role != RoleAssistant || answer.Content != answers[i] {
+			t.Errorf("call %d: answer message = %+v, want %q", i+1, answer, answers[i])
+		}
+		if repair.Role != RoleUser || !strings.Contains(repair.Content, problem) {
+			t.Errorf("call %d: repair message %q does not mention %q", i+1, repair.Content, problem)
+		}
+	}
+}
+
+func TestChatJSONGivesUp(t *testing.T) {
+	fake := &Fake{Record: true, Reply: replies(`{"reps": -1}`)}
+
+	_, err := ChatJSON(context.Background(), fake, []Message{{Role: RoleUser, Content: "plan"}}, Options{}, 2, checkPlan)
+	var invalid *InvalidOutputError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("err = %v, want InvalidOutputError", err)
+	}
+	if invalid.Attempts != 2 || len(invalid.Problems) != 1 || invalid.Problems[0] != "reps must be positive" {
+		t.Errorf("err = %+v", invalid)
+	}
+	if n := len(fake.Calls()); n != 2 {
+		t.Errorf("got %d calls, want 2", n)
+	}
+}
+
+func TestChatJSONStopsOnProviderError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := ChatJSON[plan](ctx, NewFake(), nil, Options{}, 3, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestFakeAnswersWithPromptExample(t *testing.T) {
+	fake := NewFake()
+	messages := []Message{
+		{Role: RoleSystem, Content: `Answer like {"reps": 8} and nothing else.`},
+		{Role: RoleUser, Content: "plan"},
+	}
+	got, err := ChatJSON(context.Background(), fake, messages, Options{}, 1, checkPlan)
+	if err != nil {
+		t.Fatalf("ChatJSON: %v", err)
+	}
+	if got.Reps != 8 {
+		t.Errorf("Reps = %d, want 8", got.Reps)
+	}
+	if calls := fake.Calls(); len(calls) != 0 {
+		t.Errorf("fake recorded %d calls without Record set", len(calls))
+	}
+}
@@ -0,0 +1,369 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Environments selected with APP_ENV
+const (
+	EnvDev        = "dev"
+	EnvProduction = "production"
+)
+
+// Config holds every setting the server needs at startup
+type Config struct {
+	// Env is EnvDev on a developer's machine and EnvProduction anywhere
+	// else; only dev may run without SESSION_KEYS.
+	Env        string
+	ListenAddr string
+	Database   DatabaseConfig
+	Session    SessionConfig
+	AI         AIConfig
+	Features   FeatureFlags
+
+	// Args holds the positional arguments left after flag parsing
+	Args []string
+}
+
+// DatabaseConfig describes the SQL connection and its pool
+type DatabaseConfig struct {
+	Driver          string
+	DSN             string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	AutoMigrate     bool
+}
+
+// SessionConfig holds the cookie signing/encryption keys and cookie options
+type SessionConfig struct {
+	// KeyPairs alternates hash and block keys, current pair first, then
+	// older pairs that are still accepted while rotating.
+	KeyPairs [][]byte
+	// Ephemeral is set when dev mode made up KeyPairs for this run, so
+	// sessions end when the server restarts
+	Ephemeral bool
+	Secure    bool
+	HttpOnly  bool
+	SameSite  http.SameSite
+	MaxAge    time.Duration
+}
+
+// AIConfig selects and configures the AI chat provider: "cohere",
+// "openai" (any OpenAI-compatible server) or "fake" for offline use.
+// An empty BaseURL or Model means the provider's default.
+type AIConfig struct {
+	Provider    string
+	APIKey      string
+	BaseURL     string
+	Model       string
+	MaxTokens   int
+	Temperature float64
+	Timeout     time.Duration
+
+	// HistoryTokens is the budget for past turns sent with each message;
+	// older turns are summarised to stay within it.
+	HistoryTokens int
+
+	// ContextTemplate is the text/template file that turns the member's
+	// profile into the system prompt; it is re-read on every request.
+	ContextTemplate string
+}
+
+// FeatureFlags switch optional parts of the app on or off
+type FeatureFlags struct {
+	AIChat    bool
+	CoachChat bool
+}
+
+// Load reads configuration from the .env file, the environment and the
+// command line flags in args, in increasing order of precedence, and checks
+// everything the server needs.
+func Load(args []string) (*Config, error) {
+	cfg, err := load(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadDatabase reads configuration like Load but only checks the database
+// settings, for commands such as migrate that never serve requests
+func LoadDatabase(args []string) (*Config, error) {
+	cfg, err := load(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Database.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func load(args []string) (*Config, error) {
+	// A missing .env is fine: CI and staging set real environment variables
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading .env: %w", err)
+	}
+
+	var errs []error
+	env := envReader{errs: &errs}
+
+	cfg := &Config{
+		Env:        env.String("APP_ENV", EnvProduction),
+		ListenAddr: env.String("LISTEN_ADDR", ":8080"),
+		Database: DatabaseConfig{
+			Driver:          env.String("DATABASE_DRIVER", "mysql"),
+			DSN:             env.String("DATABASE_DSN", ""),
+			MaxOpenConns:    env.Int("DB_MAX_OPEN_CONNS", 10),
+			MaxIdleConns:    env.Int("DB_MAX_IDLE_CONNS", 5),
+			ConnMaxLifetime: env.Duration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
+			AutoMigrate:     env.Bool("DB_AUTO_MIGRATE", false),
+		},
+		Session: SessionConfig{
+			KeyPairs: env.KeyPairs("SESSION_KEYS"),
+			Secure:   env.Bool("SESSION_SECURE", true),
+			HttpOnly: env.Bool("SESSION_HTTP_ONLY", true),
+			SameSite: env.SameSite("SESSION_SAME_SITE", http.SameSiteLaxMode),
+			MaxAge:   env.Duration("SESSION_MAX_AGE", 7*24*time.Hour),
+		},
+		AI: AIConfig{
+			Provider:    env.String("AI_PROVIDER", "cohere"),
+			APIKey:      env.String("AI_API_KEY", os.Getenv("COHERE_API_KEY")),
+			BaseURL:     env.String("AI_BASE_URL", ""),
+			Model:       env.String("AI_MODEL", ""),
+			MaxTokens:   env.Int("AI_MAX_TOKENS", 800),
+			Temperature: env.Float("AI_TEMPERATURE", 0.7),
+			Timeout:     env.Duration("AI_TIMEOUT", 60*time.Second),
+
+			HistoryTokens: env.Int("AI_HISTORY_TOKENS", 2000),
+
+			ContextTemplate: env.String("AI_CONTEXT_TEMPLATE", "templates/ai_context.tmpl"),
+		},
+		Features: FeatureFlags{
+			AIChat:    env.Bool("FEATURE_AI_CHAT", true),
+			CoachChat: env.Bool("FEATURE_COACH_CHAT", true),
+		},
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	fs := flag.NewFlagSet("fitnesscoach", flag.ContinueOnError)
+	fs.StringVar(&cfg.ListenAddr, "addr", cfg.ListenAddr, "HTTP listen address (LISTEN_ADDR)")
+	fs.StringVar(&cfg.Database.Driver, "db", cfg.Database.Driver, "database backend: mysql, sqlite or memory (DATABASE_DRIVER)")
+	fs.StringVar(&cfg.Database.DSN, "dsn", cfg.Database.DSN, "MySQL DSN or SQLite file path (DATABASE_DSN)")
+	fs.StringVar(&cfg.AI.Provider, "ai-provider", cfg.AI.Provider, "AI provider: cohere, openai or fake (AI_PROVIDER)")
+	fs.BoolVar(&cfg.Database.AutoMigrate, "migrate", cfg.Database.AutoMigrate, "apply pending migrations on start (DB_AUTO_MIGRATE)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	cfg.Args = fs.Args()
+
+	if cfg.Env == EnvDev && len(cfg.Session.KeyPairs) == 0 {
+		pairs, err := randomKeyPair()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Session.KeyPairs, cfg.Session.Ephemeral = pairs, true
+	}
+	return cfg, nil
+}
+
+// Validate reports every invalid or missing setting at once
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Env != EnvDev && c.Env != EnvProduction {
+		errs = append(errs, fmt.Errorf("unknown APP_ENV %q (want dev or production)", c.Env))
+	}
+	if c.ListenAddr == "" {
+		errs = append(errs, errors.New("LISTEN_ADDR must not be empty"))
+	}
+	if err := c.Database.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(c.Session.KeyPairs) == 0 {
+		errs = append(errs, errors.New("SESSION_KEYS is required outside APP_ENV=dev (hashkey:blockkey[,oldhash:oldblock...] in hex)"))
+	}
+	if c.Session.SameSite == http.SameSiteNoneMode && !c.Session.Secure {
+		errs = append(errs, errors.New("SESSION_SAME_SITE=none requires SESSION_SECURE=true"))
+	}
+	if c.Features.AIChat {
+		switch c.AI.Provider {
+		case "cohere":
+			if c.AI.APIKey == "" {
+				errs = append(errs, errors.New("AI_API_KEY (or COHERE_API_KEY) is required for the cohere provider"))
+			}
+		case "openai", "fake":
+			// Local OpenAI-compatible servers usually need no key
+		default:
+			errs = append(errs, fmt.Errorf("unknown AI_PROVIDER %q (want cohere, openai or fake)", c.AI.Provider))
+		}
+		if c.AI.MaxTokens <= 0 {
+			errs = append(errs, errors.New("AI_MAX_TOKENS must be positive"))
+		}
+		if c.AI.Timeout <= 0 {
+			errs = append(errs, errors.New("AI_TIMEOUT must be positive"))
+		}
+		if c.AI.HistoryTokens <= 0 {
+			errs = append(errs, errors.New("AI_HISTORY_TOKENS must be positive"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate reports every invalid or missing database setting at once
+func (d DatabaseConfig) Validate() error {
+	var errs []error
+	switch d.Driver {
+	case "mysql", "sqlite":
+		if d.DSN == "" {
+			errs = append(errs, fmt.Errorf("DATABASE_DSN is required for the %s driver", d.Driver))
+		}
+	case "memory":
+	default:
+		errs = append(errs, fmt.Errorf("unknown DATABASE_DRIVER %q (want mysql, sqlite or memory)", d.Driver))
+	}
+	if d.MaxOpenConns < 0 || d.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// randomKeyPair makes a throwaway hash and block key for dev mode
+func randomKeyPair() ([][]byte, error) {
+	hashKey, blockKey := make([]byte, 32), make([]byte, 32)
+	if _, err := rand.Read(hashKey); err != nil {
+		return nil, fmt.Errorf("generating session keys: %w", err)
+	}
+	if _, err := rand.Read(blockKey); err != nil {
+		return nil, fmt.Errorf("generating session keys: %w", err)
+	}
+	return [][]byte{hashKey, blockKey}, nil
+}
+
+// envReader parses typed environment variables and collects parse errors
+type envReader struct {
+	errs *[]error
+}
+
+func (e envReader) String(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return strings.TrimSpace(v)
+	}
+	return def
+}
+
+func (e envReader) Int(key string, def int) int {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		*e.errs = append(*e.errs, fmt.Errorf("%s: %q is not an integer", key, v))
+		return def
+	}
+	return n
+}
+
+func (e envReader) Float(key string, def float64) float64 {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		*e.errs = append(*e.errs, fmt.Errorf("%s: %q is not a number", key, v))
+		return def
+	}
+	return f
+}
+
+func (e envReader) Bool(key string, def bool) bool {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		*e.errs = append(*e.errs, fmt.Errorf("%s: %q is not a boolean", key, v))
+		return def
+	}
+	return b
+}
+
+// KeyPairs parses a comma-separated list of hashkey:blockkey pairs in hex.
+// Hash keys must be 32 or 64 bytes and block (AES) keys 16, 24 or 32 bytes.
+func (e envReader) KeyPairs(key string) [][]byte {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return nil
+	}
+
+	var pairs [][]byte
+	for i, pair := range strings.Split(v, ",") {
+		hashHex, blockHex, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			*e.errs = append(*e.errs, fmt.Errorf("%s: pair %d must be hashkey:blockkey", key, i+1))
+			return nil
+		}
+		hashKey, err := hex.DecodeString(hashHex)
+		if err != nil || (len(hashKey) != 32 && len(hashKey) != 64) {
+			*e.errs = append(*e.errs, fmt.Errorf("%s: pair %d hash key must be 32 or 64 bytes of hex", key, i+1))
+			return nil
+		}
+		blockKey, err := hex.DecodeString(blockHex)
+		if err != nil || (len(blockKey) != 16 && len(blockKey) != 24 && len(blockKey) != 32) {
+			*e.errs = append(*e.errs, fmt.Errorf("%s: pair %d block key must be 16, 24 or 32 bytes of hex", key, i+1))
+			return nil
+		}
+		pairs = append(pairs, hashKey, blockKey)
+	}
+	return pairs
+}
+
+func (e envReader) SameSite(key string, def http.SameSite) http.SameSite {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return def
+	}
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		*e.errs = append(*e.errs, fmt.Errorf("%s: %q must be lax, strict or none", key, v))
+		return def
+	}
+}
+
+func (e envReader) Duration(key string, def time.Duration) time.Duration {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(v))
+	if err != nil {
+		*e.errs = append(*e.errs, fmt.Errorf("%s: %q is not a duration", key, v))
+		return def
+	}
+	return d
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+var (
+	hash32  = strings.Repeat("ab", 32)
+	hash64  = strings.Repeat("cd", 64)
+	block16 = strings.Repeat("01", 16)
+	block24 = strings.Repeat("02", 24)
+	block32 = strings.Repeat("03", 32)
+)
+
+// setEnv gives Load a minimal valid environment plus the overrides
+func setEnv(t *testing.T, overrides map[string]string) {
+	t.Helper()
+	env := map[string]string{
+		"APP_ENV":           EnvProduction,
+		"DATABASE_DRIVER":   "memory",
+		"FEATURE_AI_CHAT":   "false",
+		"SESSION_KEYS":      hash32 + ":" + block32,
+		"SESSION_SECURE":    "",
+		"SESSION_SAME_SITE": "",
+	}
+	for k, v := range overrides {
+		env[k] = v
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+}
+
+func TestSessionKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  [][]byte
+		err   string
+	}{
+		{
+			name:  "one pair",
+			value: hash32 + ":" + block32,
+			want:  [][]byte{bytes.Repeat([]byte{0xab}, 32), bytes.Repeat([]byte{0x03}, 32)},
+		},
+		{
+			name:  "rotated pairs, newest first",
+			value: hash64 + ":" + block16 + ", " + hash32 + ":" + block24,
+			want: [][]byte{
+				bytes.Repeat([]byte{0xcd}, 64), bytes.Repeat([]byte{0x01}, 16),
+				bytes.Repeat([]byte{0xab}, 32), bytes.Repeat([]byte{0x02}, 24),
+			},
+		},
+		{name: "missing", value: "", err: "SESSION_KEYS is required"},
+		{name: "no block key", value: hash32, err: "pair 1 must be hashkey:blockkey"},
+		{name: "bad second pair", value: hash32 + ":" + block32 + "," + hash32, err: "pair 2 must be hashkey:blockkey"},
+		{name: "not hex", value: strings.Repeat("zz", 32) + ":" + block32, err: "pair 1 hash key must be 32 or 64 bytes"},
+		{name: "short hash key", value: strings.Repeat("ab", 16) + ":" + block32, err: "pair 1 hash key must be 32 or 64 bytes"},
+		{name: "odd block key", value: hash32 + ":" + strings.Repeat("01", 20), err: "pair 1 block key must be 16, 24 or 32 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, map[string]string{"SESSION_KEYS": tt.value})
+			cfg, err := Load(nil)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Load error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if len(cfg.Session.KeyPairs) != len(tt.want) {
+				t.Fatalf("got %d keys, want %d", len(cfg.Session.KeyPairs), len(tt.want))
+			}
+			for i := range tt.want {
+				if !bytes.Equal(cfg.Session.KeyPairs[i], tt.want[i]) {
+					t.Errorf("key %d = %x, want %x", i, cfg.Session.KeyPairs[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSessionCookieOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		secure   bool
+		sameSite http.SameSite
+		err      string
+	}{
+		{name: "defaults", secure: true, sameSite: http.SameSiteLaxMode},
+		{name: "strict", env: map[string]string{"SESSION_SAME_SITE": "Strict"}, secure: true, sameSite: http.SameSiteStrictMode},
+		{name: "insecure for local http", env: map[string]string{"SESSION_SECURE": "false"}, sameSite: http.SameSiteLaxMode},
+		{name: "none needs secure", env: map[string]string{"SESSION_SAME_SITE": "none", "SESSION_SECURE": "false"}, err: "requires SESSION_SECURE=true"},
+		{name: "unknown same site", env: map[string]string{"SESSION_SAME_SITE": "sometimes"}, err: "must be lax, strict or none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+			cfg, err := Load(nil)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Load error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Session.Secure != tt.secure || cfg.Session.SameSite != tt.sameSite || !cfg.Session.HttpOnly {
+				t.Errorf("session = %+v", cfg.Session)
+			}
+		})
+	}
+}
+
+func TestDevSessionKeys(t *testing.T) {
+	setEnv(t, map[string]string{"APP_ENV": EnvDev, "SESSION_KEYS": ""})
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.Session.Ephemeral || len(cfg.Session.KeyPairs) != 2 {
+		t.Errorf("dev session = %+v, want one random pair", cfg.Session)
+	}
+	other, _ := Load(nil)
+	if bytes.Equal(cfg.Session.KeyPairs[0], other.Session.KeyPairs[0]) {
+		t.Error("two dev runs made the same hash key")
+	}
+
+	// Configured keys are used as they are
+	setEnv(t, map[string]string{"APP_ENV": EnvDev})
+	if cfg, err := Load(nil); err != nil || cfg.Session.Ephemeral {
+		t.Errorf("dev with SESSION_KEYS: ephemeral %v, err %v", cfg != nil && cfg.Session.Ephemeral, err)
+	}
+
+	for _, appEnv := range []string{EnvProduction, "staging"} {
+		setEnv(t, map[string]string{"APP_ENV": appEnv, "SESSION_KEYS": ""})
+		if _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "SESSION_KEYS is required") {
+			t.Errorf("APP_ENV=%q without SESSION_KEYS: err = %v", appEnv, err)
+		}
+	}
+}
+
+func TestLoadDatabase(t *testing.T) {
+	// migrate runs without the server's settings
+	setEnv(t, map[string]string{"SESSION_KEYS": "", "FEATURE_AI_CHAT": "true", "AI_PROVIDER": "cohere", "AI_API_KEY": ""})
+	if _, err := Load(nil); err == nil {
+		t.Fatal("Load accepted a server config without SESSION_KEYS or an AI key")
+	}
+	cfg, err := LoadDatabase([]string{"down", "1"})
+	if err != nil {
+		t.Fatalf("LoadDatabase: %v", err)
+	}
+	if cfg.Database.Driver != "memory" || len(cfg.Args) != 2 {
+		t.Errorf("database = %+v, args %v", cfg.Database, cfg.Args)
+	}
+
+	// but still needs a usable database
+	setEnv(t, map[string]string{"DATABASE_DRIVER": "sqlite", "DATABASE_DSN": ""})
+	if _, err := LoadDatabase(nil); err == nil || !strings.Contains(err.Error(), "DATABASE_DSN is required") {
+		t.Errorf("LoadDatabase without a DSN: err = %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// CardioSession is one logged cardio workout. Pace and speed are derived
+// from duration and distance and are not stored.
+type CardioSession struct {
+	ID               int64     `json:"id"`
+	Activity         string    `json:"activity"`
+	StartedAt        time.Time `json:"startedAt"`
+	DurationSeconds  int       `json:"durationSeconds"`
+	DistanceKM       *float64  `json:"distanceKm,omitempty"`
+	ElevationM       *float64  `json:"elevationM,omitempty"`
+	AvgHR            *int      `json:"avgHr,omitempty"`
+	MaxHR            *int      `json:"maxHr,omitempty"`
+	Calories         *int      `json:"calories,omitempty"`
+	Notes            string    `json:"notes"`
+	PaceSecondsPerKM *float64  `json:"paceSecondsPerKm,omitempty"`
+	SpeedKMH         *float64  `json:"speedKmh,omitempty"`
+}
+
+// CardioBest holds the personal bests for one activity
+type CardioBest struct {
+	Activity                string   `json:"activity"`
+	Sessions                int      `json:"sessions"`
+	LongestDistanceKM       *float64 `json:"longestDistanceKm,omitempty"`
+	LongestDurationSeconds  int      `json:"longestDurationSeconds"`
+	FastestPaceSecondsPerKM *float64 `json:"fastestPaceSecondsPerKm,omitempty"`
+	HighestCalories         *int     `json:"highestCalories,omitempty"`
+	HighestElevationM       *float64 `json:"highestElevationM,omitempty"`
+}
+
+// CardioWeek totals the cardio done in one Monday-to-Sunday week
+type CardioWeek struct {
+	WeekStart       string         `json:"weekStart"`
+	Sessions        int            `json:"sessions"`
+	DurationSeconds int            `json:"durationSeconds"`
+	DistanceKM      float64        `json:"distanceKm"`
+	Calories        int            `json:"calories"`
+	ByActivity      map[string]int `json:"minutesByActivity"`
+}
+
+// CardioStore persists cardio sessions
+type CardioStore interface {
+	AddCardioSession(userID int64, c CardioSession) (int64, error)
+	UpdateCardioSession(userID int64, c CardioSession) error
+	DeleteCardioSession(userID, id int64) error
+	ListCardioSessions(userID int64, from, to time.Time, limit int) ([]CardioSession, error)
+	CardioBests(userID int64) ([]CardioBest, error)
+}
+
+// derive fills in pace and speed when the session has a distance
+func (c *CardioSession) derive() {
+	if c.DistanceKM == nil || *c.DistanceKM <= 0 || c.DurationSeconds <= 0 {
+		return
+	}
+	pace := round1(float64(c.DurationSeconds) / *c.DistanceKM)
+	speed := round1(*c.DistanceKM / (float64(c.DurationSeconds) / 3600))
+	c.PaceSecondsPerKM, c.SpeedKMH = &pace, &speed
+}
+
+// AddCardioSession stores a cardio session
+func (s *SQLStore) AddCardioSession(userID int64, c CardioSession) (int64, error) {
+	if c.StartedAt.IsZero() {
+		c.StartedAt = time.Now()
+	}
+	result, err := s.db.Exec(`INSERT INTO cardio_sessions
+		(user_id, activity, started_at, duration_seconds, distance_km, elevation_m, avg_hr, max_hr, calories, notes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, c.Activity, c.StartedAt.UTC(), c.DurationSeconds, c.DistanceKM, c.ElevationM, c.AvgHR, c.MaxHR, c.Calories, c.Notes)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	s.refreshGoalsAfter(userID)
+	return id, nil
+}
+
+// UpdateCardioSession overwrites one of the user's cardio sessions
+func (s *SQLStore) UpdateCardioSession(userID int64, c CardioSession) error {
+	result, err := s.db.Exec(`UPDATE cardio_sessions SET activity = ?, started_at = ?, duration_seconds = ?,
+		distance_km = ?, elevation_m = ?, avg_hr = ?, max_hr = ?, calories = ?, notes = ?
+		WHERE id = ? AND user_id = ?`,
+		c.Activity, c.StartedAt.UTC(), c.DurationSeconds, c.DistanceKM, c.ElevationM, c.AvgHR, c.MaxHR, c.Calories, c.Notes,
+		c.ID, userID)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(result); err != nil {
+		return err
+	}
+	s.refreshGoalsAfter(userID)
+	return nil
+}
+
+// DeleteCardioSession removes one of the user's cardio sessions
+func (s *SQLStore) DeleteCardioSession(userID, id int64) error {
+	result, err := s.db.Exec("DELETE FROM cardio_sessions WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(result); err != nil {
+		return err
+	}
+	s.refreshGoalsAfter(userID)
+	return nil
+}
+
+// ListCardioSessions returns sessions started in [from, to), newest first.
+// Zero bounds are open and a limit of 0 means no limit.
+func (s *SQLStore) ListCardioSessions(userID int64, from, to time.Time, limit int) ([]CardioSession, error) {
+	query := `SELECT id, activity, started_at, duration_seconds, distance_km, elevation_m, avg_hr, max_hr, calories, notes
+		FROM cardio_sessions WHERE user_id = ?`
+	args := []any{userID}
+	if !from.IsZero() {
+		query += " AND started_at >= ?"
+		args = append(args, from.UTC())
+	}
+	if !to.IsZero() {
+		query += " AND started_at < ?"
+		args = append(args, to.UTC())
+	}
+	query += " ORDER BY started_at DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []CardioSession{}
+	for rows.Next() {
+		var c CardioSession
+		var distance, elevation sql.NullFloat64
+		var avgHR, maxHR, calories sql.NullInt64
+		err := rows.Scan(&c.ID, &c.Activity, &c.StartedAt, &c.DurationSeconds, &distance, &elevation,
+			&avgHR, &maxHR, &calories, &c.Notes)
+		if err != nil {
+			return nil, err
+		}
+		c.DistanceKM, c.ElevationM = nullFloat(distance), nullFloat(elevation)
+		c.AvgHR, c.MaxHR, c.Calories = nullInt(avgHR), nullInt(maxHR), nullInt(calories)
+		c.derive()
+		sessions = append(sessions, c)
+	}
+	return sessions, rows.Err()
+}
+
+// CardioBests returns per-activity personal bests. Fastest pace only
+// counts sessions of at least one kilometre so short sprints don't win.
+func (s *SQLStore) CardioBests(userID int64) ([]CardioBest, error) {
+	rows, err := s.db.Query(`
+		SELECT activity, COUNT(*), MAX(distance_km), MAX(duration_seconds),
+			MIN(CASE WHEN distance_km >= 1 THEN duration_seconds / distance_km END),
+			MAX(calories), MAX(elevation_m)
+		FROM cardio_sessions
+		WHERE user_id = ?
+		GROUP BY activity
+		ORDER BY activity`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bests := []CardioBest{}
+	for rows.Next() {
+		var b CardioBest
+		var distance, pace, elevation sql.NullFloat64
+		var calories sql.NullInt64
+		err := rows.Scan(&b.Activity, &b.Sessions, &distance, &b.LongestDurationSeconds, &pace, &calories, &elevation)
+		if err != nil {
+			return nil, err
+		}
+		b.LongestDistanceKM, b.HighestElevationM = nullFloat(distance), nullFloat(elevation)
+		b.HighestCalories = nullInt(calories)
+		if pace.Valid {
+			p := round1(pace.Float64)
+			b.FastestPaceSecondsPerKM = &p
+		}
+		bests = append(bests, b)
+	}
+	return bests, rows.Err()
+}
+
+// WeeklyCardioVolume buckets sessions into Monday-based local weeks,
+// oldest first, including empty weeks between from and now.
+func WeeklyCardioVolume(sessions []CardioSession, from time.Time) []CardioWeek {
+	weekStart := func(t time.Time) time.Time {
+		t = t.Local()
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.Local)
+	}
+
+	var weeks []CardioWeek
+	index := make(map[string]int)
+	for w := weekStart(from); !w.After(time.Now()); w = w.AddDate(0, 0, 7) {
+		key := w.Format(dateLayout)
+		index[key] = len(weeks)
+		weeks = append(weeks, CardioWeek{WeekStart: key, ByActivity: map[string]int{}})
+	}
+
+	for _, c := range sessions {
+		i, ok := index[weekStart(c.StartedAt).Format(dateLayout)]
+		if !ok {
+			continue
+		}
+		week := &weeks[i]
+		week.Sessions++
+		week.DurationSeconds += c.DurationSeconds
+		week.ByActivity[c.Activity] += c.DurationSeconds / 60
+		if c.DistanceKM != nil {
+			week.DistanceKM = round1(week.DistanceKM + *c.DistanceKM)
+		}
+		if c.Calories != nil {
+			week.Calories += *c.Calories
+		}
+	}
+	return weeks
+}
+
+func nullInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	i := int(v.Int64)
+	return &i
+}
+
+func AddCardioSession(userID int64, c CardioSession) (int64, error) {
+	return current.AddCardioSession(userID, c)
+}
+
+func UpdateCardioSession(userID int64, c CardioSession) error {
+	return current.UpdateCardioSession(userID, c)
+}
+
+func DeleteCardioSession(userID, id int64) error {
+	return current.DeleteCardioSession(userID, id)
+}
+
+func ListCardioSessions(userID int64, from, to time.Time, limit int) ([]CardioSession, error) {
+	return current.ListCardioSessions(userID, from, to, limit)
+}
+
+func CardioBests(userID int64) ([]CardioBest, error) {
+	return current.CardioBests(userID)
+}
@@ -0,0 +1,210 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AIConversation is a saved multi-turn chat with the AI coach
+type AIConversation struct {
+	ID                int64     `json:"id"`
+	Title             string    `json:"title"`
+	Summary           string    `json:"summary,omitempty"`
+	SummarizedThrough int64     `json:"summarizedThrough"`
+	MessageCount      int       `json:"messageCount"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+// AIMessage is one turn of an AI conversation
+type AIMessage struct {
+	ID        int64     `json:"id"`
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ConversationStore persists AI conversations and their messages
+type ConversationStore interface {
+	CreateConversation(userID int64, title string) (*AIConversation, error)
+	ListConversations(userID int64) ([]AIConversation, error)
+	GetConversation(userID, id int64) (*AIConversation, error)
+	RenameConversation(userID, id int64, title string) error
+	DeleteConversation(userID, id int64) error
+	AddConversationMessage(userID, conversationID int64, role, content string) (*AIMessage, error)
+	ConversationMessages(userID, conversationID, afterID int64) ([]AIMessage, error)
+	SaveConversationSummary(userID, conversationID int64, summary string, through int64) error
+}
+
+// CreateConversation starts an empty conversation
+func (s *SQLStore) CreateConversation(userID int64, title string) (*AIConversation, error) {
+	now := time.Now().UTC()
+	result, err := s.db.Exec(`INSERT INTO ai_conversations (user_id, title, summary, created_at, updated_at)
+		VALUES (?, ?, '', ?, ?)`, userID, title, now, now)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &AIConversation{ID: id, Title: title, CreatedAt: now, UpdatedAt: now}, nil
+}
+
+const conversationColumns = `c.id, c.title, c.summary, c.summarized_through, c.created_at, c.updated_at,
+	(SELECT COUNT(*) FROM ai_messages m WHERE m.conversation_id = c.id)`
+
+func scanConversation(row interface{ Scan(...any) error }) (*AIConversation, error) {
+	var c AIConversation
+	err := row.Scan(&c.ID, &c.Title, &c.Summary, &c.SummarizedThrough, &c.CreatedAt, &c.UpdatedAt, &c.MessageCount)
+	return &c, err
+}
+
+// ListConversations returns the user's conversations, most recently
+// active first
+func (s *SQLStore) ListConversations(userID int64) ([]AIConversation, error) {
+	rows, err := s.db.Query(`SELECT `+conversationColumns+`
+		FROM ai_conversations c WHERE c.user_id = ?
+		ORDER BY c.updated_at DESC, c.id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conversations := []AIConversation{}
+	for rows.Next() {
+		c, err := scanConversation(rows)
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, *c)
+	}
+	return conversations, rows.Err()
+}
+
+// GetConversation loads one of the user's conversations without messages
+func (s *SQLStore) GetConversation(userID, id int64) (*AIConversation, error) {
+	c, err := scanConversation(s.db.QueryRow(`SELECT `+conversationColumns+`
+		FROM ai_conversations c WHERE c.id = ? AND c.user_id = ?`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// RenameConversation changes a conversation's title
+func (s *SQLStore) RenameConversation(userID, id int64, title string) error {
+	result, err := s.db.Exec("UPDATE ai_conversations SET title = ? WHERE id = ? AND user_id = ?", title, id, userID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// DeleteConversation removes a conversation and all of its messages
+func (s *SQLStore) DeleteConversation(userID, id int64) error {
+	result, err := s.db.Exec("DELETE FROM ai_conversations WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// AddConversationMessage appends a turn and marks the conversation active
+func (s *SQLStore) AddConversationMessage(userID, conversationID int64, role, content string) (*AIMessage, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	result, err := tx.Exec("UPDATE ai_conversations SET updated_at = ? WHERE id = ? AND user_id = ?", now, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectRow(result); err != nil {
+		return nil, err
+	}
+
+	result, err = tx.Exec(`INSERT INTO ai_messages (conversation_id, role, content, created_at) VALUES (?, ?, ?, ?)`,
+		conversationID, role, content, now)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &AIMessage{ID: id, Role: role, Content: content, CreatedAt: now}, tx.Commit()
+}
+
+// ConversationMessages returns the messages after afterID, oldest first.
+// Pass 0 for the whole conversation.
+func (s *SQLStore) ConversationMessages(userID, conversationID, afterID int64) ([]AIMessage, error) {
+	rows, err := s.db.Query(`SELECT m.id, m.role, m.content, m.created_at
+		FROM ai_messages m
+		JOIN ai_conversations c ON c.id = m.conversation_id
+		WHERE c.id = ? AND c.user_id = ? AND m.id > ?
+		ORDER BY m.id`, conversationID, userID, afterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []AIMessage{}
+	for rows.Next() {
+		var m AIMessage
+		if err := rows.Scan(&m.ID, &m.Role, &m.Content, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+// SaveConversationSummary replaces the summary of the older turns, which
+// now covers every message up to and including through
+func (s *SQLStore) SaveConversationSummary(userID, conversationID int64, summary string, through int64) error {
+	result, err := s.db.Exec(`UPDATE ai_conversations SET summary = ?, summarized_through = ?
+		WHERE id = ? AND user_id = ?`, summary, through, conversationID, userID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+func CreateConversation(userID int64, title string) (*AIConversation, error) {
+	return current.CreateConversation(userID, title)
+}
+
+func ListConversations(userID int64) ([]AIConversation, error) {
+	return current.ListConversations(userID)
+}
+
+func GetConversation(userID, id int64) (*AIConversation, error) {
+	return current.GetConversation(userID, id)
+}
+
+func RenameConversation(userID, id int64, title string) error {
+	return current.RenameConversation(userID, id, title)
+}
+
+func DeleteConversation(userID, id int64) error {
+	return current.DeleteConversation(userID, id)
+}
+
+func AddConversationMessage(userID, conversationID int64, role, content string) (*AIMessage, error) {
+	return current.AddConversationMessage(userID, conversationID, role, content)
+}
+
+func ConversationMessages(userID, conversationID, afterID int64) ([]AIMessage, error) {
+	return current.ConversationMessages(userID, conversationID, afterID)
+}
+
+func SaveConversationSummary(userID, conversationID int64, summary string, through int64) error {
+	return current.SaveConversationSummary(userID, conversationID, summary, through)
+}
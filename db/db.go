@@ -7,32 +7,29 @@ import (
 	"log"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var db *sql.DB
+// SQLStore implements Store on top of database/sql for MySQL and SQLite
+type SQLStore struct {
+	db      *sql.DB
+	dialect dialect
+}
 
-// InitDB initializes the global DB connection
-func InitDB() error {
-	var err error
-	dsn := "root:keshav@tcp(127.0.0.1:3306)/webtech4"
-	db, err = sql.Open("mysql", dsn)
-	if err != nil {
-		return err
-	}
-	return db.Ping()
+// Close releases the underlying connection pool
+func (s *SQLStore) Close() error {
+	return s.db.Close()
 }
 
 // CreateUser inserts a new user into the person table
-func CreateUser(username, email, password, role string) (int64, error) {
+func (s *SQLStore) CreateUser(username, email, password, role string) (int64, error) {
 	hashedPassword, err := HashPassword(password)
 	if err != nil {
 		return 0, err
 	}
 
 	query := `INSERT INTO person (username, email, password_hash, role) VALUES (?, ?, ?, ?)`
-	result, err := db.Exec(query, username, email, hashedPassword, role)
+	result, err := s.db.Exec(query, username, email, hashedPassword, role)
 	if err != nil {
 		return 0, err
 	}
@@ -40,49 +37,76 @@ func CreateUser(username, email, password, role string) (int64, error) {
 }
 
 // InsertUserInfo adds personal details into the user_info table
-func InsertUserInfo(userID int64, fullName string, age int, gender string, height, weight float64) error {
+func (s *SQLStore) InsertUserInfo(userID int64, fullName string, age int, gender string, height, weight float64) error {
 	query := `INSERT INTO user_info (user_id, full_name, age, gender, height_cm, weight_kg) VALUES (?, ?, ?, ?, ?, ?)`
-	_, err := db.Exec(query, userID, fullName, age, gender, height, weight)
-	return err
+	if _, err := s.db.Exec(query, userID, fullName, age, gender, height, weight); err != nil {
+		return err
+	}
+	return s.RecalculateMetrics(userID)
 }
 
 // SaveUserInfoByID inserts or updates personal info using user ID
-func SaveUserInfoByID(userID int64, fullName string, age int, gender string, height, weight float64) error {
+func (s *SQLStore) SaveUserInfoByID(userID int64, fullName string, age int, gender string, height, weight float64) error {
+	oldWeight, err := s.profileWeight(userID)
+	if err != nil {
+		return err
+	}
+	before, err := s.profileSnapshot(userID)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO user_info (user_id, full_name, age, gender, height_cm, weight_kg)
 		VALUES (?, ?, ?, ?, ?, ?)
-		ON DUPLICATE KEY UPDATE
-			full_name = VALUES(full_name),
-			age = VALUES(age),
-			gender = VALUES(gender),
-			height_cm = VALUES(height_cm),
-			weight_kg = VALUES(weight_kg)`
-	_, err := db.Exec(query, userID, fullName, age, gender, height, weight)
-	return err
+		` + s.dialect.upsert([]string{"user_id"}, "full_name", "age", "gender", "height_cm", "weight_kg")
+	if _, err := s.db.Exec(query, userID, fullName, age, gender, height, weight); err != nil {
+		return err
+	}
+	if err := s.recordProfileChanges(userID, before); err != nil {
+		return err
+	}
+	if err := s.recordWeightChange(userID, oldWeight, weight); err != nil {
+		return err
+	}
+	return s.RecalculateMetrics(userID)
 }
 
 // SaveUserInfo inserts or updates personal info using username
-func SaveUserInfo(username string, fullName string, age int, gender string, height, weight float64) error {
-	var userID int
-	err := db.QueryRow("SELECT id FROM person WHERE username = ?", username).Scan(&userID)
+func (s *SQLStore) SaveUserInfo(username string, fullName string, age int, gender string, height, weight float64) error {
+	var userID int64
+	err := s.db.QueryRow("SELECT id FROM person WHERE username = ?", username).Scan(&userID)
 	if err != nil {
 		return err
 	}
 
-	var exists bool
-	err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM user_info WHERE user_id = ?)", userID).Scan(&exists)
+	oldWeight, err := s.profileWeight(userID)
+	if err != nil {
+		return err
+	}
+	exists := oldWeight.Valid
+	before, err := s.profileSnapshot(userID)
 	if err != nil {
 		return err
 	}
 
 	if exists {
-		_, err = db.Exec(`UPDATE user_info SET full_name=?, age=?, gender=?, height_cm=?, weight_kg=? WHERE user_id=?`,
+		_, err = s.db.Exec(`UPDATE user_info SET full_name=?, age=?, gender=?, height_cm=?, weight_kg=? WHERE user_id=?`,
 			fullName, age, gender, height, weight, userID)
 	} else {
-		_, err = db.Exec(`INSERT INTO user_info (user_id, full_name, age, gender, height_cm, weight_kg) VALUES (?, ?, ?, ?, ?, ?)`,
+		_, err = s.db.Exec(`INSERT INTO user_info (user_id, full_name, age, gender, height_cm, weight_kg) VALUES (?, ?, ?, ?, ?, ?)`,
 			userID, fullName, age, gender, height, weight)
 	}
-	return err
+	if err != nil {
+		return err
+	}
+	if err := s.recordProfileChanges(userID, before); err != nil {
+		return err
+	}
+	if err := s.recordWeightChange(userID, oldWeight, weight); err != nil {
+		return err
+	}
+	return s.RecalculateMetrics(userID)
 }
 
 func HashPassword(password string) (string, error) {
@@ -95,9 +119,9 @@ func CheckPasswordHash(password, hash string) error {
 }
 
 // ValidateUser checks if the username/password is valid
-func ValidateUser(username, password string) (bool, string, error) {
+func (s *SQLStore) ValidateUser(username, password string) (bool, string, error) {
 	var storedPassword, role string
-	err := db.QueryRow("SELECT password_hash, role FROM person WHERE username = ?", username).Scan(&storedPassword, &role)
+	err := s.db.QueryRow("SELECT password_hash, role FROM person WHERE username = ?", username).Scan(&storedPassword, &role)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return false, "", nil
@@ -117,23 +141,25 @@ func ValidateUser(username, password string) (bool, string, error) {
 
 // UserInfo holds personal data
 type UserInfo struct {
+	Username string
 	FullName string
 	Age      int
 	Gender   string
 	Height   float64
 	Weight   float64
+	Goal     string
 }
 
 // GetUserInfoByUsername fetches user_info using username
-func GetUserInfoByUsername(username string) (*UserInfo, error) {
+func (s *SQLStore) GetUserInfoByUsername(username string) (*UserInfo, error) {
 	query := `
-		SELECT ui.full_name, ui.age, ui.gender, ui.height_cm, ui.weight_kg
+		SELECT ui.full_name, ui.age, ui.gender, ui.height_cm, ui.weight_kg, ui.fitness_goal
 		FROM user_info ui
 		JOIN person p ON ui.user_id = p.id
 		WHERE p.username = ?`
 
-	var info UserInfo
-	err := db.QueryRow(query, username).Scan(&info.FullName, &info.Age, &info.Gender, &info.Height, &info.Weight)
+	info := UserInfo{Username: username}
+	err := s.db.QueryRow(query, username).Scan(&info.FullName, &info.Age, &info.Gender, &info.Height, &info.Weight, &info.Goal)
 	if err != nil {
 		return nil, err
 	}
@@ -141,22 +167,34 @@ func GetUserInfoByUsername(username string) (*UserInfo, error) {
 }
 
 // GetAllUserInfo fetches all user information from the user_info table
-func GetAllUserInfo() ([]UserInfo, error) {
-	query := `
-        SELECT full_name, age, gender, height_cm, weight_kg
-        FROM user_info
-    `
-	rows, err := db.Query(query)
+func (s *SQLStore) GetAllUserInfo() ([]UserInfo, error) {
+	return s.queryUserInfo(userInfoQuery)
+}
+
+// GetClientInfo fetches the profiles of a coach's active clients
+func (s *SQLStore) GetClientInfo(coachID int64) ([]UserInfo, error) {
+	return s.queryUserInfo(userInfoQuery+`
+		JOIN coach_clients cc ON cc.member_id = ui.user_id
+		WHERE cc.coach_id = ? AND cc.status = ?`, coachID, LinkActive)
+}
+
+const userInfoQuery = `
+        SELECT p.username, ui.full_name, ui.age, ui.gender, ui.height_cm, ui.weight_kg, ui.fitness_goal
+        FROM user_info ui
+        JOIN person p ON p.id = ui.user_id`
+
+func (s *SQLStore) queryUserInfo(query string, args ...any) ([]UserInfo, error) {
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		log.Printf("❌ Query error: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var users []UserInfo
+	users := []UserInfo{}
 	for rows.Next() {
 		var user UserInfo
-		err := rows.Scan(&user.FullName, &user.Age, &user.Gender, &user.Height, &user.Weight)
+		err := rows.Scan(&user.Username, &user.FullName, &user.Age, &user.Gender, &user.Height, &user.Weight, &user.Goal)
 		if err != nil {
 			log.Printf("❌ Row scan error: %v", err)
 			return nil, err
@@ -167,72 +205,162 @@ func GetAllUserInfo() ([]UserInfo, error) {
 	return users, nil
 }
 
-// SaveOrUpdateProgress inserts or updates daily progress
-func SaveOrUpdateProgress(userID int64, workout, meals, water bool) error {
+// SetFitnessGoal stores the member's goal in their own words
+func (s *SQLStore) SetFitnessGoal(userID int64, goal string) error {
+	before, err := s.profileSnapshot(userID)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.Exec("UPDATE user_info SET fitness_goal = ? WHERE user_id = ?", goal, userID)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(result); err != nil {
+		return err
+	}
+	return s.recordProfileChanges(userID, before)
+}
+
+// SaveOrUpdateProgress inserts or updates the built-in habits for a day
+// (YYYY-MM-DD, empty for today). Water is left to the logged intake, and
+// meals stay ticked on a day with logged food.
+func (s *SQLStore) SaveOrUpdateProgress(userID int64, date string, workout, meals bool) error {
+	if date == "" {
+		date = today()
+	}
+	logged, err := hasFoodEntries(s.db, userID, date)
+	if err != nil {
+		return err
+	}
+	meals = meals || logged
 	query := `
-	INSERT INTO user_progress (user_id, date, workout_done, meals_logged, water_done)
-	VALUES (?, CURDATE(), ?, ?, ?)
-	ON DUPLICATE KEY UPDATE 
-		workout_done = VALUES(workout_done),
-		meals_logged = VALUES(meals_logged),
-		water_done = VALUES(water_done)`
-	_, err := db.Exec(query, userID, workout, meals, water)
-	return err
+	INSERT INTO user_progress (user_id, date, workout_done, meals_logged)
+	VALUES (?, ?, ?, ?)
+	` + s.dialect.upsert([]string{"user_id", "date"}, "workout_done", "meals_logged")
+	if _, err := s.db.Exec(query, userID, date, workout, meals); err != nil {
+		return err
+	}
+	s.refreshGoalsAfter(userID)
+	return nil
 }
 
 // GetUserIDByUsername returns a user's ID based on username
-func GetUserIDByUsername(username string) (int64, error) {
+func (s *SQLStore) GetUserIDByUsername(username string) (int64, error) {
 	var id int64
-	err := db.QueryRow("SELECT id FROM person WHERE username = ?", username).Scan(&id)
+	err := s.db.QueryRow("SELECT id FROM person WHERE username = ?", username).Scan(&id)
 	return id, err
 }
 
-// SendMessage stores a chat message in the database
-func SendMessage(senderID, receiverID int64, content string) error {
+// SendMessage stores a chat message in the database and returns its ID
+// and the timestamp it was stored with
+func (s *SQLStore) SendMessage(senderID, receiverID int64, content string) (int64, time.Time, error) {
+	// DATETIME keeps whole seconds, so hand back exactly what history will read
+	sentAt := time.Now().UTC().Truncate(time.Second)
 	query := `INSERT INTO messages (sender_id, receiver_id, message, timestamp) VALUES (?, ?, ?, ?)`
-	_, err := db.Exec(query, senderID, receiverID, content, time.Now())
-	return err
+	result, err := s.db.Exec(query, senderID, receiverID, content, sentAt)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return id, sentAt, nil
 }
 
-// Message represents a chat message
+// Message represents a chat message. DeliveredAt and ReadAt are missing
+// until the receiver got or read it.
 type Message struct {
-	Sender  string
-	Content string
-	Time    string
+	ID          int64      `json:"id"`
+	Sender      string     `json:"sender"`
+	Content     string     `json:"content"`
+	Time        time.Time  `json:"time"`
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty"`
+	ReadAt      *time.Time `json:"readAt,omitempty"`
 }
 
-// GetMessagesBetweenUsers retrieves chat history between two users
-func GetMessagesBetweenUsers(senderID, receiverID int64) ([]Message, error) {
-	query := `
-        SELECT p.username, m.message, CAST(m.timestamp AS CHAR)
-        FROM messages m
-        JOIN person p ON m.sender_id = p.id
-        WHERE (m.sender_id = ? AND m.receiver_id = ?) OR (m.sender_id = ? AND m.receiver_id = ?)
-        ORDER BY m.timestamp ASC
-    `
+// HistoryPage selects part of a conversation by message ID: the newest
+// Limit messages before Before, the oldest Limit after After, or the
+// newest Limit when both are 0
+type HistoryPage struct {
+	Before int64
+	After  int64
+	Limit  int
+}
 
-	log.Printf("🔍 Executing query with senderID: %d, receiverID: %d", senderID, receiverID)
+// GetMessagesBetweenUsers retrieves a page of chat history between two
+// users, oldest first, and reports whether more messages lie beyond it.
+// Each direction of the conversation is read from the
+// (sender_id, receiver_id, id) index separately and then merged.
+func (s *SQLStore) GetMessagesBetweenUsers(senderID, receiverID int64, page HistoryPage) ([]Message, bool, error) {
+	cond, order, cursor := "", "DESC", page.Before
+	if page.After > 0 {
+		cond, order, cursor = " AND id > ?", "ASC", page.After
+	} else if page.Before > 0 {
+		cond = " AND id < ?"
+	}
+	direction := `SELECT * FROM (SELECT id, sender_id, message, timestamp, delivered_at, read_at FROM messages
+		WHERE sender_id = ? AND receiver_id = ?` + cond + ` ORDER BY id ` + order + ` LIMIT ?) `
+	query := `SELECT m.id, p.username, m.message, m.timestamp, m.delivered_at, m.read_at
+		FROM (` + direction + `sent UNION ALL ` + direction + `received) m
+		JOIN person p ON p.id = m.sender_id
+		ORDER BY m.id ` + order + `
+		LIMIT ?`
 
-	rows, err := db.Query(query, senderID, receiverID, receiverID, senderID)
+	var args []any
+	for _, pair := range [][2]int64{{senderID, receiverID}, {receiverID, senderID}} {
+		args = append(args, pair[0], pair[1])
+		if cond != "" {
+			args = append(args, cursor)
+		}
+		args = append(args, page.Limit+1)
+	}
+	args = append(args, page.Limit+1)
+
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		log.Printf("❌ Query error: %v", err)
-		return nil, err
+		return nil, false, err
 	}
 	defer rows.Close()
 
-	var messages []Message
+	messages := []Message{}
 	for rows.Next() {
 		var msg Message
-		var timestamp string
-		err := rows.Scan(&msg.Sender, &msg.Content, &timestamp)
+		var deliveredAt, readAt sql.NullTime
+		err := rows.Scan(&msg.ID, &msg.Sender, &msg.Content, &msg.Time, &deliveredAt, &readAt)
 		if err != nil {
 			log.Printf("❌ Row scan error: %v", err)
-			return nil, err
+			return nil, false, err
+		}
+		if deliveredAt.Valid {
+			msg.DeliveredAt = &deliveredAt.Time
+		}
+		if readAt.Valid {
+			msg.ReadAt = &readAt.Time
 		}
-		msg.Time = timestamp
 		messages = append(messages, msg)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
 
-	log.Printf("✅ Messages retrieved: %v", messages)
-	return messages, nil
+	more := len(messages) > page.Limit
+	if more {
+		messages = messages[:page.Limit]
+	}
+	if order == "DESC" {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+	return messages, more, nil
+}
+
+// today returns the current local date in the DATE column format,
+// replacing MySQL's CURDATE() so the query also runs on SQLite.
+func today() string {
+	return time.Now().Format(dateLayout)
 }
+
+const dateLayout = "2006-01-02"
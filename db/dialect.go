@@ -0,0 +1,33 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dialect captures the SQL differences between the supported backends.
+// Its value doubles as the migrations directory name.
+type dialect string
+
+const (
+	dialectMySQL  dialect = "mysql"
+	dialectSQLite dialect = "sqlite"
+)
+
+// upsert returns the clause that turns an INSERT into an insert-or-update
+// on the unique key made of keys, overwriting cols.
+func (d dialect) upsert(keys []string, cols ...string) string {
+	sets := make([]string, len(cols))
+	switch d {
+	case dialectSQLite:
+		for i, c := range cols {
+			sets[i] = fmt.Sprintf("%s = excluded.%s", c, c)
+		}
+		return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(keys, ", "), strings.Join(sets, ", "))
+	default:
+		for i, c := range cols {
+			sets[i] = fmt.Sprintf("%s = VALUES(%s)", c, c)
+		}
+		return "ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+	}
+}
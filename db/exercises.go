@@ -0,0 +1,349 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Kinds of exercise in the library
+const (
+	ExerciseStrength = "strength"
+	ExerciseCardio   = "cardio"
+)
+
+// CardioOther is the activity key for cardio that isn't in the library
+const CardioOther = "other"
+
+// Exercise is an entry in the exercise library. Slug is derived from the
+// name when the exercise is created and never changes, since cardio
+// sessions store it as their activity. MuscleGroups are lower case,
+// primary muscle first.
+type Exercise struct {
+	ID              int64    `json:"id"`
+	Slug            string   `json:"slug"`
+	Name            string   `json:"name"`
+	Kind            string   `json:"kind"`
+	MuscleGroups    []string `json:"muscleGroups"`
+	Equipment       string   `json:"equipment"`
+	MovementPattern string   `json:"movementPattern"`
+	Instructions    string   `json:"instructions"`
+	MediaURL        string   `json:"mediaUrl"`
+}
+
+// ExerciseFilter selects exercises from the library. Empty fields don't
+// filter; Search matches part of the name.
+type ExerciseFilter struct {
+	Kind      string
+	Search    string
+	Muscle    string
+	Equipment string
+	Pattern   string
+}
+
+// ExerciseFacets are the values in use, for building library filters
+type ExerciseFacets struct {
+	Muscles   []string `json:"muscles"`
+	Equipment []string `json:"equipment"`
+	Patterns  []string `json:"patterns"`
+}
+
+// ExerciseStore persists the exercise library
+type ExerciseStore interface {
+	ListExercises(f ExerciseFilter) ([]Exercise, error)
+	GetExercise(id int64) (*Exercise, error)
+	GetExerciseBySlug(slug string) (*Exercise, error)
+	CreateExercise(e Exercise) (*Exercise, error)
+	UpdateExercise(id int64, e Exercise) error
+	DeleteExercise(id int64) error
+	ListExerciseFacets(kind string) (*ExerciseFacets, error)
+}
+
+// ErrExerciseExists is returned when the library already has an exercise
+// by that name
+var ErrExerciseExists = errors.New("exercise already exists")
+
+var slugSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ExerciseSlug turns a name into its key, so "Jump Rope" becomes "jump_rope"
+func ExerciseSlug(name string) string {
+	return strings.Trim(slugSeparators.ReplaceAllString(strings.ToLower(name), "_"), "_")
+}
+
+const exerciseColumns = "id, slug, name, kind, equipment, movement_pattern, instructions, media_url"
+
+func scanExercise(row interface{ Scan(...any) error }) (Exercise, error) {
+	e := Exercise{MuscleGroups: []string{}}
+	err := row.Scan(&e.ID, &e.Slug, &e.Name, &e.Kind, &e.Equipment, &e.MovementPattern, &e.Instructions, &e.MediaURL)
+	return e, err
+}
+
+// ListExercises returns the exercises matching f, ordered by name
+func (s *SQLStore) ListExercises(f ExerciseFilter) ([]Exercise, error) {
+	var where []string
+	var args []any
+	if f.Kind != "" {
+		where = append(where, "kind = ?")
+		args = append(args, f.Kind)
+	}
+	if f.Search != "" {
+		where = append(where, "LOWER(name) LIKE ? ESCAPE '!'")
+		args = append(args, "%"+likeEscaper.Replace(strings.ToLower(f.Search))+"%")
+	}
+	if f.Muscle != "" {
+		where = append(where, "id IN (SELECT exercise_id FROM exercise_muscles WHERE muscle = ?)")
+		args = append(args, strings.ToLower(f.Muscle))
+	}
+	if f.Equipment != "" {
+		where = append(where, "LOWER(equipment) = ?")
+		args = append(args, strings.ToLower(f.Equipment))
+	}
+	if f.Pattern != "" {
+		where = append(where, "LOWER(movement_pattern) = ?")
+		args = append(args, strings.ToLower(f.Pattern))
+	}
+
+	query := "SELECT " + exerciseColumns + " FROM exercises"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	rows, err := s.db.Query(query+" ORDER BY name", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exercises := []Exercise{}
+	index := map[int64]int{}
+	for rows.Next() {
+		e, err := scanExercise(rows)
+		if err != nil {
+			return nil, err
+		}
+		index[e.ID] = len(exercises)
+		exercises = append(exercises, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return exercises, s.loadExerciseMuscles(exercises, index)
+}
+
+func (s *SQLStore) loadExerciseMuscles(exercises []Exercise, index map[int64]int) error {
+	if len(exercises) == 0 {
+		return nil
+	}
+	rows, err := s.db.Query("SELECT exercise_id, muscle FROM exercise_muscles ORDER BY exercise_id, position")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var muscle string
+		if err := rows.Scan(&id, &muscle); err != nil {
+			return err
+		}
+		if i, ok := index[id]; ok {
+			exercises[i].MuscleGroups = append(exercises[i].MuscleGroups, muscle)
+		}
+	}
+	return rows.Err()
+}
+
+// GetExercise returns one exercise by ID
+func (s *SQLStore) GetExercise(id int64) (*Exercise, error) {
+	return s.getExercise("id = ?", id)
+}
+
+// GetExerciseBySlug returns one exercise by its key
+func (s *SQLStore) GetExerciseBySlug(slug string) (*Exercise, error) {
+	return s.getExercise("slug = ?", slug)
+}
+
+func (s *SQLStore) getExercise(where string, arg any) (*Exercise, error) {
+	e, err := scanExercise(s.db.QueryRow("SELECT "+exerciseColumns+" FROM exercises WHERE "+where, arg))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	exercises := []Exercise{e}
+	if err := s.loadExerciseMuscles(exercises, map[int64]int{e.ID: 0}); err != nil {
+		return nil, err
+	}
+	return &exercises[0], nil
+}
+
+// CreateExercise adds an exercise to the library, deriving its slug from
+// the name
+func (s *SQLStore) CreateExercise(e Exercise) (*Exercise, error) {
+	e.Slug = ExerciseSlug(e.Name)
+	if e.Slug == CardioOther {
+		return nil, ErrExerciseExists
+	}
+	if err := s.checkExerciseName(0, e.Name, e.Slug); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, e.Slug, e.Name, e.Kind, e.Equipment, e.MovementPattern, e.Instructions, e.MediaURL)
+	if err != nil {
+		return nil, err
+	}
+	if e.ID, err = result.LastInsertId(); err != nil {
+		return nil, err
+	}
+	if err := insertExerciseMuscles(tx, e.ID, e.MuscleGroups); err != nil {
+		return nil, err
+	}
+	return &e, tx.Commit()
+}
+
+func insertExerciseMuscles(tx *sql.Tx, exerciseID int64, muscles []string) error {
+	for i, muscle := range muscles {
+		_, err := tx.Exec("INSERT INTO exercise_muscles (exercise_id, muscle, position) VALUES (?, ?, ?)",
+			exerciseID, muscle, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateExercise replaces everything about an exercise except its slug
+func (s *SQLStore) UpdateExercise(id int64, e Exercise) error {
+	if err := s.checkExerciseName(id, e.Name, ""); err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE exercises SET name = ?, kind = ?, equipment = ?, movement_pattern = ?,
+		instructions = ?, media_url = ? WHERE id = ?`,
+		e.Name, e.Kind, e.Equipment, e.MovementPattern, e.Instructions, e.MediaURL, id)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(result); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM exercise_muscles WHERE exercise_id = ?", id); err != nil {
+		return err
+	}
+	if err := insertExerciseMuscles(tx, id, e.MuscleGroups); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteExercise removes an exercise from the library. Logged workouts,
+// cardio sessions and programs keep the name they were saved with.
+func (s *SQLStore) DeleteExercise(id int64) error {
+	result, err := s.db.Exec("DELETE FROM exercises WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// checkExerciseName looks for another exercise with the same name, in any
+// case, or the same slug
+func (s *SQLStore) checkExerciseName(id int64, name, slug string) error {
+	var other int64
+	err := s.db.QueryRow("SELECT id FROM exercises WHERE (LOWER(name) = ? OR slug = ?) AND id <> ?",
+		strings.ToLower(name), slug, id).Scan(&other)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return ErrExerciseExists
+}
+
+// ListExerciseFacets returns the muscle groups, equipment and movement
+// patterns used by exercises of a kind, or by all exercises
+func (s *SQLStore) ListExerciseFacets(kind string) (*ExerciseFacets, error) {
+	filter, args := "", []any{}
+	if kind != "" {
+		filter, args = " AND e.kind = ?", []any{kind}
+	}
+
+	facets := &ExerciseFacets{}
+	queries := []struct {
+		into  *[]string
+		query string
+	}{
+		{&facets.Muscles, "SELECT DISTINCT m.muscle FROM exercise_muscles m JOIN exercises e ON e.id = m.exercise_id WHERE 1 = 1" + filter + " ORDER BY m.muscle"},
+		{&facets.Equipment, "SELECT DISTINCT e.equipment FROM exercises e WHERE e.equipment <> ''" + filter + " ORDER BY e.equipment"},
+		{&facets.Patterns, "SELECT DISTINCT e.movement_pattern FROM exercises e WHERE e.movement_pattern <> ''" + filter + " ORDER BY e.movement_pattern"},
+	}
+	for _, q := range queries {
+		values, err := s.listStrings(q.query, args...)
+		if err != nil {
+			return nil, err
+		}
+		*q.into = values
+	}
+	return facets, nil
+}
+
+func (s *SQLStore) listStrings(query string, args ...any) ([]string, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+func ListExercises(f ExerciseFilter) ([]Exercise, error) {
+	return current.ListExercises(f)
+}
+
+func GetExercise(id int64) (*Exercise, error) {
+	return current.GetExercise(id)
+}
+
+func GetExerciseBySlug(slug string) (*Exercise, error) {
+	return current.GetExerciseBySlug(slug)
+}
+
+func CreateExercise(e Exercise) (*Exercise, error) {
+	return current.CreateExercise(e)
+}
+
+func UpdateExercise(id int64, e Exercise) error {
+	return current.UpdateExercise(id, e)
+}
+
+func DeleteExercise(id int64) error {
+	return current.DeleteExercise(id)
+}
+
+func ListExerciseFacets(kind string) (*ExerciseFacets, error) {
+	return current.ListExerciseFacets(kind)
+}
@@ -0,0 +1,522 @@
+package db
+
+import (
+	"database/sql"
+	"log"
+	"math"
+	"time"
+)
+
+// Kinds of goal. Weight goals target the body weight, lift goals an
+// exercise's estimated one-rep max, cardio goals the distance run in a
+// week and habit goals a habit's streak.
+const (
+	GoalWeight = "weight"
+	GoalLift   = "lift"
+	GoalCardio = "cardio"
+	GoalHabit  = "habit"
+)
+
+// GoalKinds lists the kinds of goal
+var GoalKinds = []string{GoalWeight, GoalLift, GoalCardio, GoalHabit}
+
+// Goal statuses
+const (
+	GoalOnTrack  = "on_track"
+	GoalBehind   = "behind"
+	GoalAchieved = "achieved"
+)
+
+// goalStatusNames describe statuses in the coach's timeline
+var goalStatusNames = map[string]string{
+	GoalOnTrack:  "on track",
+	GoalBehind:   "behind",
+	GoalAchieved: "achieved",
+}
+
+// Goal is something a member works towards by a deadline. Dates are
+// YYYY-MM-DD; Exercise is set for lift goals and Habit (a habit key) for
+// habit goals. Status is the last one worked out by ComputeGoalProgress.
+type Goal struct {
+	ID          int64      `json:"id"`
+	Kind        string     `json:"kind"`
+	Title       string     `json:"title"`
+	Exercise    string     `json:"exercise,omitempty"`
+	Habit       string     `json:"habit,omitempty"`
+	StartValue  float64    `json:"startValue"`
+	TargetValue float64    `json:"targetValue"`
+	StartDate   string     `json:"startDate"`
+	Deadline    string     `json:"deadline"`
+	Status      string     `json:"status"`
+	AchievedAt  *time.Time `json:"achievedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// GoalPoint is one value logged towards a goal: a weigh-in, a session's
+// estimated max or a week's distance
+type GoalPoint struct {
+	Date  time.Time
+	Value float64
+}
+
+// GoalProgress is a goal with how far along it is. Percent runs from start
+// to target value; Projected is when the recent trend reaches the target,
+// missing when it doesn't head there.
+type GoalProgress struct {
+	Goal
+	Current   *float64 `json:"current"`
+	Percent   float64  `json:"percent"`
+	Projected *string  `json:"projected,omitempty"`
+}
+
+// GoalStore persists member goals and the history of their status. Reads
+// work progress out without writing; saving a goal or the data it measures
+// stores its new status.
+type GoalStore interface {
+	ListGoals(userID int64) ([]GoalProgress, error)
+	GetGoal(userID, id int64) (*GoalProgress, error)
+	GoalValues(userID int64, g Goal) (*float64, []GoalPoint, error)
+	CreateGoal(userID int64, g Goal) (*GoalProgress, error)
+	UpdateGoal(userID int64, g Goal) (*GoalProgress, error)
+	DeleteGoal(userID, id int64) error
+	RefreshGoals(userID int64) error
+}
+
+const goalColumns = `id, kind, title, exercise, habit, start_value, target_value, start_date, deadline,
+	status, achieved_at, created_at`
+
+func scanGoal(row interface{ Scan(...any) error }) (*Goal, error) {
+	var g Goal
+	var start, deadline time.Time
+	var achievedAt sql.NullTime
+	err := row.Scan(&g.ID, &g.Kind, &g.Title, &g.Exercise, &g.Habit, &g.StartValue, &g.TargetValue,
+		&start, &deadline, &g.Status, &achievedAt, &g.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	g.StartDate, g.Deadline = start.Format(dateLayout), deadline.Format(dateLayout)
+	if achievedAt.Valid {
+		g.AchievedAt = &achievedAt.Time
+	}
+	return &g, nil
+}
+
+// ListGoals returns the member's goals with their progress, soonest
+// deadline first
+func (s *SQLStore) ListGoals(userID int64) ([]GoalProgress, error) {
+	goals, err := s.storedGoals(userID)
+	if err != nil {
+		return nil, err
+	}
+	progress := make([]GoalProgress, 0, len(goals))
+	for _, g := range goals {
+		p, err := s.goalProgress(userID, g)
+		if err != nil {
+			return nil, err
+		}
+		progress = append(progress, p)
+	}
+	return progress, nil
+}
+
+// GetGoal returns one of the member's goals with its progress
+func (s *SQLStore) GetGoal(userID, id int64) (*GoalProgress, error) {
+	g, err := s.storedGoal(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.goalProgress(userID, *g)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreateGoal stores a new goal with the status its progress gives it and
+// records that in its history
+func (s *SQLStore) CreateGoal(userID int64, g Goal) (*GoalProgress, error) {
+	g.Status, g.AchievedAt, g.CreatedAt = GoalOnTrack, nil, time.Now().UTC()
+	p, err := s.goalProgress(userID, g)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO goals (user_id, kind, title, exercise, habit, start_value, target_value,
+			start_date, deadline, status, achieved_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, g.Kind, g.Title, g.Exercise, g.Habit, g.StartValue, g.TargetValue, g.StartDate, g.Deadline,
+		p.Status, p.AchievedAt, g.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if p.ID, err = result.LastInsertId(); err != nil {
+		return nil, err
+	}
+	if err := insertGoalEvent(tx, p.ID, p.Status, g.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &p, tx.Commit()
+}
+
+// UpdateGoal replaces a goal's definition and works its status out again,
+// which may take back an achievement the new target no longer meets
+func (s *SQLStore) UpdateGoal(userID int64, g Goal) (*GoalProgress, error) {
+	result, err := s.db.Exec(`UPDATE goals SET kind = ?, title = ?, exercise = ?, habit = ?, start_value = ?,
+			target_value = ?, start_date = ?, deadline = ?, achieved_at = NULL
+		WHERE id = ? AND user_id = ?`,
+		g.Kind, g.Title, g.Exercise, g.Habit, g.StartValue, g.TargetValue, g.StartDate, g.Deadline, g.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectRow(result); err != nil {
+		return nil, err
+	}
+	saved, err := s.storedGoal(userID, g.ID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.refreshGoal(userID, *saved)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// DeleteGoal removes one of the member's goals with its history
+func (s *SQLStore) DeleteGoal(userID, id int64) error {
+	result, err := s.db.Exec("DELETE FROM goals WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// RefreshGoals works out the member's goals again after the data they
+// measure changed and stores each status that moved. A deadline passing
+// shows on reads straight away and is stored with the next change.
+func (s *SQLStore) RefreshGoals(userID int64) error {
+	goals, err := s.storedGoals(userID)
+	if err != nil {
+		return err
+	}
+	for _, g := range goals {
+		if _, err := s.refreshGoal(userID, g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshGoalsAfter refreshes the member's goals once a change to their
+// data is stored. The change stands either way, so a failure is logged
+// rather than reported as a failed write.
+func (s *SQLStore) refreshGoalsAfter(userID int64) {
+	if err := s.RefreshGoals(userID); err != nil {
+		log.Printf("❌ Failed to refresh goals for user %d: %v", userID, err)
+	}
+}
+
+// refreshGoal stores a goal's status and achievement time when its
+// progress moved them, and records an event when the status changed
+func (s *SQLStore) refreshGoal(userID int64, g Goal) (GoalProgress, error) {
+	p, err := s.goalProgress(userID, g)
+	if err != nil {
+		return p, err
+	}
+	if p.Status == g.Status && (p.AchievedAt == nil) == (g.AchievedAt == nil) {
+		return p, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return p, err
+	}
+	defer tx.Rollback()
+
+	var achievedAt any
+	if p.AchievedAt != nil {
+		achievedAt = p.AchievedAt.UTC()
+	}
+	_, err = tx.Exec("UPDATE goals SET status = ?, achieved_at = ? WHERE id = ? AND user_id = ?",
+		p.Status, achievedAt, g.ID, userID)
+	if err != nil {
+		return p, err
+	}
+	if p.Status != g.Status {
+		if err := insertGoalEvent(tx, g.ID, p.Status, time.Now().UTC()); err != nil {
+			return p, err
+		}
+	}
+	return p, tx.Commit()
+}
+
+func (s *SQLStore) storedGoals(userID int64) ([]Goal, error) {
+	rows, err := s.db.Query("SELECT "+goalColumns+" FROM goals WHERE user_id = ? ORDER BY deadline, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	goals := []Goal{}
+	for rows.Next() {
+		g, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, *g)
+	}
+	return goals, rows.Err()
+}
+
+func (s *SQLStore) storedGoal(userID, id int64) (*Goal, error) {
+	g, err := scanGoal(s.db.QueryRow("SELECT "+goalColumns+" FROM goals WHERE id = ? AND user_id = ?", id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return g, err
+}
+
+func insertGoalEvent(tx *sql.Tx, goalID int64, status string, at time.Time) error {
+	_, err := tx.Exec("INSERT INTO goal_events (goal_id, status, changed_at) VALUES (?, ?, ?)", goalID, status, at)
+	return err
+}
+
+func (s *SQLStore) goalProgress(userID int64, g Goal) (GoalProgress, error) {
+	current, points, err := s.GoalValues(userID, g)
+	if err != nil {
+		return GoalProgress{}, err
+	}
+	return ComputeGoalProgress(g, current, points, time.Now()), nil
+}
+
+// GoalValues returns the current value of what a goal measures and the
+// values logged towards it, oldest first, from the start date or the
+// trend window if that's earlier: the latest weigh-in and daily weights,
+// the best estimated max and each session's, this week's distance and
+// earlier weeks', or the habit's current streak.
+func (s *SQLStore) GoalValues(userID int64, g Goal) (*float64, []GoalPoint, error) {
+	from, _ := time.ParseInLocation(dateLayout, g.StartDate, time.Local)
+	if recent := time.Now().AddDate(0, 0, -56); recent.Before(from) {
+		from = recent
+	}
+	var points []GoalPoint
+
+	switch g.Kind {
+	case GoalWeight:
+		measurements, err := s.ListMeasurements(userID, from, time.Time{})
+		if err != nil {
+			return nil, nil, err
+		}
+		trend := ComputeWeightTrend(measurements)
+		for _, pt := range trend.Points {
+			day, _ := time.ParseInLocation(dateLayout, pt.Date, time.Local)
+			points = append(points, GoalPoint{Date: day, Value: pt.WeightKG})
+		}
+		return trend.Latest, points, nil
+
+	case GoalLift:
+		maxes, err := s.EstimatedMaxes(userID, g.Exercise, from)
+		if err != nil {
+			return nil, nil, err
+		}
+		var best *float64
+		for _, m := range maxes {
+			points = append(points, GoalPoint{Date: m.StartedAt.Local(), Value: m.KG})
+			if best == nil || m.KG > *best {
+				kg := m.KG
+				best = &kg
+			}
+		}
+		return best, points, nil
+
+	case GoalCardio:
+		sessions, err := s.ListCardioSessions(userID, from, time.Time{}, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		weeks := WeeklyCardioVolume(sessions, from)
+		if len(weeks) == 0 {
+			return nil, nil, nil
+		}
+		// The week in progress is the current value; only whole weeks
+		// make the trend
+		for _, week := range weeks[:len(weeks)-1] {
+			start, _ := time.ParseInLocation(dateLayout, week.WeekStart, time.Local)
+			points = append(points, GoalPoint{Date: start, Value: week.DistanceKM})
+		}
+		km := weeks[len(weeks)-1].DistanceKM
+		return &km, points, nil
+
+	case GoalHabit:
+		habits, err := s.ListHabits(userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		checkIns, err := s.ListCheckIns(userID, "", today())
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, streak := range ComputeHabitStreaks(habits, checkIns, today()) {
+			if streak.Habit == g.Habit {
+				days := float64(streak.Current)
+				return &days, nil, nil
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+// goalTrendDays is how far back the trend behind a projection looks
+var goalTrendDays = map[string]int{
+	GoalWeight: 28,
+	GoalLift:   56,
+	GoalCardio: 56,
+}
+
+// ComputeGoalProgress works out how far along a goal is from its current
+// value and the points logged towards it, oldest first. A goal is achieved
+// once the current value or a point since the start date reaches the
+// target, and stays achieved until its definition changes. Otherwise it
+// is on track while the projection lands by the deadline or progress keeps
+// up with the time gone, and behind after that. Habit goals project one
+// day per day left in the streak.
+func ComputeGoalProgress(g Goal, current *float64, points []GoalPoint, now time.Time) GoalProgress {
+	p := GoalProgress{Goal: g, Current: current}
+	rising := g.TargetValue > g.StartValue
+	reaches := func(v float64) bool {
+		if rising {
+			return v >= g.TargetValue
+		}
+		return v <= g.TargetValue
+	}
+
+	start, _ := time.ParseInLocation(dateLayout, g.StartDate, time.Local)
+	deadline, _ := time.ParseInLocation(dateLayout, g.Deadline, time.Local)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	reached := current != nil && reaches(*current)
+	for _, pt := range points {
+		if !pt.Date.Before(start) && reaches(pt.Value) {
+			reached = true
+		}
+	}
+
+	if current != nil && g.TargetValue != g.StartValue {
+		done := (*current - g.StartValue) / (g.TargetValue - g.StartValue)
+		p.Percent = math.Round(math.Min(math.Max(done, 0), 1) * 100)
+	}
+	if reached || g.AchievedAt != nil {
+		p.Percent = 100
+		p.Status = GoalAchieved
+		if g.AchievedAt == nil {
+			at := now.UTC()
+			p.AchievedAt = &at
+		}
+		return p
+	}
+
+	var projected time.Time
+	if g.Kind == GoalHabit {
+		var streak float64
+		if current != nil {
+			streak = *current
+		}
+		projected = today.AddDate(0, 0, int(math.Ceil(g.TargetValue-streak)))
+	} else {
+		projected = projectTrend(points, g.TargetValue, today.AddDate(0, 0, -goalTrendDays[g.Kind]), today)
+	}
+	if !projected.IsZero() {
+		date := projected.Format(dateLayout)
+		p.Projected = &date
+	}
+
+	total := deadline.Sub(start).Hours()
+	elapsed := 1.0
+	if total > 0 {
+		elapsed = math.Min(math.Max(today.Sub(start).Hours()/total, 0), 1)
+	}
+	switch {
+	case today.After(deadline):
+		p.Status = GoalBehind
+	case !projected.IsZero() && !projected.After(deadline):
+		p.Status = GoalOnTrack
+	case p.Percent/100 >= elapsed:
+		p.Status = GoalOnTrack
+	default:
+		p.Status = GoalBehind
+	}
+	return p
+}
+
+// projectTrend fits a least-squares line through the points since from and
+// returns the day it reaches target, or zero when there are too few points,
+// the line heads away from the target or it would take over five years
+func projectTrend(points []GoalPoint, target float64, from, today time.Time) time.Time {
+	var xs, ys []float64
+	for _, pt := range points {
+		if !pt.Date.Before(from) {
+			xs = append(xs, pt.Date.Sub(today).Hours()/24)
+			ys = append(ys, pt.Value)
+		}
+	}
+	if len(xs) < 2 {
+		return time.Time{}
+	}
+
+	mx, my := mean(xs), mean(ys)
+	var sxy, sxx float64
+	for i := range xs {
+		sxy += (xs[i] - mx) * (ys[i] - my)
+		sxx += (xs[i] - mx) * (xs[i] - mx)
+	}
+	if sxx == 0 || sxy == 0 {
+		return time.Time{}
+	}
+	slope := sxy / sxx
+	days := (target - (my - slope*mx)) / slope
+	if days > 5*365 {
+		return time.Time{}
+	}
+	if days < 0 {
+		// The fitted line already passed the target, but no value has yet
+		if (target-ys[len(ys)-1])*slope < 0 {
+			return time.Time{}
+		}
+		days = 0
+	}
+	return today.AddDate(0, 0, int(math.Ceil(days)))
+}
+
+func ListGoals(userID int64) ([]GoalProgress, error) {
+	return current.ListGoals(userID)
+}
+
+func GetGoal(userID, id int64) (*GoalProgress, error) {
+	return current.GetGoal(userID, id)
+}
+
+func GoalValues(userID int64, g Goal) (*float64, []GoalPoint, error) {
+	return current.GoalValues(userID, g)
+}
+
+func CreateGoal(userID int64, g Goal) (*GoalProgress, error) {
+	return current.CreateGoal(userID, g)
+}
+
+func UpdateGoal(userID int64, g Goal) (*GoalProgress, error) {
+	return current.UpdateGoal(userID, g)
+}
+
+func DeleteGoal(userID, id int64) error {
+	return current.DeleteGoal(userID, id)
+}
+
+func RefreshGoals(userID int64) error {
+	return current.RefreshGoals(userID)
+}
@@ -0,0 +1,252 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+// goalNow is mid-morning so the goal code has to find the start of the day
+var goalNow = time.Date(2026, time.June, 15, 10, 30, 0, 0, time.Local)
+
+// goalDay returns the date offset days from goalNow's day
+func goalDay(offset int) time.Time {
+	return time.Date(2026, time.June, 15+offset, 0, 0, 0, 0, time.Local)
+}
+
+func goalDate(offset int) string {
+	return goalDay(offset).Format(dateLayout)
+}
+
+// points pairs day offsets with values
+func points(pairs ...float64) []GoalPoint {
+	var pts []GoalPoint
+	for i := 0; i < len(pairs); i += 2 {
+		pts = append(pts, GoalPoint{Date: goalDay(int(pairs[i])), Value: pairs[i+1]})
+	}
+	return pts
+}
+
+func value(v float64) *float64 { return &v }
+
+func TestProjectTrend(t *testing.T) {
+	today := goalDay(0)
+	from := goalDay(-28)
+	tests := []struct {
+		name   string
+		points []GoalPoint
+		target float64
+		want   int // days from today, -1 for no projection
+	}{
+		{"no points", nil, 75, -1},
+		{"one point", points(-5, 80), 75, -1},
+		{"older points ignored", points(-40, 82, -5, 80), 75, -1},
+		{"flat", points(-8, 80, 0, 80), 75, -1},
+		{"all on one day", points(0, 81, 0, 80), 75, -1},
+		{"heading away", points(-8, 79, 0, 80), 75, -1},
+		{"falling to target", points(-8, 81, 0, 80), 75, 40},
+		{"rising to target", points(-8, 100, 0, 102), 110, 32},
+		{"partial days round up", points(-8, 81, 0, 80), 75.1, 40},
+		{"just inside five years", points(-16, 80.0625, 0, 80), 75, 1280},
+		{"over five years", points(-16, 80.015625, 0, 80), 75, -1},
+		// The line through old points crossed the target, the last value did not
+		{"line already past target", points(-10, 80, -5, 77), 76, 0},
+		{"line past target, last value heading away", points(-10, 80, -5, 74, -4, 77), 78, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := projectTrend(tt.points, tt.target, from, today)
+			switch {
+			case tt.want < 0 && !got.IsZero():
+				t.Errorf("projectTrend = %s, want no projection", got.Format(dateLayout))
+			case tt.want >= 0 && !got.Equal(goalDay(tt.want)):
+				t.Errorf("projectTrend = %v, want %s", got, goalDate(tt.want))
+			}
+		})
+	}
+}
+
+func TestComputeGoalProgress(t *testing.T) {
+	// Halfway from start to deadline, so on pace means 50% done
+	weight := Goal{Kind: GoalWeight, StartValue: 80, TargetValue: 70, StartDate: goalDate(-30), Deadline: goalDate(30)}
+	achievedAt := time.Date(2026, time.June, 1, 8, 0, 0, 0, time.UTC)
+	with := func(g Goal, change func(*Goal)) Goal {
+		change(&g)
+		return g
+	}
+
+	tests := []struct {
+		name      string
+		goal      Goal
+		current   *float64
+		points    []GoalPoint
+		status    string
+		percent   float64
+		projected string
+	}{
+		{name: "current reaches target", goal: weight, current: value(70), status: GoalAchieved, percent: 100},
+		{name: "current beyond target", goal: weight, current: value(68), status: GoalAchieved, percent: 100},
+		{
+			name: "point since start reached target", goal: weight, current: value(72),
+			points: points(-5, 69.9, 0, 72), status: GoalAchieved, percent: 100,
+		},
+		{
+			name: "point before start does not count", goal: weight, current: value(78),
+			points: points(-40, 69), status: GoalBehind, percent: 20,
+		},
+		{
+			name: "achieved stays achieved", goal: with(weight, func(g *Goal) { g.AchievedAt = &achievedAt }),
+			current: value(79), status: GoalAchieved, percent: 100,
+		},
+		{name: "nothing logged", goal: weight, status: GoalBehind, percent: 0},
+		{
+			name: "on track by projection", goal: weight, current: value(78),
+			points: points(-4, 80, 0, 78), status: GoalOnTrack, percent: 20, projected: goalDate(16),
+		},
+		{
+			name: "behind when projection misses deadline", goal: weight, current: value(78),
+			points: points(-16, 80, 0, 78), status: GoalBehind, percent: 20, projected: goalDate(64),
+		},
+		{name: "on track by pace", goal: weight, current: value(74), status: GoalOnTrack, percent: 60},
+		{name: "exactly on pace", goal: weight, current: value(75), status: GoalOnTrack, percent: 50},
+		{
+			name: "behind while heading away", goal: weight, current: value(78),
+			points: points(-8, 77, 0, 78), status: GoalBehind, percent: 20,
+		},
+		{name: "moving away clamps at zero", goal: weight, current: value(85), status: GoalBehind, percent: 0},
+		{
+			name: "past deadline", goal: with(weight, func(g *Goal) { g.StartDate, g.Deadline = goalDate(-60), goalDate(-1) }),
+			current: value(72), points: points(-8, 74, 0, 72), status: GoalBehind, percent: 80, projected: goalDate(8),
+		},
+		{
+			name:    "lift uses eight weeks of points",
+			goal:    Goal{Kind: GoalLift, StartValue: 100, TargetValue: 120, StartDate: goalDate(-60), Deadline: goalDate(60)},
+			current: value(102), points: points(-48, 100, -40, 102), status: GoalOnTrack, percent: 10, projected: goalDate(32),
+		},
+		{
+			name:    "weight ignores points over four weeks old",
+			goal:    with(weight, func(g *Goal) { g.StartDate = goalDate(-60) }),
+			current: value(78), points: points(-48, 80, -40, 78), status: GoalBehind, percent: 20,
+		},
+		{
+			name:    "habit streak on track",
+			goal:    Goal{Kind: GoalHabit, StartValue: 0, TargetValue: 30, StartDate: goalDate(-10), Deadline: goalDate(30)},
+			current: value(10), status: GoalOnTrack, percent: 33, projected: goalDate(20),
+		},
+		{
+			name:    "habit streak too short for deadline",
+			goal:    Goal{Kind: GoalHabit, StartValue: 0, TargetValue: 30, StartDate: goalDate(-20), Deadline: goalDate(10)},
+			current: value(5), status: GoalBehind, percent: 17, projected: goalDate(25),
+		},
+		{
+			name:   "habit without a streak",
+			goal:   Goal{Kind: GoalHabit, StartValue: 0, TargetValue: 7, StartDate: goalDate(0), Deadline: goalDate(14)},
+			status: GoalOnTrack, percent: 0, projected: goalDate(7),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := ComputeGoalProgress(tt.goal, tt.current, tt.points, goalNow)
+			if p.Status != tt.status || p.Percent != tt.percent {
+				t.Errorf("status, percent = %s, %v; want %s, %v", p.Status, p.Percent, tt.status, tt.percent)
+			}
+			var projected string
+			if p.Projected != nil {
+				projected = *p.Projected
+			}
+			if projected != tt.projected {
+				t.Errorf("projected = %q, want %q", projected, tt.projected)
+			}
+
+			// A newly achieved goal is stamped now; an old one keeps its date
+			switch {
+			case p.Status != GoalAchieved && p.AchievedAt != nil:
+				t.Errorf("AchievedAt = %v on a goal that is %s", p.AchievedAt, p.Status)
+			case p.Status == GoalAchieved && tt.goal.AchievedAt != nil && !p.AchievedAt.Equal(*tt.goal.AchievedAt):
+				t.Errorf("AchievedAt = %v, want it kept at %v", p.AchievedAt, tt.goal.AchievedAt)
+			case p.Status == GoalAchieved && tt.goal.AchievedAt == nil && (p.AchievedAt == nil || !p.AchievedAt.Equal(goalNow)):
+				t.Errorf("AchievedAt = %v, want %v", p.AchievedAt, goalNow)
+			}
+		})
+	}
+}
+
+func TestWritesSurviveGoalRefreshFailure(t *testing.T) {
+	s := useMemoryStore(t)
+	id := mustCreateUser(t, "al", "member")
+	// Without the goal tables every refresh fails
+	for _, table := range []string{"goal_events", "goals"} {
+		if _, err := s.db.Exec("DROP TABLE " + table); err != nil {
+			t.Fatalf("dropping %s: %v", table, err)
+		}
+	}
+	if err := s.RefreshGoals(id); err == nil {
+		t.Fatal("RefreshGoals succeeded without the goal tables")
+	}
+
+	started := time.Now().Add(-time.Hour)
+	if _, err := AddCardioSession(id, CardioSession{Activity: "running", StartedAt: started, DurationSeconds: 1800}); err != nil {
+		t.Errorf("AddCardioSession: %v", err)
+	}
+	sessions, err := ListCardioSessions(id, started.Add(-time.Minute), time.Now(), 10)
+	if err != nil || len(sessions) != 1 {
+		t.Errorf("ListCardioSessions = %d sessions, %v; want the stored one", len(sessions), err)
+	}
+	if _, err := AddWater(id, 250, time.Now()); err != nil {
+		t.Errorf("AddWater: %v", err)
+	}
+}
+
+func TestUpdateGoalEvents(t *testing.T) {
+	s := useMemoryStore(t)
+	id := mustCreateUser(t, "al", "member")
+	if _, err := AddMeasurement(id, BodyMeasurement{WeightKG: value(74)}); err != nil {
+		t.Fatalf("AddMeasurement: %v", err)
+	}
+	events := func(goalID int64) []string {
+		t.Helper()
+		rows, err := s.db.Query("SELECT status FROM goal_events WHERE goal_id = ? ORDER BY id", goalID)
+		if err != nil {
+			t.Fatalf("listing goal events: %v", err)
+		}
+		defer rows.Close()
+		var statuses []string
+		for rows.Next() {
+			var status string
+			if err := rows.Scan(&status); err != nil {
+				t.Fatalf("scanning goal event: %v", err)
+			}
+			statuses = append(statuses, status)
+		}
+		return statuses
+	}
+
+	now := time.Now()
+	g := Goal{
+		Kind: GoalWeight, Title: "Cut", StartValue: 80, TargetValue: 75,
+		StartDate: now.AddDate(0, 0, -7).Format(dateLayout), Deadline: now.AddDate(0, 0, 30).Format(dateLayout),
+	}
+	created, err := CreateGoal(id, g)
+	if err != nil || created.Status != GoalAchieved {
+		t.Fatalf("CreateGoal = %+v, %v; want achieved", created, err)
+	}
+
+	// Renaming an achieved goal keeps it achieved without a second event
+	g.ID, g.Title = created.ID, "Summer cut"
+	updated, err := UpdateGoal(id, g)
+	if err != nil || updated.Status != GoalAchieved || updated.AchievedAt == nil {
+		t.Fatalf("UpdateGoal(title) = %+v, %v; want achieved", updated, err)
+	}
+	if got := events(g.ID); len(got) != 1 {
+		t.Errorf("events after renaming = %v, want one achieved", got)
+	}
+
+	// A target it no longer meets takes the achievement back
+	g.TargetValue = 70
+	updated, err = UpdateGoal(id, g)
+	if err != nil || updated.Status == GoalAchieved || updated.AchievedAt != nil {
+		t.Fatalf("UpdateGoal(target) = %+v, %v; want not achieved", updated, err)
+	}
+	if got := events(g.ID); len(got) != 2 || got[1] == GoalAchieved {
+		t.Errorf("events after raising the target = %v", got)
+	}
+}
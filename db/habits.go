@@ -0,0 +1,342 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keys of the built-in habits stored as user_progress columns
+const (
+	HabitWorkout = "workout"
+	HabitMeals   = "meals"
+	HabitWater   = "water"
+)
+
+// BuiltinHabits are the daily habits every member has
+var BuiltinHabits = []Habit{
+	{Key: HabitWorkout, Name: "Workout Completed", BuiltIn: true},
+	{Key: HabitMeals, Name: "Meals Logged", BuiltIn: true},
+	{Key: HabitWater, Name: "Water Target Met", BuiltIn: true, Derived: true},
+}
+
+var progressColumns = map[string]string{
+	HabitWorkout: "workout_done",
+	HabitMeals:   "meals_logged",
+	HabitWater:   "water_done",
+}
+
+const customHabitPrefix = "custom-"
+
+// Habit is something a member checks in for daily. Built-in habits are
+// identified by their key, custom ones by "custom-<id>". Derived habits
+// follow logged data and cannot be checked in by hand.
+type Habit struct {
+	ID      int64  `json:"id,omitempty"`
+	Key     string `json:"key"`
+	Name    string `json:"name"`
+	BuiltIn bool   `json:"builtIn"`
+	Derived bool   `json:"derived,omitempty"`
+}
+
+// CheckIn records that a habit was done on a day (YYYY-MM-DD)
+type CheckIn struct {
+	Habit string `json:"habit"`
+	Date  string `json:"date"`
+}
+
+// HabitStreak is the current and longest run of consecutive days
+type HabitStreak struct {
+	Habit    string `json:"habit"`
+	Name     string `json:"name"`
+	Current  int    `json:"current"`
+	Longest  int    `json:"longest"`
+	Total    int    `json:"total"`
+	LastDone string `json:"lastDone,omitempty"`
+}
+
+// HabitStore persists custom habits and daily check-ins
+type HabitStore interface {
+	ListHabits(userID int64) ([]Habit, error)
+	CreateHabit(userID int64, name string) (*Habit, error)
+	RenameHabit(userID, id int64, name string) error
+	DeleteHabit(userID, id int64) error
+	SetCheckIn(userID int64, habit, date string, done bool) error
+	ListCheckIns(userID int64, from, to string) ([]CheckIn, error)
+}
+
+// ErrHabitExists is returned when a member already has a habit by that name
+var ErrHabitExists = errors.New("habit already exists")
+
+// ErrDerivedHabit is returned when checking in a habit that is worked out
+// from logged data, such as the water target, or unticking meals on a day
+// with logged food
+var ErrDerivedHabit = errors.New("habit follows logged data")
+
+func customHabitKey(id int64) string {
+	return customHabitPrefix + strconv.FormatInt(id, 10)
+}
+
+// parseHabitKey returns the user_progress column of a built-in habit or
+// the ID of a custom one
+func parseHabitKey(key string) (column string, id int64, ok bool) {
+	if column, ok := progressColumns[key]; ok {
+		return column, 0, true
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(key, customHabitPrefix), 10, 64)
+	if !strings.HasPrefix(key, customHabitPrefix) || err != nil {
+		return "", 0, false
+	}
+	return "", id, true
+}
+
+// ListHabits returns the built-in habits followed by the member's own
+func (s *SQLStore) ListHabits(userID int64) ([]Habit, error) {
+	habits := append([]Habit{}, BuiltinHabits...)
+	rows, err := s.db.Query("SELECT id, name FROM habits WHERE user_id = ? ORDER BY created_at, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var h Habit
+		if err := rows.Scan(&h.ID, &h.Name); err != nil {
+			return nil, err
+		}
+		h.Key = customHabitKey(h.ID)
+		habits = append(habits, h)
+	}
+	return habits, rows.Err()
+}
+
+// CreateHabit adds a custom habit for the member
+func (s *SQLStore) CreateHabit(userID int64, name string) (*Habit, error) {
+	if err := s.checkHabitName(userID, 0, name); err != nil {
+		return nil, err
+	}
+	result, err := s.db.Exec("INSERT INTO habits (user_id, name, created_at) VALUES (?, ?, ?)",
+		userID, name, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &Habit{ID: id, Key: customHabitKey(id), Name: name}, nil
+}
+
+// RenameHabit changes the name of one of the member's custom habits
+func (s *SQLStore) RenameHabit(userID, id int64, name string) error {
+	if err := s.checkHabitName(userID, id, name); err != nil {
+		return err
+	}
+	result, err := s.db.Exec("UPDATE habits SET name = ? WHERE id = ? AND user_id = ?", name, id, userID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// DeleteHabit removes a custom habit together with its check-ins
+func (s *SQLStore) DeleteHabit(userID, id int64) error {
+	result, err := s.db.Exec("DELETE FROM habits WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(result); err != nil {
+		return err
+	}
+	s.refreshGoalsAfter(userID)
+	return nil
+}
+
+func (s *SQLStore) checkHabitName(userID, id int64, name string) error {
+	var other int64
+	err := s.db.QueryRow("SELECT id FROM habits WHERE user_id = ? AND name = ? AND id <> ?", userID, name, id).Scan(&other)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return ErrHabitExists
+}
+
+// SetCheckIn marks a habit done or not done on a day (YYYY-MM-DD)
+func (s *SQLStore) SetCheckIn(userID int64, habit, date string, done bool) error {
+	column, id, ok := parseHabitKey(habit)
+	if !ok {
+		return ErrNotFound
+	}
+	if habit == HabitWater {
+		return ErrDerivedHabit
+	}
+	if habit == HabitMeals && !done {
+		logged, err := hasFoodEntries(s.db, userID, date)
+		if err != nil {
+			return err
+		}
+		if logged {
+			return ErrDerivedHabit
+		}
+	}
+
+	if column != "" {
+		query := `INSERT INTO user_progress (user_id, date, ` + column + `) VALUES (?, ?, ?)
+		` + s.dialect.upsert([]string{"user_id", "date"}, column)
+		if _, err := s.db.Exec(query, userID, date, done); err != nil {
+			return err
+		}
+		s.refreshGoalsAfter(userID)
+		return nil
+	}
+
+	var owner int64
+	err := s.db.QueryRow("SELECT user_id FROM habits WHERE id = ? AND user_id = ?", id, userID).Scan(&owner)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if done {
+		_, err = s.db.Exec(`INSERT INTO habit_checkins (habit_id, date) VALUES (?, ?)
+			`+s.dialect.upsert([]string{"habit_id", "date"}, "date"), id, date)
+	} else {
+		_, err = s.db.Exec("DELETE FROM habit_checkins WHERE habit_id = ? AND date = ?", id, date)
+	}
+	if err != nil {
+		return err
+	}
+	s.refreshGoalsAfter(userID)
+	return nil
+}
+
+// ListCheckIns returns the done habits for days in [from, to], both
+// YYYY-MM-DD, ordered by date. An empty from starts at the first check-in.
+func (s *SQLStore) ListCheckIns(userID int64, from, to string) ([]CheckIn, error) {
+	if from == "" {
+		from = "0001-01-01"
+	}
+	checkIns := []CheckIn{}
+
+	rows, err := s.db.Query(`SELECT date, workout_done, meals_logged, water_done FROM user_progress
+		WHERE user_id = ? AND date >= ? AND date <= ?`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var date time.Time
+		var workout, meals, water bool
+		if err := rows.Scan(&date, &workout, &meals, &water); err != nil {
+			return nil, err
+		}
+		day := date.Format(dateLayout)
+		for key, done := range map[string]bool{HabitWorkout: workout, HabitMeals: meals, HabitWater: water} {
+			if done {
+				checkIns = append(checkIns, CheckIn{Habit: key, Date: day})
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	custom, err := s.db.Query(`SELECT c.habit_id, c.date FROM habit_checkins c
+		JOIN habits h ON h.id = c.habit_id
+		WHERE h.user_id = ? AND c.date >= ? AND c.date <= ?`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer custom.Close()
+	for custom.Next() {
+		var id int64
+		var date time.Time
+		if err := custom.Scan(&id, &date); err != nil {
+			return nil, err
+		}
+		checkIns = append(checkIns, CheckIn{Habit: customHabitKey(id), Date: date.Format(dateLayout)})
+	}
+	if err := custom.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(checkIns, func(i, j int) bool {
+		if checkIns[i].Date != checkIns[j].Date {
+			return checkIns[i].Date < checkIns[j].Date
+		}
+		return checkIns[i].Habit < checkIns[j].Habit
+	})
+	return checkIns, nil
+}
+
+// ComputeHabitStreaks counts consecutive days per habit. A current streak
+// survives until the end of the day after the last check-in, so it doesn't
+// drop to zero in the morning before the member has checked in.
+func ComputeHabitStreaks(habits []Habit, checkIns []CheckIn, today string) []HabitStreak {
+	days := make(map[string][]string)
+	for _, c := range checkIns {
+		days[c.Habit] = append(days[c.Habit], c.Date)
+	}
+	now, _ := time.ParseInLocation(dateLayout, today, time.Local)
+	yesterday := now.AddDate(0, 0, -1).Format(dateLayout)
+
+	streaks := make([]HabitStreak, 0, len(habits))
+	for _, h := range habits {
+		streak := HabitStreak{Habit: h.Key, Name: h.Name}
+		dates := days[h.Key]
+		sort.Strings(dates)
+
+		run := 0
+		var prev time.Time
+		for _, date := range dates {
+			d, _ := time.ParseInLocation(dateLayout, date, time.Local)
+			if run > 0 && d.Equal(prev.AddDate(0, 0, 1)) {
+				run++
+			} else {
+				run = 1
+			}
+			prev = d
+			streak.Longest = max(streak.Longest, run)
+			streak.Total++
+		}
+		if n := len(dates); n > 0 {
+			streak.LastDone = dates[n-1]
+			if streak.LastDone == today || streak.LastDone == yesterday {
+				streak.Current = run
+			}
+		}
+		streaks = append(streaks, streak)
+	}
+	return streaks
+}
+
+func ListHabits(userID int64) ([]Habit, error) {
+	return current.ListHabits(userID)
+}
+
+func CreateHabit(userID int64, name string) (*Habit, error) {
+	return current.CreateHabit(userID, name)
+}
+
+func RenameHabit(userID, id int64, name string) error {
+	return current.RenameHabit(userID, id, name)
+}
+
+func DeleteHabit(userID, id int64) error {
+	return current.DeleteHabit(userID, id)
+}
+
+func SetCheckIn(userID int64, habit, date string, done bool) error {
+	return current.SetCheckIn(userID, habit, date, done)
+}
+
+func ListCheckIns(userID int64, from, to string) ([]CheckIn, error) {
+	return current.ListCheckIns(userID, from, to)
+}
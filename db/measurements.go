@@ -0,0 +1,281 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// BodyMeasurement is one entry in a member's body measurement history.
+// Every metric is optional so a weigh-in and a tape measurement can be
+// logged separately.
+type BodyMeasurement struct {
+	ID         int64     `json:"id"`
+	MeasuredAt time.Time `json:"measuredAt"`
+	WeightKG   *float64  `json:"weightKg,omitempty"`
+	BodyFatPct *float64  `json:"bodyFatPct,omitempty"`
+	WaistCM    *float64  `json:"waistCm,omitempty"`
+	ChestCM    *float64  `json:"chestCm,omitempty"`
+	HipsCM     *float64  `json:"hipsCm,omitempty"`
+	ArmCM      *float64  `json:"armCm,omitempty"`
+	ThighCM    *float64  `json:"thighCm,omitempty"`
+	NeckCM     *float64  `json:"neckCm,omitempty"`
+	Note       string    `json:"note"`
+}
+
+// MeasurementStore persists body measurement history
+type MeasurementStore interface {
+	AddMeasurement(userID int64, m BodyMeasurement) (int64, error)
+	UpdateMeasurement(userID int64, m BodyMeasurement) error
+	DeleteMeasurement(userID, id int64) error
+	ListMeasurements(userID int64, from, to time.Time) ([]BodyMeasurement, error)
+}
+
+// ErrNotFound is returned when a row does not exist or belongs to another user
+var ErrNotFound = errors.New("not found")
+
+const measurementColumns = `weight_kg, body_fat_pct, waist_cm, chest_cm, hips_cm, arm_cm, thigh_cm, neck_cm, note`
+
+func (m *BodyMeasurement) values() []any {
+	return []any{m.WeightKG, m.BodyFatPct, m.WaistCM, m.ChestCM, m.HipsCM, m.ArmCM, m.ThighCM, m.NeckCM, m.Note}
+}
+
+// AddMeasurement records a new measurement, defaulting the time to now
+func (s *SQLStore) AddMeasurement(userID int64, m BodyMeasurement) (int64, error) {
+	if m.MeasuredAt.IsZero() {
+		m.MeasuredAt = time.Now()
+	}
+	query := `INSERT INTO body_measurements (user_id, measured_at, ` + measurementColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	args := append([]any{userID, m.MeasuredAt.UTC()}, m.values()...)
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if m.WeightKG != nil || m.BodyFatPct != nil {
+		if err := s.RecalculateMetrics(userID); err != nil {
+			return id, err
+		}
+	}
+	s.refreshGoalsAfter(userID)
+	return id, nil
+}
+
+// UpdateMeasurement overwrites one of the user's measurements
+func (s *SQLStore) UpdateMeasurement(userID int64, m BodyMeasurement) error {
+	query := `UPDATE body_measurements SET measured_at = ?, weight_kg = ?, body_fat_pct = ?, waist_cm = ?,
+		chest_cm = ?, hips_cm = ?, arm_cm = ?, thigh_cm = ?, neck_cm = ?, note = ?
+		WHERE id = ? AND user_id = ?`
+	args := append([]any{m.MeasuredAt.UTC()}, m.values()...)
+	args = append(args, m.ID, userID)
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(result); err != nil {
+		return err
+	}
+	if err := s.RecalculateMetrics(userID); err != nil {
+		return err
+	}
+	s.refreshGoalsAfter(userID)
+	return nil
+}
+
+// DeleteMeasurement removes one of the user's measurements
+func (s *SQLStore) DeleteMeasurement(userID, id int64) error {
+	result, err := s.db.Exec("DELETE FROM body_measurements WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(result); err != nil {
+		return err
+	}
+	if err := s.RecalculateMetrics(userID); err != nil {
+		return err
+	}
+	s.refreshGoalsAfter(userID)
+	return nil
+}
+
+// ListMeasurements returns measurements in [from, to) oldest first.
+// A zero bound leaves that side of the range open.
+func (s *SQLStore) ListMeasurements(userID int64, from, to time.Time) ([]BodyMeasurement, error) {
+	query := `SELECT id, measured_at, ` + measurementColumns + `
+		FROM body_measurements WHERE user_id = ?`
+	args := []any{userID}
+	if !from.IsZero() {
+		query += " AND measured_at >= ?"
+		args = append(args, from.UTC())
+	}
+	if !to.IsZero() {
+		query += " AND measured_at < ?"
+		args = append(args, to.UTC())
+	}
+	query += " ORDER BY measured_at ASC, id ASC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var measurements []BodyMeasurement
+	for rows.Next() {
+		var m BodyMeasurement
+		var weight, fat, waist, chest, hips, arm, thigh, neck sql.NullFloat64
+		err := rows.Scan(&m.ID, &m.MeasuredAt, &weight, &fat, &waist, &chest, &hips, &arm, &thigh, &neck, &m.Note)
+		if err != nil {
+			return nil, err
+		}
+		m.WeightKG, m.BodyFatPct = nullFloat(weight), nullFloat(fat)
+		m.WaistCM, m.ChestCM, m.HipsCM = nullFloat(waist), nullFloat(chest), nullFloat(hips)
+		m.ArmCM, m.ThighCM, m.NeckCM = nullFloat(arm), nullFloat(thigh), nullFloat(neck)
+		measurements = append(measurements, m)
+	}
+	return measurements, rows.Err()
+}
+
+// recordWeightChange logs a history entry when a profile save changes the
+// stored weight, so overwriting user_info no longer loses data.
+func (s *SQLStore) recordWeightChange(userID int64, oldWeight sql.NullFloat64, newWeight float64) error {
+	if newWeight <= 0 || (oldWeight.Valid && oldWeight.Float64 == newWeight) {
+		return nil
+	}
+	_, err := s.AddMeasurement(userID, BodyMeasurement{WeightKG: &newWeight, Note: "profile"})
+	return err
+}
+
+func (s *SQLStore) profileWeight(userID int64) (sql.NullFloat64, error) {
+	var weight sql.NullFloat64
+	err := s.db.QueryRow("SELECT weight_kg FROM user_info WHERE user_id = ?", userID).Scan(&weight)
+	if err == sql.ErrNoRows {
+		return weight, nil
+	}
+	return weight, err
+}
+
+func AddMeasurement(userID int64, m BodyMeasurement) (int64, error) {
+	return current.AddMeasurement(userID, m)
+}
+
+func UpdateMeasurement(userID int64, m BodyMeasurement) error {
+	return current.UpdateMeasurement(userID, m)
+}
+
+func DeleteMeasurement(userID, id int64) error {
+	return current.DeleteMeasurement(userID, id)
+}
+
+func ListMeasurements(userID int64, from, to time.Time) ([]BodyMeasurement, error) {
+	return current.ListMeasurements(userID, from, to)
+}
+
+// WeightPoint is the 7-day moving average of body weight on one day
+type WeightPoint struct {
+	Date          string  `json:"date"`
+	WeightKG      float64 `json:"weightKg"`
+	MovingAverage float64 `json:"movingAverage"`
+}
+
+// WeightTrend summarises recent weight history
+type WeightTrend struct {
+	Latest        *float64      `json:"latest,omitempty"`
+	MovingAverage *float64      `json:"movingAverage,omitempty"`
+	WeeklyDelta   *float64      `json:"weeklyDelta,omitempty"`
+	Points        []WeightPoint `json:"points"`
+}
+
+// ComputeWeightTrend averages weigh-ins per day, then computes a trailing
+// 7-day moving average for each day and the change of that average over
+// the last week. Measurements must be sorted oldest first.
+func ComputeWeightTrend(measurements []BodyMeasurement) WeightTrend {
+	daily := make(map[string][]float64)
+	for _, m := range measurements {
+		if m.WeightKG == nil {
+			continue
+		}
+		day := m.MeasuredAt.Local().Format(dateLayout)
+		daily[day] = append(daily[day], *m.WeightKG)
+	}
+
+	days := make([]string, 0, len(daily))
+	for day := range daily {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	trend := WeightTrend{Points: []WeightPoint{}}
+	averages := make(map[string]float64, len(days))
+	for _, day := range days {
+		d, _ := time.ParseInLocation(dateLayout, day, time.Local)
+		var sum float64
+		var n int
+		for _, other := range days {
+			o, _ := time.ParseInLocation(dateLayout, other, time.Local)
+			if !o.After(d) && o.After(d.AddDate(0, 0, -7)) {
+				sum += mean(daily[other])
+				n++
+			}
+		}
+		avg := round1(sum / float64(n))
+		averages[day] = avg
+		trend.Points = append(trend.Points, WeightPoint{Date: day, WeightKG: round1(mean(daily[day])), MovingAverage: avg})
+	}
+
+	if len(trend.Points) == 0 {
+		return trend
+	}
+	last := trend.Points[len(trend.Points)-1]
+	latest, avg := last.WeightKG, last.MovingAverage
+	trend.Latest, trend.MovingAverage = &latest, &avg
+
+	// Compare with the moving average on (or just before) a week earlier
+	lastDay, _ := time.ParseInLocation(dateLayout, last.Date, time.Local)
+	weekAgo := lastDay.AddDate(0, 0, -7).Format(dateLayout)
+	for i := len(days) - 1; i >= 0; i-- {
+		if days[i] <= weekAgo {
+			delta := round1(avg - averages[days[i]])
+			trend.WeeklyDelta = &delta
+			break
+		}
+	}
+	return trend
+}
+
+func expectRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	f := v.Float64
+	return &f
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
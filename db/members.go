@@ -0,0 +1,243 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// Orders a member search can be sorted in
+const (
+	SortByName       = "name"
+	SortByUsername   = "username"
+	SortByAge        = "age"
+	SortByBMI        = "bmi"
+	SortByLastActive = "lastActive"
+)
+
+// ErrBadCursor is returned for a cursor that is malformed or was issued for
+// another sort order
+var ErrBadCursor = errors.New("invalid cursor")
+
+// MemberFilter selects and orders members. Zero values don't filter.
+// CoachID limits the search to that coach's active clients; 0 searches
+// every member. Cursor is the NextCursor of the previous page.
+type MemberFilter struct {
+	CoachID     int64
+	Search      string
+	Gender      string
+	MinAge      int
+	MaxAge      int
+	MinBMI      float64
+	MaxBMI      float64
+	ActiveSince string // YYYY-MM-DD
+	Sort        string
+	Desc        bool
+	Limit       int
+	Cursor      string
+}
+
+// MemberSummary is a member's profile as a coach sees it in their list.
+// BMI is missing without a height, LastActive (YYYY-MM-DD) without any
+// logged workout, cardio, measurement or habit.
+type MemberSummary struct {
+	ID         int64    `json:"id"`
+	Username   string   `json:"username"`
+	FullName   string   `json:"fullName"`
+	Age        int      `json:"age"`
+	Gender     string   `json:"gender"`
+	HeightCM   float64  `json:"heightCm"`
+	WeightKG   float64  `json:"weightKg"`
+	BMI        *float64 `json:"bmi,omitempty"`
+	Goal       string   `json:"goal"`
+	LastActive string   `json:"lastActive,omitempty"`
+}
+
+// MemberPage is one page of a member search. NextCursor is empty on the
+// last page.
+type MemberPage struct {
+	Members    []MemberSummary `json:"members"`
+	NextCursor string          `json:"nextCursor,omitempty"`
+}
+
+// MemberStore searches member profiles
+type MemberStore interface {
+	SearchMembers(f MemberFilter) (*MemberPage, error)
+}
+
+const bmiExpr = `CASE WHEN ui.height_cm > 0 THEN ui.weight_kg * 10000 / (ui.height_cm * ui.height_cm) END`
+
+// lastActiveQuery finds each member's latest day with anything logged.
+// Timestamps are compared by their date prefix, which both backends store.
+const lastActiveQuery = `
+	SELECT user_id, MAX(day) AS day FROM (
+		SELECT user_id, SUBSTR(started_at, 1, 10) AS day FROM workout_sessions
+		UNION ALL SELECT user_id, SUBSTR(started_at, 1, 10) FROM cardio_sessions
+		UNION ALL SELECT user_id, SUBSTR(measured_at, 1, 10) FROM body_measurements
+		UNION ALL SELECT h.user_id, SUBSTR(c.date, 1, 10) FROM habit_checkins c JOIN habits h ON h.id = c.habit_id
+		UNION ALL SELECT user_id, SUBSTR(date, 1, 10) FROM user_progress
+			WHERE workout_done = 1 OR meals_logged = 1 OR water_done = 1
+	) activity GROUP BY user_id`
+
+// sortKeys are the ORDER BY expressions of each sort order. They never
+// return NULL so that keyset pagination can compare them.
+var sortKeys = map[string]string{
+	SortByName:       "LOWER(ui.full_name)",
+	SortByUsername:   "LOWER(p.username)",
+	SortByAge:        "ui.age",
+	SortByBMI:        "COALESCE(" + bmiExpr + ", 0)",
+	SortByLastActive: "COALESCE(la.day, '')",
+}
+
+// memberCursor is the position after the last member of a page
+type memberCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value any    `json:"v"`
+	ID    int64  `json:"id"`
+}
+
+func (c memberCursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeMemberCursor(s string) (*memberCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrBadCursor
+	}
+	var c memberCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, ErrBadCursor
+	}
+	return &c, nil
+}
+
+// SearchMembers returns a page of members matching f. Pages are keyed on
+// the sort value and user ID, so they stay stable while members are added.
+func (s *SQLStore) SearchMembers(f MemberFilter) (*MemberPage, error) {
+	if f.Sort == "" {
+		f.Sort = SortByName
+	}
+	key, ok := sortKeys[f.Sort]
+	if !ok {
+		return nil, errors.New("unknown sort order " + f.Sort)
+	}
+
+	query := `SELECT p.id, p.username, ui.full_name, ui.age, ui.gender, ui.height_cm, ui.weight_kg, ` + bmiExpr + `,
+			ui.fitness_goal, la.day, ` + key + `
+		FROM user_info ui
+		JOIN person p ON p.id = ui.user_id
+		LEFT JOIN (` + lastActiveQuery + `) la ON la.user_id = ui.user_id`
+	var where []string
+	var args []any
+	if f.CoachID != 0 {
+		query += `
+		JOIN coach_clients cc ON cc.member_id = ui.user_id AND cc.coach_id = ? AND cc.status = ?`
+		args = append(args, f.CoachID, LinkActive)
+	}
+	if f.Search != "" {
+		like := "%" + likeEscaper.Replace(strings.ToLower(f.Search)) + "%"
+		where = append(where, "(LOWER(ui.full_name) LIKE ? ESCAPE '!' OR LOWER(p.username) LIKE ? ESCAPE '!')")
+		args = append(args, like, like)
+	}
+	if f.Gender != "" {
+		where = append(where, "LOWER(ui.gender) = ?")
+		args = append(args, strings.ToLower(f.Gender))
+	}
+	if f.MinAge > 0 {
+		where = append(where, "ui.age >= ?")
+		args = append(args, f.MinAge)
+	}
+	if f.MaxAge > 0 {
+		where = append(where, "ui.age <= ?")
+		args = append(args, f.MaxAge)
+	}
+	if f.MinBMI > 0 {
+		where = append(where, bmiExpr+" >= ?")
+		args = append(args, f.MinBMI)
+	}
+	if f.MaxBMI > 0 {
+		where = append(where, bmiExpr+" <= ?")
+		args = append(args, f.MaxBMI)
+	}
+	if f.ActiveSince != "" {
+		where = append(where, "la.day >= ?")
+		args = append(args, f.ActiveSince)
+	}
+
+	if f.Cursor != "" {
+		c, err := decodeMemberCursor(f.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.Sort != f.Sort || c.Desc != f.Desc {
+			return nil, ErrBadCursor
+		}
+		op := ">"
+		if f.Desc {
+			op = "<"
+		}
+		where = append(where, "("+key+" "+op+" ? OR ("+key+" = ? AND ui.user_id "+op+" ?))")
+		args = append(args, c.Value, c.Value, c.ID)
+	}
+	if len(where) > 0 {
+		query += `
+		WHERE ` + strings.Join(where, " AND ")
+	}
+
+	dir := "ASC"
+	if f.Desc {
+		dir = "DESC"
+	}
+	query += `
+		ORDER BY ` + key + ` ` + dir + `, ui.user_id ` + dir + `
+		LIMIT ?`
+	args = append(args, f.Limit+1)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &MemberPage{Members: []MemberSummary{}}
+	var last any
+	for rows.Next() {
+		var m MemberSummary
+		var bmi sql.NullFloat64
+		var lastActive sql.NullString
+		var sortValue any
+		err := rows.Scan(&m.ID, &m.Username, &m.FullName, &m.Age, &m.Gender, &m.HeightCM, &m.WeightKG, &bmi,
+			&m.Goal, &lastActive, &sortValue)
+		if err != nil {
+			return nil, err
+		}
+		if len(page.Members) == f.Limit {
+			page.NextCursor = memberCursor{Sort: f.Sort, Desc: f.Desc, Value: last, ID: page.Members[f.Limit-1].ID}.encode()
+			break
+		}
+		m.BMI = nullFloat(bmi)
+		m.LastActive = lastActive.String
+		page.Members = append(page.Members, m)
+		last = cursorValue(sortValue)
+	}
+	return page, rows.Err()
+}
+
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// cursorValue makes a scanned sort value survive the JSON round trip
+func cursorValue(v any) any {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return v
+}
+
+func SearchMembers(f MemberFilter) (*MemberPage, error) {
+	return current.SearchMembers(f)
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fitnesscoach/metrics"
+	"time"
+)
+
+// Metrics are a member's body metrics and energy needs as last worked out
+// from their profile
+type Metrics struct {
+	metrics.Result
+	BodyFatPct   *float64  `json:"bodyFatPct"`
+	CalculatedAt time.Time `json:"calculatedAt"`
+}
+
+// MetricsStore persists the inputs and results of the metrics calculator.
+// Saving a profile, the member's settings or a weight or body fat
+// measurement recalculates them.
+type MetricsStore interface {
+	GetMetrics(userID int64) (*Metrics, error)
+	SetMetricsSettings(userID int64, activity, goal string) error
+	RecalculateMetrics(userID int64) error
+}
+
+// GetMetrics returns the member's metrics, or ErrNotFound while their
+// profile lacks weight, height or age
+func (s *SQLStore) GetMetrics(userID int64) (*Metrics, error) {
+	var m Metrics
+	var katch sql.NullFloat64
+	err := s.db.QueryRow(`SELECT m.bmi, m.bmi_category, m.bmr_mifflin, m.bmr_katch, m.bmr, m.bmr_formula, m.tdee,
+			m.calories, m.protein_g, m.carbs_g, m.fat_g, m.fibre_g, m.calculated_at, ui.activity_level, ui.weight_goal
+		FROM user_metrics m JOIN user_info ui ON ui.user_id = m.user_id
+		WHERE m.user_id = ?`, userID).Scan(
+		&m.BMI, &m.BMICategory, &m.BMRMifflin, &katch, &m.BMR, &m.BMRFormula, &m.TDEE,
+		&m.Targets.Calories, &m.Targets.ProteinG, &m.Targets.CarbsG, &m.Targets.FatG, &m.Targets.FibreG,
+		&m.CalculatedAt, &m.Activity, &m.Goal)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.BMRKatch = nullFloat(katch)
+	if m.BodyFatPct, err = s.latestMeasurement(userID, "body_fat_pct"); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// SetMetricsSettings stores how active the member is and their weight goal,
+// then recalculates
+func (s *SQLStore) SetMetricsSettings(userID int64, activity, goal string) error {
+	result, err := s.db.Exec("UPDATE user_info SET activity_level = ?, weight_goal = ? WHERE user_id = ?",
+		activity, goal, userID)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(result); err != nil {
+		return err
+	}
+	return s.RecalculateMetrics(userID)
+}
+
+// RecalculateMetrics works the member's metrics out again from their
+// profile and latest weight and body fat measurements
+func (s *SQLStore) RecalculateMetrics(userID int64) error {
+	p := metrics.Profile{}
+	err := s.db.QueryRow(`SELECT weight_kg, height_cm, age, gender, activity_level, weight_goal
+		FROM user_info WHERE user_id = ?`, userID).
+		Scan(&p.WeightKG, &p.HeightCM, &p.Age, &p.Gender, &p.Activity, &p.Goal)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	weight, err := s.latestMeasurement(userID, "weight_kg")
+	if err != nil {
+		return err
+	}
+	if weight != nil {
+		p.WeightKG = *weight
+	}
+	if p.BodyFatPct, err = s.latestMeasurement(userID, "body_fat_pct"); err != nil {
+		return err
+	}
+
+	r, err := metrics.Calculate(p)
+	if errors.Is(err, metrics.ErrIncomplete) {
+		_, err := s.db.Exec("DELETE FROM user_metrics WHERE user_id = ?", userID)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO user_metrics (user_id, bmi, bmi_category, bmr_mifflin, bmr_katch, bmr, bmr_formula,
+			tdee, calories, protein_g, carbs_g, fat_g, fibre_g, calculated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`+s.dialect.upsert([]string{"user_id"}, "bmi", "bmi_category", "bmr_mifflin", "bmr_katch", "bmr", "bmr_formula",
+		"tdee", "calories", "protein_g", "carbs_g", "fat_g", "fibre_g", "calculated_at"),
+		userID, r.BMI, r.BMICategory, r.BMRMifflin, r.BMRKatch, r.BMR, r.BMRFormula,
+		r.TDEE, r.Targets.Calories, r.Targets.ProteinG, r.Targets.CarbsG, r.Targets.FatG, r.Targets.FibreG, time.Now().UTC())
+	return err
+}
+
+// latestMeasurement returns the member's most recent value of a
+// body_measurements column, or nil when they never recorded one
+func (s *SQLStore) latestMeasurement(userID int64, column string) (*float64, error) {
+	var value sql.NullFloat64
+	err := s.db.QueryRow(`SELECT `+column+` FROM body_measurements
+		WHERE user_id = ? AND `+column+` IS NOT NULL
+		ORDER BY measured_at DESC, id DESC LIMIT 1`, userID).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nullFloat(value), nil
+}
+
+func GetMetrics(userID int64) (*Metrics, error) {
+	return current.GetMetrics(userID)
+}
+
+func SetMetricsSettings(userID int64, activity, goal string) error {
+	return current.SetMetricsSettings(userID, activity, goal)
+}
+
+func RecalculateMetrics(userID int64) error {
+	return current.RecalculateMetrics(userID)
+}
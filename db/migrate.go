@@ -0,0 +1,338 @@
+package db
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*/*.sql
+var migrationFiles embed.FS
+
+// Migration is one versioned schema change with its rollback script
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState reports whether a migration has been applied
+type MigrationState struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrations returns the embedded migrations for the store's dialect ordered
+// by version. Files live in migrations/<dialect>/ and are named
+// NNNN_name.up.sql and NNNN_name.down.sql.
+func (s *SQLStore) Migrations() ([]Migration, error) {
+	dir := "migrations/" + string(s.dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		name := e.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: bad version: %w", name, err)
+		}
+
+		body, err := migrationFiles.ReadFile(dir + "/" + name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func (s *SQLStore) ensureMigrationsTable() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INT PRIMARY KEY,
+			name       VARCHAR(255) NOT NULL,
+			applied_at DATETIME NOT NULL
+		)`)
+	return err
+}
+
+func (s *SQLStore) appliedMigrations() (map[int]time.Time, error) {
+	if err := s.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// MigrationStatus lists every known migration and whether it is applied
+func (s *SQLStore) MigrationStatus() ([]MigrationState, error) {
+	migrations, err := s.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, len(migrations))
+	for i, m := range migrations {
+		at, ok := applied[m.Version]
+		states[i] = MigrationState{Migration: m, Applied: ok, AppliedAt: at}
+	}
+	return states, nil
+}
+
+// SchemaVersion returns the highest applied migration version, or 0
+func (s *SQLStore) SchemaVersion() (int, error) {
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// MigrateUp applies every pending migration
+func (s *SQLStore) MigrateUp() error {
+	migrations, err := s.Migrations()
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		return nil
+	}
+	return s.MigrateTo(migrations[len(migrations)-1].Version)
+}
+
+// MigrateDown rolls back the most recently applied migration
+func (s *SQLStore) MigrateDown() error {
+	migrations, err := s.Migrations()
+	if err != nil {
+		return err
+	}
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[migrations[i].Version]; !ok {
+			continue
+		}
+		if i == 0 {
+			return s.MigrateTo(0)
+		}
+		return s.MigrateTo(migrations[i-1].Version)
+	}
+	return nil
+}
+
+// MigrateTo moves the schema up or down until version is the latest applied
+// migration. Version 0 rolls everything back.
+func (s *SQLStore) MigrateTo(version int) error {
+	migrations, err := s.Migrations()
+	if err != nil {
+		return err
+	}
+	if version != 0 && !hasVersion(migrations, version) {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	// Apply pending migrations up to and including the target
+	for _, m := range migrations {
+		if m.Version > version {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := s.runScript(m.Up); err != nil {
+			return fmt.Errorf("migration %04d_%s up: %w", m.Version, m.Name, err)
+		}
+		if _, err := s.db.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+			m.Version, m.Name, time.Now().UTC()); err != nil {
+			return err
+		}
+		log.Printf("⬆️ Applied migration %04d_%s", m.Version, m.Name)
+	}
+
+	// Roll back applied migrations above the target, newest first
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version <= version {
+			break
+		}
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == "" {
+			return fmt.Errorf("migration %04d_%s has no down script", m.Version, m.Name)
+		}
+		if err := s.runScript(m.Down); err != nil {
+			return fmt.Errorf("migration %04d_%s down: %w", m.Version, m.Name, err)
+		}
+		if _, err := s.db.Exec("DELETE FROM schema_migrations WHERE version = ?", m.Version); err != nil {
+			return err
+		}
+		log.Printf("⬇️ Rolled back migration %04d_%s", m.Version, m.Name)
+	}
+	return nil
+}
+
+// migrator returns the current store when it supports schema migrations
+func migrator() (*SQLStore, error) {
+	s, ok := current.(*SQLStore)
+	if !ok {
+		return nil, errors.New("current store does not support migrations")
+	}
+	return s, nil
+}
+
+// MigrateUp applies every pending migration to the current store
+func MigrateUp() error {
+	s, err := migrator()
+	if err != nil {
+		return err
+	}
+	return s.MigrateUp()
+}
+
+// MigrateDown rolls back the newest migration on the current store
+func MigrateDown() error {
+	s, err := migrator()
+	if err != nil {
+		return err
+	}
+	return s.MigrateDown()
+}
+
+// MigrateTo moves the current store's schema to version
+func MigrateTo(version int) error {
+	s, err := migrator()
+	if err != nil {
+		return err
+	}
+	return s.MigrateTo(version)
+}
+
+// MigrationStatus lists the migrations known to the current store
+func MigrationStatus() ([]MigrationState, error) {
+	s, err := migrator()
+	if err != nil {
+		return nil, err
+	}
+	return s.MigrationStatus()
+}
+
+func hasVersion(migrations []Migration, version int) bool {
+	for _, m := range migrations {
+		if m.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// runScript executes a migration file one statement at a time, since the
+// MySQL driver rejects multi-statement Exec calls by default.
+func (s *SQLStore) runScript(script string) error {
+	for _, stmt := range splitStatements(script) {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements splits a script on semicolons that end a line and drops
+// full-line "--" comments.
+func splitStatements(script string) []string {
+	var stmts []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmt := strings.TrimSpace(current.String())
+			stmts = append(stmts, strings.TrimSuffix(stmt, ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS messages;
+DROP TABLE IF EXISTS user_progress;
+DROP TABLE IF EXISTS user_info;
+DROP TABLE IF EXISTS person;
@@ -0,0 +1,44 @@
+-- Core accounts, profiles, daily progress and chat tables.
+-- IF NOT EXISTS lets databases created by hand adopt the migration history.
+
+CREATE TABLE IF NOT EXISTS person (
+    id            INT AUTO_INCREMENT PRIMARY KEY,
+    username      VARCHAR(50)  NOT NULL,
+    email         VARCHAR(100) NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    role          VARCHAR(20)  NOT NULL DEFAULT 'member',
+    created_at    DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_person_username (username),
+    UNIQUE KEY uq_person_email (email)
+);
+
+CREATE TABLE IF NOT EXISTS user_info (
+    user_id   INT PRIMARY KEY,
+    full_name VARCHAR(100) NOT NULL,
+    age       INT          NOT NULL DEFAULT 0,
+    gender    VARCHAR(20)  NOT NULL DEFAULT '',
+    height_cm DOUBLE       NOT NULL DEFAULT 0,
+    weight_kg DOUBLE       NOT NULL DEFAULT 0,
+    CONSTRAINT fk_user_info_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_progress (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    user_id      INT     NOT NULL,
+    date         DATE    NOT NULL,
+    workout_done BOOLEAN NOT NULL DEFAULT FALSE,
+    meals_logged BOOLEAN NOT NULL DEFAULT FALSE,
+    water_done   BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE KEY uq_user_progress_day (user_id, date),
+    CONSTRAINT fk_user_progress_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS messages (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    sender_id   INT      NOT NULL,
+    receiver_id INT      NOT NULL,
+    message     TEXT     NOT NULL,
+    timestamp   DATETIME NOT NULL,
+    CONSTRAINT fk_messages_sender FOREIGN KEY (sender_id) REFERENCES person (id) ON DELETE CASCADE,
+    CONSTRAINT fk_messages_receiver FOREIGN KEY (receiver_id) REFERENCES person (id) ON DELETE CASCADE
+);
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS body_measurements (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    user_id      INT          NOT NULL,
+    measured_at  DATETIME     NOT NULL,
+    weight_kg    DOUBLE       NULL,
+    body_fat_pct DOUBLE       NULL,
+    waist_cm     DOUBLE       NULL,
+    chest_cm     DOUBLE       NULL,
+    hips_cm      DOUBLE       NULL,
+    arm_cm       DOUBLE       NULL,
+    thigh_cm     DOUBLE       NULL,
+    neck_cm      DOUBLE       NULL,
+    note         VARCHAR(255) NOT NULL DEFAULT '',
+    KEY idx_body_measurements_user_time (user_id, measured_at),
+    CONSTRAINT fk_body_measurements_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
+
+-- Seed the history with the weight currently stored on each profile
+INSERT INTO body_measurements (user_id, measured_at, weight_kg, note)
+SELECT user_id, NOW(), weight_kg, 'profile'
+FROM user_info
+WHERE weight_kg > 0;
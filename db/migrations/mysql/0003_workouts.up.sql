@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS workout_sessions (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    user_id     INT          NOT NULL,
+    name        VARCHAR(100) NOT NULL DEFAULT '',
+    started_at  DATETIME     NOT NULL,
+    finished_at DATETIME     NULL,
+    notes       TEXT         NOT NULL,
+    KEY idx_workout_sessions_user_time (user_id, started_at),
+    CONSTRAINT fk_workout_sessions_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS workout_exercises (
+    id            INT AUTO_INCREMENT PRIMARY KEY,
+    session_id    INT          NOT NULL,
+    exercise_name VARCHAR(100) NOT NULL,
+    position      INT          NOT NULL,
+    UNIQUE KEY uq_workout_exercises_name (session_id, exercise_name),
+    CONSTRAINT fk_workout_exercises_session FOREIGN KEY (session_id) REFERENCES workout_sessions (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS workout_sets (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    exercise_id  INT      NOT NULL,
+    set_number   INT      NOT NULL,
+    reps         INT      NOT NULL,
+    load_kg      DOUBLE   NOT NULL DEFAULT 0,
+    rpe          DOUBLE   NULL,
+    rest_seconds INT      NULL,
+    logged_at    DATETIME NOT NULL,
+    CONSTRAINT fk_workout_sets_exercise FOREIGN KEY (exercise_id) REFERENCES workout_exercises (id) ON DELETE CASCADE
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS cardio_sessions (
+    id               INT AUTO_INCREMENT PRIMARY KEY,
+    user_id          INT         NOT NULL,
+    activity         VARCHAR(30) NOT NULL,
+    started_at       DATETIME    NOT NULL,
+    duration_seconds INT         NOT NULL,
+    distance_km      DOUBLE      NULL,
+    elevation_m      DOUBLE      NULL,
+    avg_hr           INT         NULL,
+    max_hr           INT         NULL,
+    calories         INT         NULL,
+    notes            TEXT        NOT NULL,
+    KEY idx_cardio_sessions_user_time (user_id, started_at),
+    CONSTRAINT fk_cardio_sessions_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
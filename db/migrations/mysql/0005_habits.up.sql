@@ -0,0 +1,18 @@
+-- Member-defined habits on top of the built-in user_progress booleans.
+
+CREATE TABLE IF NOT EXISTS habits (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT         NOT NULL,
+    name       VARCHAR(60) NOT NULL,
+    created_at DATETIME    NOT NULL,
+    UNIQUE KEY uq_habits_user_name (user_id, name),
+    CONSTRAINT fk_habits_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS habit_checkins (
+    id       INT AUTO_INCREMENT PRIMARY KEY,
+    habit_id INT  NOT NULL,
+    date     DATE NOT NULL,
+    UNIQUE KEY uq_habit_checkins_habit_date (habit_id, date),
+    CONSTRAINT fk_habit_checkins_habit FOREIGN KEY (habit_id) REFERENCES habits (id) ON DELETE CASCADE
+);
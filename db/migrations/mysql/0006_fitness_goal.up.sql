@@ -0,0 +1,4 @@
+-- A member's own words on what they are training for, shared with the
+-- AI coach and their coach.
+
+ALTER TABLE user_info ADD COLUMN fitness_goal VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,25 @@
+-- Multi-turn AI chat. Older turns are folded into summary once the history
+-- outgrows the token budget; summarized_through is the last message ID the
+-- summary covers.
+
+CREATE TABLE IF NOT EXISTS ai_conversations (
+    id                 INT AUTO_INCREMENT PRIMARY KEY,
+    user_id            INT          NOT NULL,
+    title              VARCHAR(120) NOT NULL,
+    summary            TEXT         NOT NULL,
+    summarized_through INT          NOT NULL DEFAULT 0,
+    created_at         DATETIME     NOT NULL,
+    updated_at         DATETIME     NOT NULL,
+    KEY idx_ai_conversations_user_updated (user_id, updated_at),
+    CONSTRAINT fk_ai_conversations_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS ai_messages (
+    id              INT AUTO_INCREMENT PRIMARY KEY,
+    conversation_id INT         NOT NULL,
+    role            VARCHAR(20) NOT NULL,
+    content         TEXT        NOT NULL,
+    created_at      DATETIME    NOT NULL,
+    KEY idx_ai_messages_conversation (conversation_id, id),
+    CONSTRAINT fk_ai_messages_conversation FOREIGN KEY (conversation_id) REFERENCES ai_conversations (id) ON DELETE CASCADE
+);
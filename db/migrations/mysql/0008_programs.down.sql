@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS program_exercises;
+DROP TABLE IF EXISTS program_days;
+DROP TABLE IF EXISTS programs;
@@ -0,0 +1,45 @@
+-- Training programs: multi-week plans of days and exercises, written by the
+-- member or generated by the AI coach. A coach approves a pending program;
+-- editing it makes it pending again.
+
+CREATE TABLE IF NOT EXISTS programs (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    user_id     INT          NOT NULL,
+    title       VARCHAR(100) NOT NULL,
+    weeks       INT          NOT NULL,
+    notes       TEXT         NOT NULL,
+    source      VARCHAR(16)  NOT NULL DEFAULT 'member',
+    status      VARCHAR(16)  NOT NULL DEFAULT 'pending',
+    approved_by INT          NULL,
+    approved_at DATETIME     NULL,
+    created_at  DATETIME     NOT NULL,
+    updated_at  DATETIME     NOT NULL,
+    KEY idx_programs_user_created (user_id, created_at),
+    KEY idx_programs_status (status),
+    CONSTRAINT fk_programs_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE,
+    CONSTRAINT fk_programs_approver FOREIGN KEY (approved_by) REFERENCES person (id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS program_days (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    program_id INT          NOT NULL,
+    week       INT          NOT NULL,
+    day        INT          NOT NULL,
+    name       VARCHAR(100) NOT NULL DEFAULT '',
+    UNIQUE KEY uq_program_days_day (program_id, week, day),
+    CONSTRAINT fk_program_days_program FOREIGN KEY (program_id) REFERENCES programs (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS program_exercises (
+    id        INT AUTO_INCREMENT PRIMARY KEY,
+    day_id    INT          NOT NULL,
+    position  INT          NOT NULL,
+    type      VARCHAR(16)  NOT NULL,
+    exercise  VARCHAR(100) NOT NULL,
+    sets      INT          NOT NULL DEFAULT 0,
+    reps      VARCHAR(20)  NOT NULL DEFAULT '',
+    minutes   INT          NOT NULL DEFAULT 0,
+    intensity VARCHAR(100) NOT NULL DEFAULT '',
+    KEY idx_program_exercises_day (day_id, position),
+    CONSTRAINT fk_program_exercises_day FOREIGN KEY (day_id) REFERENCES program_days (id) ON DELETE CASCADE
+);
@@ -0,0 +1,16 @@
+-- Coach rosters. A link starts pending when one side invites or requests
+-- the other and becomes active once the other side accepts.
+
+CREATE TABLE IF NOT EXISTS coach_clients (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    coach_id     INT         NOT NULL,
+    member_id    INT         NOT NULL,
+    status       VARCHAR(16) NOT NULL DEFAULT 'pending',
+    initiated_by VARCHAR(16) NOT NULL,
+    created_at   DATETIME    NOT NULL,
+    accepted_at  DATETIME    NULL,
+    UNIQUE KEY uq_coach_clients_pair (coach_id, member_id),
+    KEY idx_coach_clients_member (member_id),
+    CONSTRAINT fk_coach_clients_coach FOREIGN KEY (coach_id) REFERENCES person (id) ON DELETE CASCADE,
+    CONSTRAINT fk_coach_clients_member FOREIGN KEY (member_id) REFERENCES person (id) ON DELETE CASCADE
+);
@@ -0,0 +1,33 @@
+-- Notes coaches keep about their clients, visible only to the coach who
+-- wrote them, and a log of profile edits for the client timeline.
+
+CREATE TABLE IF NOT EXISTS coach_notes (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    coach_id   INT      NOT NULL,
+    member_id  INT      NOT NULL,
+    body       TEXT     NOT NULL,
+    pinned     BOOLEAN  NOT NULL DEFAULT FALSE,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    KEY idx_coach_notes_pair (coach_id, member_id, created_at),
+    CONSTRAINT fk_coach_notes_coach FOREIGN KEY (coach_id) REFERENCES person (id) ON DELETE CASCADE,
+    CONSTRAINT fk_coach_notes_member FOREIGN KEY (member_id) REFERENCES person (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS coach_note_tags (
+    note_id INT         NOT NULL,
+    tag     VARCHAR(40) NOT NULL,
+    UNIQUE KEY uq_coach_note_tags (note_id, tag),
+    CONSTRAINT fk_coach_note_tags_note FOREIGN KEY (note_id) REFERENCES coach_notes (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS profile_changes (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT          NOT NULL,
+    field      VARCHAR(32)  NOT NULL,
+    old_value  VARCHAR(255) NOT NULL,
+    new_value  VARCHAR(255) NOT NULL,
+    changed_at DATETIME     NOT NULL,
+    KEY idx_profile_changes_user_time (user_id, changed_at),
+    CONSTRAINT fk_profile_changes_user FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+-- The foreign key on receiver_id needs an index once the composite one goes
+ALTER TABLE messages
+    ADD KEY fk_messages_receiver (receiver_id),
+    DROP KEY idx_messages_unread,
+    DROP COLUMN read_at,
+    DROP COLUMN delivered_at;
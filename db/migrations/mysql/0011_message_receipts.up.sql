@@ -0,0 +1,7 @@
+-- When a chat message reached one of the receiver's connections and when
+-- the receiver read it. Unread counts look messages up by receiver.
+
+ALTER TABLE messages
+    ADD COLUMN delivered_at DATETIME NULL,
+    ADD COLUMN read_at DATETIME NULL,
+    ADD KEY idx_messages_unread (receiver_id, read_at);
@@ -0,0 +1,4 @@
+-- The foreign key on sender_id needs an index once the composite one goes
+ALTER TABLE messages
+    ADD KEY fk_messages_sender (sender_id),
+    DROP KEY idx_messages_conversation;
@@ -0,0 +1,4 @@
+-- Chat history pages through one direction of a conversation at a time by
+-- message ID.
+
+ALTER TABLE messages ADD KEY idx_messages_conversation (sender_id, receiver_id, id);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS nutrition_targets;
+DROP TABLE IF EXISTS favourite_meal_items;
+DROP TABLE IF EXISTS favourite_meals;
+DROP TABLE IF EXISTS food_entries;
+DROP TABLE IF EXISTS recipe_items;
+DROP TABLE IF EXISTS recipes;
+DROP TABLE IF EXISTS foods;
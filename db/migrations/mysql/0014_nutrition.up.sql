@@ -0,0 +1,122 @@
+-- Nutrition: a shared food database, meal entries per member and day,
+-- recipes, favourite meals and daily targets. Entries and favourites copy
+-- the nutrients they were logged with, so editing a food changes no history.
+
+CREATE TABLE IF NOT EXISTS foods (
+    id        INT AUTO_INCREMENT PRIMARY KEY,
+    name      VARCHAR(100) NOT NULL,
+    serving   VARCHAR(40)  NOT NULL,
+    calories  DOUBLE       NOT NULL,
+    protein_g DOUBLE       NOT NULL DEFAULT 0,
+    carbs_g   DOUBLE       NOT NULL DEFAULT 0,
+    fat_g     DOUBLE       NOT NULL DEFAULT 0,
+    fibre_g   DOUBLE       NOT NULL DEFAULT 0,
+    UNIQUE KEY uq_foods_name (name)
+);
+
+CREATE TABLE IF NOT EXISTS recipes (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT          NOT NULL,
+    name       VARCHAR(100) NOT NULL,
+    portions   DOUBLE       NOT NULL,
+    created_at DATETIME     NOT NULL,
+    UNIQUE KEY uq_recipes_user_name (user_id, name),
+    CONSTRAINT fk_recipes_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS recipe_items (
+    recipe_id INT    NOT NULL,
+    food_id   INT    NOT NULL,
+    servings  DOUBLE NOT NULL,
+    UNIQUE KEY uq_recipe_items (recipe_id, food_id),
+    CONSTRAINT fk_recipe_items_recipe FOREIGN KEY (recipe_id) REFERENCES recipes (id) ON DELETE CASCADE,
+    CONSTRAINT fk_recipe_items_food FOREIGN KEY (food_id) REFERENCES foods (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS food_entries (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT          NOT NULL,
+    date       DATE         NOT NULL,
+    meal       VARCHAR(16)  NOT NULL,
+    food_id    INT          NULL,
+    recipe_id  INT          NULL,
+    name       VARCHAR(100) NOT NULL,
+    servings   DOUBLE       NOT NULL,
+    calories   DOUBLE       NOT NULL,
+    protein_g  DOUBLE       NOT NULL,
+    carbs_g    DOUBLE       NOT NULL,
+    fat_g      DOUBLE       NOT NULL,
+    fibre_g    DOUBLE       NOT NULL,
+    created_at DATETIME     NOT NULL,
+    KEY idx_food_entries_user_date (user_id, date),
+    CONSTRAINT fk_food_entries_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE,
+    CONSTRAINT fk_food_entries_food FOREIGN KEY (food_id) REFERENCES foods (id) ON DELETE SET NULL,
+    CONSTRAINT fk_food_entries_recipe FOREIGN KEY (recipe_id) REFERENCES recipes (id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS favourite_meals (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT          NOT NULL,
+    name       VARCHAR(100) NOT NULL,
+    created_at DATETIME     NOT NULL,
+    UNIQUE KEY uq_favourite_meals_user_name (user_id, name),
+    CONSTRAINT fk_favourite_meals_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS favourite_meal_items (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    favourite_id INT          NOT NULL,
+    food_id      INT          NULL,
+    recipe_id    INT          NULL,
+    name         VARCHAR(100) NOT NULL,
+    servings     DOUBLE       NOT NULL,
+    calories     DOUBLE       NOT NULL,
+    protein_g    DOUBLE       NOT NULL,
+    carbs_g      DOUBLE       NOT NULL,
+    fat_g        DOUBLE       NOT NULL,
+    fibre_g      DOUBLE       NOT NULL,
+    CONSTRAINT fk_favourite_meal_items_favourite FOREIGN KEY (favourite_id) REFERENCES favourite_meals (id) ON DELETE CASCADE,
+    CONSTRAINT fk_favourite_meal_items_food FOREIGN KEY (food_id) REFERENCES foods (id) ON DELETE SET NULL,
+    CONSTRAINT fk_favourite_meal_items_recipe FOREIGN KEY (recipe_id) REFERENCES recipes (id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS nutrition_targets (
+    user_id   INT    NOT NULL PRIMARY KEY,
+    calories  DOUBLE NULL,
+    protein_g DOUBLE NULL,
+    carbs_g   DOUBLE NULL,
+    fat_g     DOUBLE NULL,
+    fibre_g   DOUBLE NULL,
+    CONSTRAINT fk_nutrition_targets_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
+
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Apple', '1 medium (182 g)', 95, 0.5, 25, 0.3, 4.4);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Banana', '1 medium (118 g)', 105, 1.3, 27, 0.4, 3.1);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Orange', '1 medium (131 g)', 62, 1.2, 15.4, 0.2, 3.1);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Blueberries', '100 g', 57, 0.7, 14.5, 0.3, 2.4);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Oats, rolled', '40 g', 150, 5, 27, 2.5, 4);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Whole wheat bread', '1 slice (32 g)', 80, 4, 14, 1, 2);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('White rice, cooked', '100 g', 130, 2.7, 28, 0.3, 0.4);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Brown rice, cooked', '100 g', 123, 2.7, 25.6, 1, 1.6);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Pasta, cooked', '100 g', 158, 5.8, 31, 0.9, 1.8);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Potato, baked', '1 medium (173 g)', 161, 4.3, 37, 0.2, 3.8);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Sweet potato, baked', '100 g', 90, 2, 20.7, 0.2, 3.3);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Chicken breast, cooked', '100 g', 165, 31, 0, 3.6, 0);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Salmon, cooked', '100 g', 206, 22, 0, 12, 0);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Tuna, canned in water', '100 g', 116, 26, 0, 0.8, 0);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Lean beef mince, cooked', '100 g', 218, 26, 0, 12, 0);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Egg, large', '1 egg (50 g)', 72, 6.3, 0.4, 4.8, 0);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Greek yogurt, plain 2%', '170 g', 146, 20, 7.8, 3.8, 0);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Milk, semi-skimmed', '250 ml', 122, 8.5, 12, 4.8, 0);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Cheddar cheese', '30 g', 121, 7.5, 0.4, 10, 0);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Cottage cheese', '100 g', 98, 11, 3.4, 4.3, 0);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Whey protein', '1 scoop (30 g)', 120, 24, 3, 1.5, 0);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Tofu, firm', '100 g', 144, 17, 2.8, 8.7, 2.3);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Lentils, cooked', '100 g', 116, 9, 20, 0.4, 7.9);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Chickpeas, canned', '100 g', 139, 7, 22.5, 2.1, 6.1);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Broccoli, steamed', '100 g', 35, 2.4, 7.2, 0.4, 3.3);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Spinach, raw', '30 g', 7, 0.9, 1.1, 0.1, 0.7);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Avocado', '1/2 fruit (100 g)', 160, 2, 8.5, 14.7, 6.7);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Almonds', '28 g', 164, 6, 6.1, 14.2, 3.5);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Peanut butter', '2 tbsp (32 g)', 188, 8, 6, 16, 1.9);
+INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g) VALUES ('Olive oil', '1 tbsp (14 g)', 119, 0, 0, 13.5, 0);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS water_quick_adds;
+DROP TABLE IF EXISTS water_targets;
+DROP TABLE IF EXISTS water_entries;
@@ -0,0 +1,26 @@
+-- Water intake in millilitres. The daily target is worked out from the
+-- member's weight unless they set their own; water_done in user_progress
+-- follows whether the day's total reaches it.
+
+CREATE TABLE IF NOT EXISTS water_entries (
+    id        INT AUTO_INCREMENT PRIMARY KEY,
+    user_id   INT      NOT NULL,
+    date      DATE     NOT NULL,
+    logged_at DATETIME NOT NULL,
+    amount_ml INT      NOT NULL,
+    KEY idx_water_entries_user_date (user_id, date),
+    CONSTRAINT fk_water_entries_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS water_targets (
+    user_id   INT NOT NULL PRIMARY KEY,
+    target_ml INT NOT NULL,
+    CONSTRAINT fk_water_targets_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS water_quick_adds (
+    user_id   INT NOT NULL,
+    amount_ml INT NOT NULL,
+    UNIQUE KEY uq_water_quick_adds (user_id, amount_ml),
+    CONSTRAINT fk_water_quick_adds_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_metrics;
+ALTER TABLE user_info DROP COLUMN weight_goal;
+ALTER TABLE user_info DROP COLUMN activity_level;
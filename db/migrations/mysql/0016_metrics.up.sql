@@ -0,0 +1,25 @@
+-- How active a member is and whether they want to lose, keep or gain
+-- weight, plus the BMI, BMR, TDEE and macro targets worked out from their
+-- profile. user_metrics is recalculated whenever its inputs change and has
+-- no row while the profile lacks weight, height or age.
+
+ALTER TABLE user_info ADD COLUMN activity_level VARCHAR(16) NOT NULL DEFAULT 'moderate';
+ALTER TABLE user_info ADD COLUMN weight_goal VARCHAR(16) NOT NULL DEFAULT 'maintain';
+
+CREATE TABLE IF NOT EXISTS user_metrics (
+    user_id       INT NOT NULL PRIMARY KEY,
+    bmi           DOUBLE      NOT NULL,
+    bmi_category  VARCHAR(16) NOT NULL,
+    bmr_mifflin   DOUBLE      NOT NULL,
+    bmr_katch     DOUBLE      NULL,
+    bmr           DOUBLE      NOT NULL,
+    bmr_formula   VARCHAR(20) NOT NULL,
+    tdee          DOUBLE      NOT NULL,
+    calories      DOUBLE      NOT NULL,
+    protein_g     DOUBLE      NOT NULL,
+    carbs_g       DOUBLE      NOT NULL,
+    fat_g         DOUBLE      NOT NULL,
+    fibre_g       DOUBLE      NOT NULL,
+    calculated_at DATETIME    NOT NULL,
+    CONSTRAINT fk_user_metrics_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS goal_events;
+DROP TABLE IF EXISTS goals;
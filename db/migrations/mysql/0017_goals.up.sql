@@ -0,0 +1,30 @@
+-- Member goals. Progress is worked out from logged data when goals are
+-- read; status keeps the last result, goal_events records each change
+-- for the coach's timeline, and achieved_at makes reaching a goal stick.
+
+CREATE TABLE IF NOT EXISTS goals (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    user_id      INT          NOT NULL,
+    kind         VARCHAR(16)  NOT NULL,
+    title        VARCHAR(100) NOT NULL,
+    exercise     VARCHAR(80)  NOT NULL DEFAULT '',
+    habit        VARCHAR(40)  NOT NULL DEFAULT '',
+    start_value  DOUBLE       NOT NULL,
+    target_value DOUBLE       NOT NULL,
+    start_date   DATE         NOT NULL,
+    deadline     DATE         NOT NULL,
+    status       VARCHAR(16)  NOT NULL DEFAULT 'on_track',
+    achieved_at  DATETIME     NULL,
+    created_at   DATETIME     NOT NULL,
+    KEY idx_goals_user (user_id),
+    CONSTRAINT fk_goals_person FOREIGN KEY (user_id) REFERENCES person (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS goal_events (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    goal_id    INT         NOT NULL,
+    status     VARCHAR(16) NOT NULL,
+    changed_at DATETIME    NOT NULL,
+    KEY idx_goal_events_goal (goal_id),
+    CONSTRAINT fk_goal_events_goal FOREIGN KEY (goal_id) REFERENCES goals (id) ON DELETE CASCADE
+);
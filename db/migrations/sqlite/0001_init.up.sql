@@ -0,0 +1,37 @@
+-- Core accounts, profiles, daily progress and chat tables.
+
+CREATE TABLE IF NOT EXISTS person (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    username      VARCHAR(50)  NOT NULL UNIQUE,
+    email         VARCHAR(100) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    role          VARCHAR(20)  NOT NULL DEFAULT 'member',
+    created_at    DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_info (
+    user_id   INTEGER PRIMARY KEY REFERENCES person (id) ON DELETE CASCADE,
+    full_name VARCHAR(100) NOT NULL,
+    age       INTEGER      NOT NULL DEFAULT 0,
+    gender    VARCHAR(20)  NOT NULL DEFAULT '',
+    height_cm DOUBLE       NOT NULL DEFAULT 0,
+    weight_kg DOUBLE       NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS user_progress (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id      INTEGER NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    date         DATE    NOT NULL,
+    workout_done BOOLEAN NOT NULL DEFAULT FALSE,
+    meals_logged BOOLEAN NOT NULL DEFAULT FALSE,
+    water_done   BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (user_id, date)
+);
+
+CREATE TABLE IF NOT EXISTS messages (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    sender_id   INTEGER  NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    receiver_id INTEGER  NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    message     TEXT     NOT NULL,
+    timestamp   DATETIME NOT NULL
+);
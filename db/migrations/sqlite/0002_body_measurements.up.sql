@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS body_measurements (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id      INTEGER      NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    measured_at  DATETIME     NOT NULL,
+    weight_kg    DOUBLE       NULL,
+    body_fat_pct DOUBLE       NULL,
+    waist_cm     DOUBLE       NULL,
+    chest_cm     DOUBLE       NULL,
+    hips_cm      DOUBLE       NULL,
+    arm_cm       DOUBLE       NULL,
+    thigh_cm     DOUBLE       NULL,
+    neck_cm      DOUBLE       NULL,
+    note         VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_body_measurements_user_time ON body_measurements (user_id, measured_at);
+
+-- Seed the history with the weight currently stored on each profile
+INSERT INTO body_measurements (user_id, measured_at, weight_kg, note)
+SELECT user_id, CURRENT_TIMESTAMP, weight_kg, 'profile'
+FROM user_info
+WHERE weight_kg > 0;
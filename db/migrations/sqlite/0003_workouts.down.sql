@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS workout_sets;
+DROP TABLE IF EXISTS workout_exercises;
+DROP TABLE IF EXISTS workout_sessions;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS workout_sessions (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id     INTEGER      NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    name        VARCHAR(100) NOT NULL DEFAULT '',
+    started_at  DATETIME     NOT NULL,
+    finished_at DATETIME     NULL,
+    notes       TEXT         NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_workout_sessions_user_time ON workout_sessions (user_id, started_at);
+
+CREATE TABLE IF NOT EXISTS workout_exercises (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id    INTEGER      NOT NULL REFERENCES workout_sessions (id) ON DELETE CASCADE,
+    exercise_name VARCHAR(100) NOT NULL,
+    position      INTEGER      NOT NULL,
+    UNIQUE (session_id, exercise_name)
+);
+
+CREATE TABLE IF NOT EXISTS workout_sets (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    exercise_id  INTEGER  NOT NULL REFERENCES workout_exercises (id) ON DELETE CASCADE,
+    set_number   INTEGER  NOT NULL,
+    reps         INTEGER  NOT NULL,
+    load_kg      DOUBLE   NOT NULL DEFAULT 0,
+    rpe          DOUBLE   NULL,
+    rest_seconds INTEGER  NULL,
+    logged_at    DATETIME NOT NULL
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS cardio_sessions;
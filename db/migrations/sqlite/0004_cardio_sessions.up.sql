@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS cardio_sessions (
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id          INTEGER     NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    activity         VARCHAR(30) NOT NULL,
+    started_at       DATETIME    NOT NULL,
+    duration_seconds INTEGER     NOT NULL,
+    distance_km      DOUBLE      NULL,
+    elevation_m      DOUBLE      NULL,
+    avg_hr           INTEGER     NULL,
+    max_hr           INTEGER     NULL,
+    calories         INTEGER     NULL,
+    notes            TEXT        NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_cardio_sessions_user_time ON cardio_sessions (user_id, started_at);
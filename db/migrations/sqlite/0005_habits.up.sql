@@ -0,0 +1,16 @@
+-- Member-defined habits on top of the built-in user_progress booleans.
+
+CREATE TABLE IF NOT EXISTS habits (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER     NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    name       VARCHAR(60) NOT NULL,
+    created_at DATETIME    NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS habit_checkins (
+    id       INTEGER PRIMARY KEY AUTOINCREMENT,
+    habit_id INTEGER NOT NULL REFERENCES habits (id) ON DELETE CASCADE,
+    date     DATE    NOT NULL,
+    UNIQUE (habit_id, date)
+);
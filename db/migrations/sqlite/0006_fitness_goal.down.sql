@@ -0,0 +1 @@
+ALTER TABLE user_info DROP COLUMN fitness_goal;
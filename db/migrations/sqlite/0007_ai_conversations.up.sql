@@ -0,0 +1,25 @@
+-- Multi-turn AI chat. Older turns are folded into summary once the history
+-- outgrows the token budget; summarized_through is the last message ID the
+-- summary covers.
+
+CREATE TABLE IF NOT EXISTS ai_conversations (
+    id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id            INTEGER      NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    title              VARCHAR(120) NOT NULL,
+    summary            TEXT         NOT NULL DEFAULT '',
+    summarized_through INTEGER      NOT NULL DEFAULT 0,
+    created_at         DATETIME     NOT NULL,
+    updated_at         DATETIME     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_conversations_user_updated ON ai_conversations (user_id, updated_at);
+
+CREATE TABLE IF NOT EXISTS ai_messages (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    conversation_id INTEGER     NOT NULL REFERENCES ai_conversations (id) ON DELETE CASCADE,
+    role            VARCHAR(20) NOT NULL,
+    content         TEXT        NOT NULL,
+    created_at      DATETIME    NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_messages_conversation ON ai_messages (conversation_id, id);
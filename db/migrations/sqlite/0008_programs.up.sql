@@ -0,0 +1,43 @@
+-- Training programs: multi-week plans of days and exercises, written by the
+-- member or generated by the AI coach. A coach approves a pending program;
+-- editing it makes it pending again.
+
+CREATE TABLE IF NOT EXISTS programs (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id     INTEGER      NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    title       VARCHAR(100) NOT NULL,
+    weeks       INTEGER      NOT NULL,
+    notes       TEXT         NOT NULL DEFAULT '',
+    source      VARCHAR(16)  NOT NULL DEFAULT 'member',
+    status      VARCHAR(16)  NOT NULL DEFAULT 'pending',
+    approved_by INTEGER      NULL REFERENCES person (id) ON DELETE SET NULL,
+    approved_at DATETIME     NULL,
+    created_at  DATETIME     NOT NULL,
+    updated_at  DATETIME     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_programs_user_created ON programs (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_programs_status ON programs (status);
+
+CREATE TABLE IF NOT EXISTS program_days (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    program_id INTEGER      NOT NULL REFERENCES programs (id) ON DELETE CASCADE,
+    week       INTEGER      NOT NULL,
+    day        INTEGER      NOT NULL,
+    name       VARCHAR(100) NOT NULL DEFAULT '',
+    UNIQUE (program_id, week, day)
+);
+
+CREATE TABLE IF NOT EXISTS program_exercises (
+    id        INTEGER PRIMARY KEY AUTOINCREMENT,
+    day_id    INTEGER      NOT NULL REFERENCES program_days (id) ON DELETE CASCADE,
+    position  INTEGER      NOT NULL,
+    type      VARCHAR(16)  NOT NULL,
+    exercise  VARCHAR(100) NOT NULL,
+    sets      INTEGER      NOT NULL DEFAULT 0,
+    reps      VARCHAR(20)  NOT NULL DEFAULT '',
+    minutes   INTEGER      NOT NULL DEFAULT 0,
+    intensity VARCHAR(100) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_program_exercises_day ON program_exercises (day_id, position);
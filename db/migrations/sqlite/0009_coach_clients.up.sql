@@ -0,0 +1,15 @@
+-- Coach rosters. A link starts pending when one side invites or requests
+-- the other and becomes active once the other side accepts.
+
+CREATE TABLE IF NOT EXISTS coach_clients (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    coach_id     INTEGER     NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    member_id    INTEGER     NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    status       VARCHAR(16) NOT NULL DEFAULT 'pending',
+    initiated_by VARCHAR(16) NOT NULL,
+    created_at   DATETIME    NOT NULL,
+    accepted_at  DATETIME    NULL,
+    UNIQUE (coach_id, member_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_coach_clients_member ON coach_clients (member_id);
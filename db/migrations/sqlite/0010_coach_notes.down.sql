@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS profile_changes;
+DROP TABLE IF EXISTS coach_note_tags;
+DROP TABLE IF EXISTS coach_notes;
@@ -0,0 +1,31 @@
+-- Notes coaches keep about their clients, visible only to the coach who
+-- wrote them, and a log of profile edits for the client timeline.
+
+CREATE TABLE IF NOT EXISTS coach_notes (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    coach_id   INTEGER  NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    member_id  INTEGER  NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    body       TEXT     NOT NULL,
+    pinned     BOOLEAN  NOT NULL DEFAULT FALSE,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_coach_notes_pair ON coach_notes (coach_id, member_id, created_at);
+
+CREATE TABLE IF NOT EXISTS coach_note_tags (
+    note_id INTEGER     NOT NULL REFERENCES coach_notes (id) ON DELETE CASCADE,
+    tag     VARCHAR(40) NOT NULL,
+    UNIQUE (note_id, tag)
+);
+
+CREATE TABLE IF NOT EXISTS profile_changes (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER      NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    field      VARCHAR(32)  NOT NULL,
+    old_value  VARCHAR(255) NOT NULL,
+    new_value  VARCHAR(255) NOT NULL,
+    changed_at DATETIME     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_profile_changes_user_time ON profile_changes (user_id, changed_at);
@@ -0,0 +1,7 @@
+-- When a chat message reached one of the receiver's connections and when
+-- the receiver read it. Unread counts look messages up by receiver.
+
+ALTER TABLE messages ADD COLUMN delivered_at DATETIME NULL;
+ALTER TABLE messages ADD COLUMN read_at DATETIME NULL;
+
+CREATE INDEX IF NOT EXISTS idx_messages_unread ON messages (receiver_id, read_at);
@@ -0,0 +1,4 @@
+-- Chat history pages through one direction of a conversation at a time by
+-- message ID.
+
+CREATE INDEX IF NOT EXISTS idx_messages_conversation ON messages (sender_id, receiver_id, id);
@@ -0,0 +1,123 @@
+-- Exercise library shown on the weight and cardio pages, seeded with the
+-- exercises those pages used to hard-code. Cardio slugs are the activity
+-- keys cardio sessions store.
+
+CREATE TABLE IF NOT EXISTS exercises (
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    slug             VARCHAR(80)  NOT NULL UNIQUE,
+    name             VARCHAR(80)  NOT NULL UNIQUE,
+    kind             VARCHAR(16)  NOT NULL,
+    equipment        VARCHAR(40)  NOT NULL DEFAULT '',
+    movement_pattern VARCHAR(40)  NOT NULL DEFAULT '',
+    instructions     TEXT         NOT NULL,
+    media_url        VARCHAR(500) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_exercises_kind ON exercises (kind);
+
+CREATE TABLE IF NOT EXISTS exercise_muscles (
+    exercise_id INTEGER     NOT NULL REFERENCES exercises (id) ON DELETE CASCADE,
+    muscle      VARCHAR(40) NOT NULL,
+    position    INTEGER     NOT NULL,
+    UNIQUE (exercise_id, muscle)
+);
+
+CREATE INDEX IF NOT EXISTS idx_exercise_muscles_muscle ON exercise_muscles (muscle);
+
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('bench_press', 'Bench Press', 'strength', 'barbell', 'push', 'Lie on a flat bench with your eyes under the bar. Lower the bar to mid-chest with elbows at about 45 degrees, then press it back up to straight arms.', '/static/exercises/bench_press.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('incline_dumbbell_press', 'Incline Dumbbell Press', 'strength', 'dumbbell', 'push', 'Set the bench to 30-45 degrees. Press the dumbbells up over your upper chest and lower them under control until your elbows are just below the bench.', '/static/exercises/incline_dumbbell_press.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('chest_fly', 'Chest Fly', 'strength', 'dumbbell', 'isolation', 'Lie on a flat bench with a slight bend in the elbows. Open the arms wide until you feel a stretch across the chest, then bring the dumbbells back together over it.', '/static/exercises/chest_fly.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('push_ups', 'Push-ups', 'strength', 'bodyweight', 'push', 'Hands just wider than shoulders and body in a straight line. Lower your chest to a fist''s height from the floor and push back up without letting the hips sag.', '/static/exercises/push_ups.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('cable_crossover', 'Cable Crossover', 'strength', 'cable', 'isolation', 'Stand between two high pulleys with a staggered stance. Bring the handles down and together in front of your hips, squeeze, and return slowly.', '/static/exercises/cable_crossover.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('deadlift', 'Deadlift', 'strength', 'barbell', 'hinge', 'Stand with the bar over mid-foot. Brace, grip just outside the legs and stand up by driving through the floor, keeping the bar close and the back flat. Lower it the same way.', '/static/exercises/deadlift.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('lat_pulldown', 'Lat Pulldown', 'strength', 'machine', 'pull', 'Grip the bar wider than your shoulders, lean back slightly and pull it to your upper chest by driving the elbows down. Let it rise under control.', '/static/exercises/lat_pulldown.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('pull_ups', 'Pull-Ups', 'strength', 'bodyweight', 'pull', 'Hang from the bar with an overhand grip. Pull until your chin clears the bar, then lower all the way to straight arms.', '/static/exercises/pull_ups.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('bent_over_row', 'Bent Over Row', 'strength', 'barbell', 'pull', 'Hinge at the hips to about 45 degrees with a flat back. Row the bar to your lower ribs and lower it without standing up.', '/static/exercises/bent_over_row.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('seated_row', 'Seated Row', 'strength', 'cable', 'pull', 'Sit tall with a slight knee bend. Pull the handle to your stomach, squeezing the shoulder blades together, then reach forward under control.', '/static/exercises/seated_row.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('barbell_curl', 'Barbell Curl', 'strength', 'barbell', 'isolation', 'Stand with elbows at your sides. Curl the bar to your shoulders without swinging and lower it slowly to straight arms.', '/static/exercises/barbell_curl.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('hammer_curl', 'Hammer Curl', 'strength', 'dumbbell', 'isolation', 'Hold the dumbbells with palms facing each other and curl them up, keeping the elbows still at your sides.', '/static/exercises/hammer_curl.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('concentration_curl', 'Concentration Curl', 'strength', 'dumbbell', 'isolation', 'Sit with your elbow braced against the inside of your thigh. Curl the dumbbell to your shoulder and lower it all the way.', '/static/exercises/concentration_curl.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('preacher_curl', 'Preacher Curl', 'strength', 'barbell', 'isolation', 'Rest the backs of your upper arms on the preacher pad. Curl the bar up and lower it until the arms are almost straight.', '/static/exercises/preacher_curl.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('tricep_dips', 'Tricep Dips', 'strength', 'bodyweight', 'push', 'Support yourself on parallel bars with straight arms. Lower until your upper arms are parallel to the floor, keeping the torso upright, then press back up.', '/static/exercises/tricep_dips.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('skull_crushers', 'Skull Crushers', 'strength', 'barbell', 'isolation', 'Lie on a bench holding the bar over your shoulders. Bend only at the elbows to lower it towards your forehead, then extend back up.', '/static/exercises/skull_crushers.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('overhead_extension', 'Overhead Extension', 'strength', 'dumbbell', 'isolation', 'Hold one dumbbell overhead with both hands. Lower it behind your head by bending the elbows, keeping them pointed up, then extend.', '/static/exercises/overhead_extension.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('pushdown', 'Pushdown', 'strength', 'cable', 'isolation', 'Stand at a high pulley with elbows pinned to your sides. Push the bar or rope down to straight arms and let it rise to 90 degrees.', '/static/exercises/pushdown.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('close_grip_bench', 'Close-Grip Bench', 'strength', 'barbell', 'push', 'Bench press with hands shoulder-width apart and elbows tucked close to the body.', '/static/exercises/close_grip_bench.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('overhead_press', 'Overhead Press', 'strength', 'barbell', 'push', 'Stand with the bar on your front shoulders. Brace, press it straight overhead to locked arms and lower it back to the shoulders.', '/static/exercises/overhead_press.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('lateral_raise', 'Lateral Raise', 'strength', 'dumbbell', 'isolation', 'With a slight bend in the elbows, raise the dumbbells out to the sides until they reach shoulder height, then lower slowly.', '/static/exercises/lateral_raise.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('front_raise', 'Front Raise', 'strength', 'dumbbell', 'isolation', 'Raise the dumbbells in front of you to shoulder height with straight arms and lower them under control.', '/static/exercises/front_raise.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('arnold_press', 'Arnold Press', 'strength', 'dumbbell', 'push', 'Start with the dumbbells in front of your shoulders, palms facing you. Rotate the palms forward as you press overhead and reverse on the way down.', '/static/exercises/arnold_press.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('upright_row', 'Upright Row', 'strength', 'barbell', 'pull', 'Hold the bar with a shoulder-width grip and pull it up along your body to chest height, leading with the elbows.', '/static/exercises/upright_row.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('squat', 'Squat', 'strength', 'barbell', 'squat', 'Bar on your upper back, feet shoulder-width apart. Sit down between your heels until the thighs are at least parallel, then stand up with the chest up.', '/static/exercises/squat.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('leg_press', 'Leg Press', 'strength', 'machine', 'squat', 'Place your feet shoulder-width on the platform. Lower it until your knees reach 90 degrees and press back without locking the knees.', '/static/exercises/leg_press.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('lunges', 'Lunges', 'strength', 'dumbbell', 'lunge', 'Step forward and lower until both knees are at 90 degrees, front knee over the ankle. Push back to standing and switch legs.', '/static/exercises/lunges.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('leg_curl', 'Leg Curl', 'strength', 'machine', 'isolation', 'Lie on the machine with the pad above your heels. Curl your heels towards your glutes and lower slowly.', '/static/exercises/leg_curl.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('calf_raise', 'Calf Raise', 'strength', 'machine', 'isolation', 'Stand on the edge of a step or platform. Rise onto your toes as high as you can, pause, and lower the heels below the step.', '/static/exercises/calf_raise.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('running', 'Running', 'cardio', 'none', 'gait', 'Great for endurance, heart health, and calorie burn.', '/static/exercises/running.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('walking', 'Walking', 'cardio', 'none', 'gait', 'An easy, low-impact way to build daily activity and recover between harder sessions.', '');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('cycling', 'Cycling', 'cardio', 'bike', 'cyclical', 'Low-impact cardio workout that improves leg strength and stamina.', '/static/exercises/cycling.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('swimming', 'Swimming', 'cardio', 'pool', 'cyclical', 'Full-body cardio with resistance, great for joints and lungs.', '/static/exercises/swimming.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('rowing', 'Rowing', 'cardio', 'rowing machine', 'cyclical', 'Drive with the legs, then lean back and pull the handle to your ribs. Works the legs, back and arms in every stroke.', '');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('jump_rope', 'Jump Rope', 'cardio', 'jump rope', 'plyometric', 'Boosts coordination, cardio fitness, and burns up to 10 calories/minute.', '/static/exercises/jump_rope.jpg');
+INSERT INTO exercises (slug, name, kind, equipment, movement_pattern, instructions, media_url) VALUES ('hiit', 'HIIT', 'cardio', 'none', 'intervals', 'High-Intensity Interval Training — short, intense bursts of movement to burn fat fast.', '/static/exercises/hiit.jpg');
+
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'chest', 0 FROM exercises WHERE slug = 'bench_press';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'triceps', 1 FROM exercises WHERE slug = 'bench_press';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'shoulders', 2 FROM exercises WHERE slug = 'bench_press';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'chest', 0 FROM exercises WHERE slug = 'incline_dumbbell_press';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'shoulders', 1 FROM exercises WHERE slug = 'incline_dumbbell_press';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'triceps', 2 FROM exercises WHERE slug = 'incline_dumbbell_press';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'chest', 0 FROM exercises WHERE slug = 'chest_fly';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'chest', 0 FROM exercises WHERE slug = 'push_ups';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'triceps', 1 FROM exercises WHERE slug = 'push_ups';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'shoulders', 2 FROM exercises WHERE slug = 'push_ups';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'core', 3 FROM exercises WHERE slug = 'push_ups';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'chest', 0 FROM exercises WHERE slug = 'cable_crossover';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'back', 0 FROM exercises WHERE slug = 'deadlift';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'legs', 1 FROM exercises WHERE slug = 'deadlift';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'core', 2 FROM exercises WHERE slug = 'deadlift';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'back', 0 FROM exercises WHERE slug = 'lat_pulldown';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'biceps', 1 FROM exercises WHERE slug = 'lat_pulldown';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'back', 0 FROM exercises WHERE slug = 'pull_ups';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'biceps', 1 FROM exercises WHERE slug = 'pull_ups';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'back', 0 FROM exercises WHERE slug = 'bent_over_row';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'biceps', 1 FROM exercises WHERE slug = 'bent_over_row';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'back', 0 FROM exercises WHERE slug = 'seated_row';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'biceps', 1 FROM exercises WHERE slug = 'seated_row';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'biceps', 0 FROM exercises WHERE slug = 'barbell_curl';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'biceps', 0 FROM exercises WHERE slug = 'hammer_curl';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'forearms', 1 FROM exercises WHERE slug = 'hammer_curl';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'biceps', 0 FROM exercises WHERE slug = 'concentration_curl';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'biceps', 0 FROM exercises WHERE slug = 'preacher_curl';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'triceps', 0 FROM exercises WHERE slug = 'tricep_dips';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'chest', 1 FROM exercises WHERE slug = 'tricep_dips';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'shoulders', 2 FROM exercises WHERE slug = 'tricep_dips';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'triceps', 0 FROM exercises WHERE slug = 'skull_crushers';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'triceps', 0 FROM exercises WHERE slug = 'overhead_extension';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'triceps', 0 FROM exercises WHERE slug = 'pushdown';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'triceps', 0 FROM exercises WHERE slug = 'close_grip_bench';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'chest', 1 FROM exercises WHERE slug = 'close_grip_bench';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'shoulders', 0 FROM exercises WHERE slug = 'overhead_press';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'triceps', 1 FROM exercises WHERE slug = 'overhead_press';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'shoulders', 0 FROM exercises WHERE slug = 'lateral_raise';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'shoulders', 0 FROM exercises WHERE slug = 'front_raise';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'shoulders', 0 FROM exercises WHERE slug = 'arnold_press';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'triceps', 1 FROM exercises WHERE slug = 'arnold_press';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'shoulders', 0 FROM exercises WHERE slug = 'upright_row';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'back', 1 FROM exercises WHERE slug = 'upright_row';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'legs', 0 FROM exercises WHERE slug = 'squat';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'core', 1 FROM exercises WHERE slug = 'squat';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'legs', 0 FROM exercises WHERE slug = 'leg_press';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'legs', 0 FROM exercises WHERE slug = 'lunges';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'core', 1 FROM exercises WHERE slug = 'lunges';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'legs', 0 FROM exercises WHERE slug = 'leg_curl';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'legs', 0 FROM exercises WHERE slug = 'calf_raise';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'legs', 0 FROM exercises WHERE slug = 'running';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'core', 1 FROM exercises WHERE slug = 'running';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'legs', 0 FROM exercises WHERE slug = 'walking';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'legs', 0 FROM exercises WHERE slug = 'cycling';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'full body', 0 FROM exercises WHERE slug = 'swimming';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'full body', 0 FROM exercises WHERE slug = 'rowing';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'legs', 0 FROM exercises WHERE slug = 'jump_rope';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'shoulders', 1 FROM exercises WHERE slug = 'jump_rope';
+INSERT INTO exercise_muscles (exercise_id, muscle, position) SELECT id, 'full body', 0 FROM exercises WHERE slug = 'hiit';
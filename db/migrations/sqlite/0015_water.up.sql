@@ -0,0 +1,24 @@
+-- Water intake in millilitres. The daily target is worked out from the
+-- member's weight unless they set their own; water_done in user_progress
+-- follows whether the day's total reaches it.
+
+CREATE TABLE IF NOT EXISTS water_entries (
+    id        INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id   INTEGER  NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    date      DATE     NOT NULL,
+    logged_at DATETIME NOT NULL,
+    amount_ml INTEGER  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_water_entries_user_date ON water_entries (user_id, date);
+
+CREATE TABLE IF NOT EXISTS water_targets (
+    user_id   INTEGER NOT NULL PRIMARY KEY REFERENCES person (id) ON DELETE CASCADE,
+    target_ml INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS water_quick_adds (
+    user_id   INTEGER NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    amount_ml INTEGER NOT NULL,
+    UNIQUE (user_id, amount_ml)
+);
@@ -0,0 +1,30 @@
+-- Member goals. Progress is worked out from logged data when goals are
+-- read; status keeps the last result, goal_events records each change
+-- for the coach's timeline, and achieved_at makes reaching a goal stick.
+
+CREATE TABLE IF NOT EXISTS goals (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id      INTEGER      NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    kind         VARCHAR(16)  NOT NULL,
+    title        VARCHAR(100) NOT NULL,
+    exercise     VARCHAR(80)  NOT NULL DEFAULT '',
+    habit        VARCHAR(40)  NOT NULL DEFAULT '',
+    start_value  DOUBLE       NOT NULL,
+    target_value DOUBLE       NOT NULL,
+    start_date   DATE         NOT NULL,
+    deadline     DATE         NOT NULL,
+    status       VARCHAR(16)  NOT NULL DEFAULT 'on_track',
+    achieved_at  DATETIME     NULL,
+    created_at   DATETIME     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_goals_user ON goals (user_id);
+
+CREATE TABLE IF NOT EXISTS goal_events (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    goal_id    INTEGER     NOT NULL REFERENCES goals (id) ON DELETE CASCADE,
+    status     VARCHAR(16) NOT NULL,
+    changed_at DATETIME    NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_goal_events_goal ON goal_events (goal_id);
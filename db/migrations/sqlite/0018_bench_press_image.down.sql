@@ -0,0 +1 @@
+-- Nothing to undo: the local image stays in place of the hotlinked one.
@@ -0,0 +1,5 @@
+-- The bench press was first seeded with an image hotlinked from another
+-- site; serve the local copy like every other exercise.
+
+UPDATE exercises SET media_url = '/static/exercises/bench_press.jpg'
+WHERE slug = 'bench_press' AND media_url LIKE 'https://samarpanphysioclinic.com/%';
@@ -0,0 +1,408 @@
+package db
+
+import (
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CoachNote is a private note a coach keeps about a client. Body is
+// Markdown; Tags are lower case.
+type CoachNote struct {
+	ID        int64     `json:"id"`
+	Body      string    `json:"body"`
+	Tags      []string  `json:"tags"`
+	Pinned    bool      `json:"pinned"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Kinds of timeline item
+const (
+	TimelineNote    = "note"
+	TimelineMessage = "message"
+	TimelineProfile = "profile"
+	TimelineCheckIn = "checkin"
+	TimelineWorkout = "workout"
+	TimelineGoal    = "goal"
+)
+
+// TimelineItem is one event in a client's history. Text is a plain
+// summary; Note is set for notes and From for chat messages.
+type TimelineItem struct {
+	Type string     `json:"type"`
+	At   time.Time  `json:"at"`
+	Text string     `json:"text"`
+	From string     `json:"from,omitempty"`
+	Note *CoachNote `json:"note,omitempty"`
+}
+
+// NoteStore persists coach notes and builds client timelines
+type NoteStore interface {
+	ListNotes(coachID, memberID int64, tag string) ([]CoachNote, error)
+	CreateNote(coachID, memberID int64, n CoachNote) (*CoachNote, error)
+	UpdateNote(coachID, id int64, n CoachNote) error
+	DeleteNote(coachID, id int64) error
+	ClientTimeline(coachID, memberID int64, before time.Time, limit int) ([]TimelineItem, error)
+}
+
+// ListNotes returns the coach's notes about a member, pinned first, then
+// newest first. A non-empty tag keeps only the notes carrying it.
+func (s *SQLStore) ListNotes(coachID, memberID int64, tag string) ([]CoachNote, error) {
+	query := `SELECT id, body, pinned, created_at, updated_at FROM coach_notes
+		WHERE coach_id = ? AND member_id = ?`
+	args := []any{coachID, memberID}
+	if tag != "" {
+		query += " AND id IN (SELECT note_id FROM coach_note_tags WHERE tag = ?)"
+		args = append(args, tag)
+	}
+	rows, err := s.db.Query(query+" ORDER BY pinned DESC, created_at DESC, id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []CoachNote{}
+	index := map[int64]int{}
+	for rows.Next() {
+		n := CoachNote{Tags: []string{}}
+		if err := rows.Scan(&n.ID, &n.Body, &n.Pinned, &n.CreatedAt, &n.UpdatedAt); err != nil {
+			return nil, err
+		}
+		index[n.ID] = len(notes)
+		notes = append(notes, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return notes, s.loadNoteTags(coachID, memberID, notes, index)
+}
+
+func (s *SQLStore) loadNoteTags(coachID, memberID int64, notes []CoachNote, index map[int64]int) error {
+	rows, err := s.db.Query(`SELECT t.note_id, t.tag FROM coach_note_tags t
+		JOIN coach_notes n ON n.id = t.note_id
+		WHERE n.coach_id = ? AND n.member_id = ?
+		ORDER BY t.tag`, coachID, memberID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return err
+		}
+		if i, ok := index[id]; ok {
+			notes[i].Tags = append(notes[i].Tags, tag)
+		}
+	}
+	return rows.Err()
+}
+
+// CreateNote saves a new note about a member
+func (s *SQLStore) CreateNote(coachID, memberID int64, n CoachNote) (*CoachNote, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	result, err := tx.Exec(`INSERT INTO coach_notes (coach_id, member_id, body, pinned, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`, coachID, memberID, n.Body, n.Pinned, now, now)
+	if err != nil {
+		return nil, err
+	}
+	if n.ID, err = result.LastInsertId(); err != nil {
+		return nil, err
+	}
+	if err := insertNoteTags(tx, n.ID, n.Tags); err != nil {
+		return nil, err
+	}
+	n.CreatedAt, n.UpdatedAt = now, now
+	return &n, tx.Commit()
+}
+
+func insertNoteTags(tx *sql.Tx, noteID int64, tags []string) error {
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT INTO coach_note_tags (note_id, tag) VALUES (?, ?)", noteID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateNote replaces the body, tags and pinned flag of one of the coach's
+// notes
+func (s *SQLStore) UpdateNote(coachID, id int64, n CoachNote) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE coach_notes SET body = ?, pinned = ?, updated_at = ? WHERE id = ? AND coach_id = ?",
+		n.Body, n.Pinned, time.Now().UTC(), id, coachID)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(result); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM coach_note_tags WHERE note_id = ?", id); err != nil {
+		return err
+	}
+	if err := insertNoteTags(tx, id, n.Tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteNote removes one of the coach's notes
+func (s *SQLStore) DeleteNote(coachID, id int64) error {
+	result, err := s.db.Exec("DELETE FROM coach_notes WHERE id = ? AND coach_id = ?", id, coachID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// ClientTimeline merges the coach's notes, their chat with the member, the
+// member's profile edits, daily check-ins, workouts and goal status
+// changes, newest first.
+// Items are older than before (zero for now), about limit of them.
+func (s *SQLStore) ClientTimeline(coachID, memberID int64, before time.Time, limit int) ([]TimelineItem, error) {
+	if before.IsZero() {
+		before = time.Now().Add(time.Second)
+	}
+	before = before.UTC()
+
+	var items []TimelineItem
+	notes, err := s.ListNotes(coachID, memberID, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range notes {
+		if notes[i].CreatedAt.Before(before) {
+			items = append(items, TimelineItem{Type: TimelineNote, At: notes[i].CreatedAt, Text: notes[i].Body, Note: &notes[i]})
+		}
+	}
+
+	// Each source contributes its newest limit items; the merge keeps the
+	// newest limit overall
+	sources := []struct {
+		kind  string
+		query string
+		args  []any
+	}{
+		{TimelineMessage, `SELECT m.timestamp, m.message, p.username FROM messages m
+			JOIN person p ON p.id = m.sender_id
+			WHERE ((m.sender_id = ? AND m.receiver_id = ?) OR (m.sender_id = ? AND m.receiver_id = ?))
+				AND m.timestamp < ?
+			ORDER BY m.timestamp DESC LIMIT ?`,
+			[]any{coachID, memberID, memberID, coachID, before, limit}},
+		{TimelineProfile, `SELECT changed_at, field, old_value, new_value FROM profile_changes
+			WHERE user_id = ? AND changed_at < ?
+			ORDER BY changed_at DESC LIMIT ?`,
+			[]any{memberID, before, limit}},
+		{TimelineCheckIn, `SELECT date, workout_done, meals_logged, water_done FROM user_progress
+			WHERE user_id = ? AND date <= ? AND (workout_done = 1 OR meals_logged = 1 OR water_done = 1)
+			ORDER BY date DESC LIMIT ?`,
+			[]any{memberID, before.Add(-time.Nanosecond).Format(dateLayout), limit}},
+		{TimelineWorkout, `SELECT w.started_at, w.name, w.finished_at,
+				(SELECT COUNT(*) FROM workout_exercises e JOIN workout_sets ws ON ws.exercise_id = e.id WHERE e.session_id = w.id)
+			FROM workout_sessions w
+			WHERE w.user_id = ? AND w.started_at < ?
+			ORDER BY w.started_at DESC LIMIT ?`,
+			[]any{memberID, before, limit}},
+		{TimelineGoal, `SELECT e.changed_at, g.title, e.status FROM goal_events e
+			JOIN goals g ON g.id = e.goal_id
+			WHERE g.user_id = ? AND e.changed_at < ?
+			ORDER BY e.changed_at DESC LIMIT ?`,
+			[]any{memberID, before, limit}},
+	}
+	for _, src := range sources {
+		found, err := s.timelineItems(src.kind, src.query, src.args...)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, found...)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].At.After(items[j].At) })
+	if len(items) > limit {
+		// Keep items sharing the last timestamp together, as the next page
+		// starts strictly before it
+		n := limit
+		for n < len(items) && items[n].At.Equal(items[limit-1].At) {
+			n++
+		}
+		items = items[:n]
+	}
+	if items == nil {
+		items = []TimelineItem{}
+	}
+	return items, nil
+}
+
+func (s *SQLStore) timelineItems(kind, query string, args ...any) ([]TimelineItem, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []TimelineItem
+	for rows.Next() {
+		item := TimelineItem{Type: kind}
+		switch kind {
+		case TimelineMessage:
+			err = rows.Scan(&item.At, &item.Text, &item.From)
+		case TimelineProfile:
+			var field, oldValue, newValue string
+			err = rows.Scan(&item.At, &field, &oldValue, &newValue)
+			item.Text = profileChangeText(field, oldValue, newValue)
+		case TimelineCheckIn:
+			var workout, meals, water bool
+			err = rows.Scan(&item.At, &workout, &meals, &water)
+			var done []string
+			for _, h := range []struct {
+				done bool
+				name string
+			}{{workout, "workout"}, {meals, "meals"}, {water, "water"}} {
+				if h.done {
+					done = append(done, h.name)
+				}
+			}
+			item.Text = "Checked in: " + strings.Join(done, ", ")
+		case TimelineWorkout:
+			var name string
+			var finishedAt sql.NullTime
+			var sets int
+			err = rows.Scan(&item.At, &name, &finishedAt, &sets)
+			if name == "" {
+				name = "Workout"
+			}
+			item.Text = name + ": " + strconv.Itoa(sets) + " sets"
+			if !finishedAt.Valid {
+				item.Text += " (in progress)"
+			}
+		case TimelineGoal:
+			var title, status string
+			err = rows.Scan(&item.At, &title, &status)
+			item.Text = "Goal " + strconv.Quote(title) + " " + goalStatusNames[status]
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// Labels of the profile fields recorded in profile_changes
+var profileFieldNames = map[string]string{
+	"full_name":    "Name",
+	"age":          "Age",
+	"gender":       "Gender",
+	"height_cm":    "Height (cm)",
+	"weight_kg":    "Weight (kg)",
+	"fitness_goal": "Goal",
+}
+
+func profileChangeText(field, oldValue, newValue string) string {
+	name := profileFieldNames[field]
+	if name == "" {
+		name = field
+	}
+	if oldValue == "" {
+		return name + " set to " + newValue
+	}
+	return name + " changed from " + oldValue + " to " + newValue
+}
+
+// profileSnapshot reads the profile fields recorded in profile_changes, or
+// nil when the user has no profile yet
+func (s *SQLStore) profileSnapshot(userID int64) (map[string]string, error) {
+	var fullName, gender, goal string
+	var age int
+	var height, weight float64
+	err := s.db.QueryRow("SELECT full_name, age, gender, height_cm, weight_kg, fitness_goal FROM user_info WHERE user_id = ?",
+		userID).Scan(&fullName, &age, &gender, &height, &weight, &goal)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"full_name":    fullName,
+		"age":          strconv.Itoa(age),
+		"gender":       gender,
+		"height_cm":    strconv.FormatFloat(height, 'f', -1, 64),
+		"weight_kg":    strconv.FormatFloat(weight, 'f', -1, 64),
+		"fitness_goal": goal,
+	}, nil
+}
+
+// recordProfileChanges logs every field that differs from the snapshot
+// taken before an edit. Creating the profile is not a change.
+func (s *SQLStore) recordProfileChanges(userID int64, before map[string]string) error {
+	if before == nil {
+		return nil
+	}
+	after, err := s.profileSnapshot(userID)
+	if err != nil || after == nil {
+		return err
+	}
+	fields := make([]string, 0, len(after))
+	for field := range after {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	now := time.Now().UTC()
+	for _, field := range fields {
+		if before[field] == after[field] {
+			continue
+		}
+		_, err := s.db.Exec(`INSERT INTO profile_changes (user_id, field, old_value, new_value, changed_at)
+			VALUES (?, ?, ?, ?, ?)`, userID, field, truncate(before[field], 255), truncate(after[field], 255), now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+func ListNotes(coachID, memberID int64, tag string) ([]CoachNote, error) {
+	return current.ListNotes(coachID, memberID, tag)
+}
+
+func CreateNote(coachID, memberID int64, n CoachNote) (*CoachNote, error) {
+	return current.CreateNote(coachID, memberID, n)
+}
+
+func UpdateNote(coachID, id int64, n CoachNote) error {
+	return current.UpdateNote(coachID, id, n)
+}
+
+func DeleteNote(coachID, id int64) error {
+	return current.DeleteNote(coachID, id)
+}
+
+func ClientTimeline(coachID, memberID int64, before time.Time, limit int) ([]TimelineItem, error) {
+	return current.ClientTimeline(coachID, memberID, before, limit)
+}
@@ -0,0 +1,488 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Meals a food entry can belong to, in the order of the day
+const (
+	MealBreakfast = "breakfast"
+	MealLunch     = "lunch"
+	MealDinner    = "dinner"
+	MealSnack     = "snack"
+)
+
+// Meals lists the meals in the order of the day
+var Meals = []string{MealBreakfast, MealLunch, MealDinner, MealSnack}
+
+// Nutrients are the energy (kcal) and macronutrients (g) of an amount of
+// food
+type Nutrients struct {
+	Calories float64 `json:"calories"`
+	ProteinG float64 `json:"proteinG"`
+	CarbsG   float64 `json:"carbsG"`
+	FatG     float64 `json:"fatG"`
+	FibreG   float64 `json:"fibreG"`
+}
+
+// Scale returns the nutrients of f times the amount
+func (n Nutrients) Scale(f float64) Nutrients {
+	return Nutrients{n.Calories * f, n.ProteinG * f, n.CarbsG * f, n.FatG * f, n.FibreG * f}
+}
+
+// Add returns the nutrients of both amounts together
+func (n Nutrients) Add(o Nutrients) Nutrients {
+	return Nutrients{n.Calories + o.Calories, n.ProteinG + o.ProteinG, n.CarbsG + o.CarbsG, n.FatG + o.FatG, n.FibreG + o.FibreG}
+}
+
+// Food is an entry in the shared food database. Nutrients are per serving,
+// which is described in words ("1 medium (118 g)").
+type Food struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Serving string `json:"serving"`
+	Nutrients
+}
+
+// MealItem is something eaten: a food, a portion of a recipe or a custom
+// item. Nutrients are for all servings and are copied when it is logged.
+type MealItem struct {
+	FoodID   *int64  `json:"foodId,omitempty"`
+	RecipeID *int64  `json:"recipeId,omitempty"`
+	Name     string  `json:"name"`
+	Servings float64 `json:"servings"`
+	Nutrients
+}
+
+// FoodEntry is a meal item a member logged for a meal on a day
+type FoodEntry struct {
+	ID        int64     `json:"id"`
+	Date      string    `json:"date"`
+	Meal      string    `json:"meal"`
+	CreatedAt time.Time `json:"createdAt"`
+	MealItem
+}
+
+// NutritionTargets are a member's daily goals. Missing ones aren't set.
+type NutritionTargets struct {
+	Calories *float64 `json:"calories"`
+	ProteinG *float64 `json:"proteinG"`
+	CarbsG   *float64 `json:"carbsG"`
+	FatG     *float64 `json:"fatG"`
+	FibreG   *float64 `json:"fibreG"`
+}
+
+// NutritionDay is everything a member logged on a day (YYYY-MM-DD), in meal
+// order, with totals per meal and for the day next to their targets
+type NutritionDay struct {
+	Date       string               `json:"date"`
+	Entries    []FoodEntry          `json:"entries"`
+	MealTotals map[string]Nutrients `json:"mealTotals"`
+	Totals     Nutrients            `json:"totals"`
+	Targets    NutritionTargets     `json:"targets"`
+}
+
+// NutritionStore persists foods, meal entries, recipes, favourite meals
+// and daily targets. Adding or removing entries keeps the day's
+// meals_logged habit in step with whether it has any.
+type NutritionStore interface {
+	SearchFoods(query string, limit int) ([]Food, error)
+	GetFood(id int64) (*Food, error)
+	CreateFood(f Food) (*Food, error)
+	UpdateFood(id int64, f Food) error
+	DeleteFood(id int64) error
+	ImportFoods(foods []Food) (created, updated int, err error)
+
+	AddFoodEntries(userID int64, date, meal string, items []MealItem) ([]FoodEntry, error)
+	UpdateFoodEntry(userID, id int64, meal string, servings float64) error
+	DeleteFoodEntry(userID, id int64) error
+	GetNutritionDay(userID int64, date string) (*NutritionDay, error)
+	GetNutritionTargets(userID int64) (*NutritionTargets, error)
+	SetNutritionTargets(userID int64, t NutritionTargets) error
+
+	ListRecipes(userID int64) ([]Recipe, error)
+	GetRecipe(userID, id int64) (*Recipe, error)
+	CreateRecipe(userID int64, r Recipe) (*Recipe, error)
+	UpdateRecipe(userID, id int64, r Recipe) error
+	DeleteRecipe(userID, id int64) error
+
+	ListFavouriteMeals(userID int64) ([]FavouriteMeal, error)
+	SaveFavouriteMeal(userID int64, name, date, meal string) (*FavouriteMeal, error)
+	DeleteFavouriteMeal(userID, id int64) error
+}
+
+// ErrFoodExists is returned when the food database already has a food by
+// that name
+var ErrFoodExists = errors.New("food already exists")
+
+const foodColumns = "id, name, serving, calories, protein_g, carbs_g, fat_g, fibre_g"
+
+func scanFood(row interface{ Scan(...any) error }) (Food, error) {
+	var f Food
+	err := row.Scan(&f.ID, &f.Name, &f.Serving, &f.Calories, &f.ProteinG, &f.CarbsG, &f.FatG, &f.FibreG)
+	return f, err
+}
+
+// SearchFoods returns up to limit foods whose name contains query, names
+// starting with it first
+func (s *SQLStore) SearchFoods(query string, limit int) ([]Food, error) {
+	like := likeEscaper.Replace(strings.ToLower(query))
+	rows, err := s.db.Query(`SELECT `+foodColumns+` FROM foods
+		WHERE LOWER(name) LIKE ? ESCAPE '!'
+		ORDER BY CASE WHEN LOWER(name) LIKE ? ESCAPE '!' THEN 0 ELSE 1 END, name
+		LIMIT ?`, "%"+like+"%", like+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	foods := []Food{}
+	for rows.Next() {
+		f, err := scanFood(rows)
+		if err != nil {
+			return nil, err
+		}
+		foods = append(foods, f)
+	}
+	return foods, rows.Err()
+}
+
+// GetFood returns one food by ID
+func (s *SQLStore) GetFood(id int64) (*Food, error) {
+	f, err := scanFood(s.db.QueryRow("SELECT "+foodColumns+" FROM foods WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// CreateFood adds a food to the database
+func (s *SQLStore) CreateFood(f Food) (*Food, error) {
+	if err := s.checkFoodName(0, f.Name); err != nil {
+		return nil, err
+	}
+	result, err := s.db.Exec(`INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, f.Name, f.Serving, f.Calories, f.ProteinG, f.CarbsG, f.FatG, f.FibreG)
+	if err != nil {
+		return nil, err
+	}
+	if f.ID, err = result.LastInsertId(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// UpdateFood replaces a food. Entries already logged keep their nutrients.
+func (s *SQLStore) UpdateFood(id int64, f Food) error {
+	if err := s.checkFoodName(id, f.Name); err != nil {
+		return err
+	}
+	result, err := s.db.Exec(`UPDATE foods SET name = ?, serving = ?, calories = ?, protein_g = ?, carbs_g = ?,
+		fat_g = ?, fibre_g = ? WHERE id = ?`, f.Name, f.Serving, f.Calories, f.ProteinG, f.CarbsG, f.FatG, f.FibreG, id)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// DeleteFood removes a food, and with it from every recipe using it
+func (s *SQLStore) DeleteFood(id int64) error {
+	result, err := s.db.Exec("DELETE FROM foods WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+func (s *SQLStore) checkFoodName(id int64, name string) error {
+	var other int64
+	err := s.db.QueryRow("SELECT id FROM foods WHERE LOWER(name) = ? AND id <> ?", strings.ToLower(name), id).Scan(&other)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return ErrFoodExists
+}
+
+// ImportFoods adds foods in one transaction, replacing the serving and
+// nutrients of foods that already exist by name, in any case
+func (s *SQLStore) ImportFoods(foods []Food) (created, updated int, err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	for _, f := range foods {
+		var id int64
+		err := tx.QueryRow("SELECT id FROM foods WHERE LOWER(name) = ?", strings.ToLower(f.Name)).Scan(&id)
+		switch {
+		case err == sql.ErrNoRows:
+			_, err = tx.Exec(`INSERT INTO foods (name, serving, calories, protein_g, carbs_g, fat_g, fibre_g)
+				VALUES (?, ?, ?, ?, ?, ?, ?)`, f.Name, f.Serving, f.Calories, f.ProteinG, f.CarbsG, f.FatG, f.FibreG)
+			created++
+		case err == nil:
+			_, err = tx.Exec(`UPDATE foods SET serving = ?, calories = ?, protein_g = ?, carbs_g = ?, fat_g = ?, fibre_g = ?
+				WHERE id = ?`, f.Serving, f.Calories, f.ProteinG, f.CarbsG, f.FatG, f.FibreG, id)
+			updated++
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return created, updated, tx.Commit()
+}
+
+// AddFoodEntries logs meal items for a meal on a day (YYYY-MM-DD)
+func (s *SQLStore) AddFoodEntries(userID int64, date, meal string, items []MealItem) ([]FoodEntry, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	entries := make([]FoodEntry, 0, len(items))
+	for _, item := range items {
+		result, err := tx.Exec(`INSERT INTO food_entries (user_id, date, meal, food_id, recipe_id, name, servings,
+				calories, protein_g, carbs_g, fat_g, fibre_g, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			userID, date, meal, item.FoodID, item.RecipeID, item.Name, item.Servings,
+			item.Calories, item.ProteinG, item.CarbsG, item.FatG, item.FibreG, now)
+		if err != nil {
+			return nil, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, FoodEntry{ID: id, Date: date, Meal: meal, CreatedAt: now, MealItem: item})
+	}
+	if err := s.syncMealsLogged(tx, userID, date); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.refreshGoalsAfter(userID)
+	return entries, nil
+}
+
+// UpdateFoodEntry moves an entry to another meal and changes how many
+// servings were eaten, scaling its nutrients to match
+func (s *SQLStore) UpdateFoodEntry(userID, id int64, meal string, servings float64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var old Nutrients
+	var oldServings float64
+	err = tx.QueryRow(`SELECT servings, calories, protein_g, carbs_g, fat_g, fibre_g FROM food_entries
+		WHERE id = ? AND user_id = ?`, id, userID).
+		Scan(&oldServings, &old.Calories, &old.ProteinG, &old.CarbsG, &old.FatG, &old.FibreG)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	n := old
+	if oldServings > 0 {
+		n = old.Scale(servings / oldServings)
+	}
+	_, err = tx.Exec(`UPDATE food_entries SET meal = ?, servings = ?, calories = ?, protein_g = ?, carbs_g = ?,
+		fat_g = ?, fibre_g = ? WHERE id = ?`, meal, servings, n.Calories, n.ProteinG, n.CarbsG, n.FatG, n.FibreG, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteFoodEntry removes one of the member's entries
+func (s *SQLStore) DeleteFoodEntry(userID, id int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var date time.Time
+	err = tx.QueryRow("SELECT date FROM food_entries WHERE id = ? AND user_id = ?", id, userID).Scan(&date)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM food_entries WHERE id = ?", id); err != nil {
+		return err
+	}
+	if err := s.syncMealsLogged(tx, userID, date.Format(dateLayout)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.refreshGoalsAfter(userID)
+	return nil
+}
+
+// syncMealsLogged ticks the meals habit for a day with entries and clears
+// it for a day without
+func (s *SQLStore) syncMealsLogged(tx *sql.Tx, userID int64, date string) error {
+	logged, err := hasFoodEntries(tx, userID, date)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO user_progress (user_id, date, meals_logged) VALUES (?, ?, ?)
+		`+s.dialect.upsert([]string{"user_id", "date"}, "meals_logged"), userID, date, logged)
+	return err
+}
+
+// hasFoodEntries reports whether the member logged any food on a day.
+// Such a day has its meals habit ticked by the entries, not by hand.
+func hasFoodEntries(q queryRower, userID int64, date string) (bool, error) {
+	var count int
+	err := q.QueryRow("SELECT COUNT(*) FROM food_entries WHERE user_id = ? AND date = ?", userID, date).Scan(&count)
+	return count > 0, err
+}
+
+// GetNutritionDay returns the member's entries and totals for a day
+func (s *SQLStore) GetNutritionDay(userID int64, date string) (*NutritionDay, error) {
+	rows, err := s.db.Query(`SELECT id, date, meal, food_id, recipe_id, name, servings,
+			calories, protein_g, carbs_g, fat_g, fibre_g, created_at
+		FROM food_entries WHERE user_id = ? AND date = ?
+		ORDER BY CASE meal WHEN ? THEN 0 WHEN ? THEN 1 WHEN ? THEN 2 ELSE 3 END, created_at, id`,
+		userID, date, MealBreakfast, MealLunch, MealDinner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	day := &NutritionDay{Date: date, Entries: []FoodEntry{}, MealTotals: map[string]Nutrients{}}
+	for _, meal := range Meals {
+		day.MealTotals[meal] = Nutrients{}
+	}
+	for rows.Next() {
+		var e FoodEntry
+		var on time.Time
+		var foodID, recipeID sql.NullInt64
+		err := rows.Scan(&e.ID, &on, &e.Meal, &foodID, &recipeID, &e.Name, &e.Servings,
+			&e.Calories, &e.ProteinG, &e.CarbsG, &e.FatG, &e.FibreG, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		e.Date = on.Format(dateLayout)
+		e.FoodID, e.RecipeID = nullID(foodID), nullID(recipeID)
+		day.Entries = append(day.Entries, e)
+		day.MealTotals[e.Meal] = day.MealTotals[e.Meal].Add(e.Nutrients)
+		day.Totals = day.Totals.Add(e.Nutrients)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	targets, err := s.GetNutritionTargets(userID)
+	if err != nil {
+		return nil, err
+	}
+	day.Targets = *targets
+	return day, nil
+}
+
+// GetNutritionTargets returns the member's daily targets, all missing if
+// none were set
+func (s *SQLStore) GetNutritionTargets(userID int64) (*NutritionTargets, error) {
+	var calories, protein, carbs, fat, fibre sql.NullFloat64
+	err := s.db.QueryRow(`SELECT calories, protein_g, carbs_g, fat_g, fibre_g FROM nutrition_targets
+		WHERE user_id = ?`, userID).Scan(&calories, &protein, &carbs, &fat, &fibre)
+	if err == sql.ErrNoRows {
+		return &NutritionTargets{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &NutritionTargets{
+		Calories: nullFloat(calories),
+		ProteinG: nullFloat(protein),
+		CarbsG:   nullFloat(carbs),
+		FatG:     nullFloat(fat),
+		FibreG:   nullFloat(fibre),
+	}, nil
+}
+
+// SetNutritionTargets replaces the member's daily targets
+func (s *SQLStore) SetNutritionTargets(userID int64, t NutritionTargets) error {
+	_, err := s.db.Exec(`INSERT INTO nutrition_targets (user_id, calories, protein_g, carbs_g, fat_g, fibre_g)
+		VALUES (?, ?, ?, ?, ?, ?)
+		`+s.dialect.upsert([]string{"user_id"}, "calories", "protein_g", "carbs_g", "fat_g", "fibre_g"),
+		userID, t.Calories, t.ProteinG, t.CarbsG, t.FatG, t.FibreG)
+	return err
+}
+
+func nullID(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	id := v.Int64
+	return &id
+}
+
+func SearchFoods(query string, limit int) ([]Food, error) {
+	return current.SearchFoods(query, limit)
+}
+
+func GetFood(id int64) (*Food, error) {
+	return current.GetFood(id)
+}
+
+func CreateFood(f Food) (*Food, error) {
+	return current.CreateFood(f)
+}
+
+func UpdateFood(id int64, f Food) error {
+	return current.UpdateFood(id, f)
+}
+
+func DeleteFood(id int64) error {
+	return current.DeleteFood(id)
+}
+
+func ImportFoods(foods []Food) (created, updated int, err error) {
+	return current.ImportFoods(foods)
+}
+
+func AddFoodEntries(userID int64, date, meal string, items []MealItem) ([]FoodEntry, error) {
+	return current.AddFoodEntries(userID, date, meal, items)
+}
+
+func UpdateFoodEntry(userID, id int64, meal string, servings float64) error {
+	return current.UpdateFoodEntry(userID, id, meal, servings)
+}
+
+func DeleteFoodEntry(userID, id int64) error {
+	return current.DeleteFoodEntry(userID, id)
+}
+
+func GetNutritionDay(userID int64, date string) (*NutritionDay, error) {
+	return current.GetNutritionDay(userID, date)
+}
+
+func GetNutritionTargets(userID int64) (*NutritionTargets, error) {
+	return current.GetNutritionTargets(userID)
+}
+
+func SetNutritionTargets(userID int64, t NutritionTargets) error {
+	return current.SetNutritionTargets(userID, t)
+}
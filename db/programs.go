@@ -0,0 +1,358 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Program statuses. A coach approves a pending program; editing an
+// approved program makes it pending again.
+const (
+	ProgramPending  = "pending"
+	ProgramApproved = "approved"
+)
+
+// Who wrote a program
+const (
+	ProgramFromMember = "member"
+	ProgramFromAI     = "ai"
+)
+
+// Kinds of exercise in a program day
+const (
+	ProgramStrength = "strength"
+	ProgramCardio   = "cardio"
+)
+
+// Program is a multi-week training plan. Lists leave Days empty.
+type Program struct {
+	ID         int64        `json:"id"`
+	Title      string       `json:"title"`
+	Weeks      int          `json:"weeks"`
+	Notes      string       `json:"notes"`
+	Source     string       `json:"source"`
+	Status     string       `json:"status"`
+	ApprovedBy string       `json:"approvedBy,omitempty"`
+	ApprovedAt *time.Time   `json:"approvedAt,omitempty"`
+	CreatedAt  time.Time    `json:"createdAt"`
+	UpdatedAt  time.Time    `json:"updatedAt"`
+	Days       []ProgramDay `json:"days,omitempty"`
+}
+
+// ProgramDay is one training day: day 1-7 of a week of the program
+type ProgramDay struct {
+	Week      int               `json:"week"`
+	Day       int               `json:"day"`
+	Name      string            `json:"name"`
+	Exercises []ProgramExercise `json:"exercises"`
+}
+
+// ProgramExercise is a prescribed exercise. Strength work has sets and
+// reps ("8" or "8-12"); cardio has minutes.
+type ProgramExercise struct {
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Sets      int    `json:"sets,omitempty"`
+	Reps      string `json:"reps,omitempty"`
+	Minutes   int    `json:"minutes,omitempty"`
+	Intensity string `json:"intensity"`
+}
+
+// PendingProgram is a program waiting for a coach, with its member
+type PendingProgram struct {
+	Program
+	Username string `json:"username"`
+	FullName string `json:"fullName"`
+}
+
+// ProgramStore persists training programs
+type ProgramStore interface {
+	CreateProgram(userID int64, p Program) (*Program, error)
+	ListPrograms(userID int64) ([]Program, error)
+	GetProgram(userID, id int64) (*Program, error)
+	CurrentProgram(userID int64) (*Program, error)
+	UpdateProgram(userID, id int64, p Program) error
+	DeleteProgram(userID, id int64) error
+	ProgramOwner(id int64) (int64, error)
+	PendingPrograms(coachID int64) ([]PendingProgram, error)
+	ApproveProgram(id, coachID int64) error
+}
+
+// CreateProgram saves a new pending program with its days
+func (s *SQLStore) CreateProgram(userID int64, p Program) (*Program, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	result, err := tx.Exec(`INSERT INTO programs (user_id, title, weeks, notes, source, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, p.Title, p.Weeks, p.Notes, p.Source, ProgramPending, now, now)
+	if err != nil {
+		return nil, err
+	}
+	if p.ID, err = result.LastInsertId(); err != nil {
+		return nil, err
+	}
+	if err := insertProgramDays(tx, p.ID, p.Days); err != nil {
+		return nil, err
+	}
+
+	p.Status, p.ApprovedBy, p.ApprovedAt = ProgramPending, "", nil
+	p.CreatedAt, p.UpdatedAt = now, now
+	return &p, tx.Commit()
+}
+
+func insertProgramDays(tx *sql.Tx, programID int64, days []ProgramDay) error {
+	for _, d := range days {
+		result, err := tx.Exec("INSERT INTO program_days (program_id, week, day, name) VALUES (?, ?, ?, ?)",
+			programID, d.Week, d.Day, d.Name)
+		if err != nil {
+			return err
+		}
+		dayID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		for i, e := range d.Exercises {
+			_, err := tx.Exec(`INSERT INTO program_exercises (day_id, position, type, exercise, sets, reps, minutes, intensity)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				dayID, i+1, e.Type, e.Name, e.Sets, e.Reps, e.Minutes, e.Intensity)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+const programColumns = `p.id, p.title, p.weeks, p.notes, p.source, p.status, COALESCE(a.username, ''),
+	p.approved_at, p.created_at, p.updated_at`
+
+func scanProgram(row interface{ Scan(...any) error }, extra ...any) (*Program, error) {
+	var p Program
+	var approvedAt sql.NullTime
+	dest := append([]any{&p.ID, &p.Title, &p.Weeks, &p.Notes, &p.Source, &p.Status, &p.ApprovedBy,
+		&approvedAt, &p.CreatedAt, &p.UpdatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if approvedAt.Valid {
+		p.ApprovedAt = &approvedAt.Time
+	}
+	return &p, nil
+}
+
+// ListPrograms returns the user's programs without their days, newest first
+func (s *SQLStore) ListPrograms(userID int64) ([]Program, error) {
+	rows, err := s.db.Query(`SELECT `+programColumns+`
+		FROM programs p LEFT JOIN person a ON a.id = p.approved_by
+		WHERE p.user_id = ?
+		ORDER BY p.created_at DESC, p.id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	programs := []Program{}
+	for rows.Next() {
+		p, err := scanProgram(rows)
+		if err != nil {
+			return nil, err
+		}
+		programs = append(programs, *p)
+	}
+	return programs, rows.Err()
+}
+
+// GetProgram loads one of the user's programs with every day and exercise
+func (s *SQLStore) GetProgram(userID, id int64) (*Program, error) {
+	p, err := scanProgram(s.db.QueryRow(`SELECT `+programColumns+`
+		FROM programs p LEFT JOIN person a ON a.id = p.approved_by
+		WHERE p.id = ? AND p.user_id = ?`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT d.id, d.week, d.day, d.name, e.type, e.exercise, e.sets, e.reps, e.minutes, e.intensity
+		FROM program_days d
+		LEFT JOIN program_exercises e ON e.day_id = d.id
+		WHERE d.program_id = ?
+		ORDER BY d.week, d.day, e.position`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	p.Days = []ProgramDay{}
+	var lastDay int64
+	for rows.Next() {
+		var dayID int64
+		var d ProgramDay
+		var kind, name, reps, intensity sql.NullString
+		var sets, minutes sql.NullInt64
+		if err := rows.Scan(&dayID, &d.Week, &d.Day, &d.Name, &kind, &name, &sets, &reps, &minutes, &intensity); err != nil {
+			return nil, err
+		}
+		if dayID != lastDay {
+			d.Exercises = []ProgramExercise{}
+			p.Days = append(p.Days, d)
+			lastDay = dayID
+		}
+		if !kind.Valid {
+			continue
+		}
+		day := &p.Days[len(p.Days)-1]
+		day.Exercises = append(day.Exercises, ProgramExercise{
+			Type:      kind.String,
+			Name:      name.String,
+			Sets:      int(sets.Int64),
+			Reps:      reps.String,
+			Minutes:   int(minutes.Int64),
+			Intensity: intensity.String,
+		})
+	}
+	return p, rows.Err()
+}
+
+// CurrentProgram returns the user's newest program, or nil
+func (s *SQLStore) CurrentProgram(userID int64) (*Program, error) {
+	var id int64
+	err := s.db.QueryRow(`SELECT id FROM programs WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC LIMIT 1`, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetProgram(userID, id)
+}
+
+// UpdateProgram replaces a program's details and days. The edited program
+// needs approving again.
+func (s *SQLStore) UpdateProgram(userID, id int64, p Program) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE programs SET title = ?, weeks = ?, notes = ?, status = ?,
+			approved_by = NULL, approved_at = NULL, updated_at = ?
+		WHERE id = ? AND user_id = ?`,
+		p.Title, p.Weeks, p.Notes, ProgramPending, time.Now().UTC(), id, userID)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(result); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM program_days WHERE program_id = ?", id); err != nil {
+		return err
+	}
+	if err := insertProgramDays(tx, id, p.Days); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteProgram removes a program and its days
+func (s *SQLStore) DeleteProgram(userID, id int64) error {
+	result, err := s.db.Exec("DELETE FROM programs WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// ProgramOwner returns the ID of the member a program belongs to
+func (s *SQLStore) ProgramOwner(id int64) (int64, error) {
+	var userID int64
+	err := s.db.QueryRow("SELECT user_id FROM programs WHERE id = ?", id).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	return userID, err
+}
+
+// PendingPrograms lists the programs of a coach's clients that wait for
+// approval, oldest first. A coachID of 0 lists every member's.
+func (s *SQLStore) PendingPrograms(coachID int64) ([]PendingProgram, error) {
+	rows, err := s.db.Query(`SELECT `+programColumns+`, m.username, COALESCE(ui.full_name, '')
+		FROM programs p
+		JOIN person m ON m.id = p.user_id
+		LEFT JOIN user_info ui ON ui.user_id = p.user_id
+		LEFT JOIN person a ON a.id = p.approved_by
+		WHERE p.status = ? AND (? = 0 OR p.user_id IN (
+			SELECT member_id FROM coach_clients WHERE coach_id = ? AND status = ?))
+		ORDER BY p.updated_at, p.id`, ProgramPending, coachID, coachID, LinkActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pending := []PendingProgram{}
+	for rows.Next() {
+		var pp PendingProgram
+		p, err := scanProgram(rows, &pp.Username, &pp.FullName)
+		if err != nil {
+			return nil, err
+		}
+		pp.Program = *p
+		pending = append(pending, pp)
+	}
+	return pending, rows.Err()
+}
+
+// ApproveProgram marks a program approved by the coach
+func (s *SQLStore) ApproveProgram(id, coachID int64) error {
+	result, err := s.db.Exec(`UPDATE programs SET status = ?, approved_by = ?, approved_at = ? WHERE id = ?`,
+		ProgramApproved, coachID, time.Now().UTC(), id)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+func CreateProgram(userID int64, p Program) (*Program, error) {
+	return current.CreateProgram(userID, p)
+}
+
+func ListPrograms(userID int64) ([]Program, error) {
+	return current.ListPrograms(userID)
+}
+
+func GetProgram(userID, id int64) (*Program, error) {
+	return current.GetProgram(userID, id)
+}
+
+func CurrentProgram(userID int64) (*Program, error) {
+	return current.CurrentProgram(userID)
+}
+
+func UpdateProgram(userID, id int64, p Program) error {
+	return current.UpdateProgram(userID, id, p)
+}
+
+func DeleteProgram(userID, id int64) error {
+	return current.DeleteProgram(userID, id)
+}
+
+func ProgramOwner(id int64) (int64, error) {
+	return current.ProgramOwner(id)
+}
+
+func PendingPrograms(coachID int64) ([]PendingProgram, error) {
+	return current.PendingPrograms(coachID)
+}
+
+func ApproveProgram(id, coachID int64) error {
+	return current.ApproveProgram(id, coachID)
+}
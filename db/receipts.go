@@ -0,0 +1,132 @@
+package db
+
+import "time"
+
+// Receipt statuses
+const (
+	ReceiptDelivered = "delivered"
+	ReceiptRead      = "read"
+)
+
+// Receipt says that Reader got or read Sender's messages up to UpTo
+type Receipt struct {
+	Sender string    `json:"sender"`
+	Reader string    `json:"reader"`
+	Status string    `json:"status"`
+	UpTo   int64     `json:"upTo"`
+	At     time.Time `json:"at"`
+}
+
+// ReceiptStore records chat message delivery and reading
+type ReceiptStore interface {
+	MarkDelivered(senderID, receiverID, upTo int64) (bool, error)
+	MarkRead(senderID, receiverID, upTo int64) (bool, error)
+	DeliverPending(receiverID int64) ([]Receipt, error)
+	UnreadCounts(receiverID int64) (map[string]int, error)
+}
+
+// MarkDelivered stamps the sender's messages to receiver up to the given
+// ID as delivered and reports whether any were not already
+func (s *SQLStore) MarkDelivered(senderID, receiverID, upTo int64) (bool, error) {
+	result, err := s.db.Exec(`UPDATE messages SET delivered_at = ?
+		WHERE sender_id = ? AND receiver_id = ? AND id <= ? AND delivered_at IS NULL`,
+		time.Now().UTC(), senderID, receiverID, upTo)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// MarkRead stamps the sender's messages to receiver up to the given ID as
+// read, and delivered if they weren't, and reports whether any were unread
+func (s *SQLStore) MarkRead(senderID, receiverID, upTo int64) (bool, error) {
+	now := time.Now().UTC()
+	result, err := s.db.Exec(`UPDATE messages SET read_at = ?, delivered_at = COALESCE(delivered_at, ?)
+		WHERE sender_id = ? AND receiver_id = ? AND id <= ? AND read_at IS NULL`,
+		now, now, senderID, receiverID, upTo)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// DeliverPending marks every message waiting for receiver as delivered and
+// returns a receipt per sender
+func (s *SQLStore) DeliverPending(receiverID int64) ([]Receipt, error) {
+	rows, err := s.db.Query(`SELECT m.sender_id, p.username, MAX(m.id) FROM messages m
+		JOIN person p ON p.id = m.sender_id
+		WHERE m.receiver_id = ? AND m.delivered_at IS NULL
+		GROUP BY m.sender_id, p.username`, receiverID)
+	if err != nil {
+		return nil, err
+	}
+	type pending struct {
+		senderID int64
+		receipt  Receipt
+	}
+	var found []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.senderID, &p.receipt.Sender, &p.receipt.UpTo); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		found = append(found, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	receipts := []Receipt{}
+	for _, p := range found {
+		if _, err := s.MarkDelivered(p.senderID, receiverID, p.receipt.UpTo); err != nil {
+			return nil, err
+		}
+		p.receipt.Status, p.receipt.At = ReceiptDelivered, time.Now().UTC()
+		receipts = append(receipts, p.receipt)
+	}
+	return receipts, nil
+}
+
+// UnreadCounts returns how many unread messages receiver has from each
+// sender, by username
+func (s *SQLStore) UnreadCounts(receiverID int64) (map[string]int, error) {
+	rows, err := s.db.Query(`SELECT p.username, COUNT(*) FROM messages m
+		JOIN person p ON p.id = m.sender_id
+		WHERE m.receiver_id = ? AND m.read_at IS NULL
+		GROUP BY p.username`, receiverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var username string
+		var n int
+		if err := rows.Scan(&username, &n); err != nil {
+			return nil, err
+		}
+		counts[username] = n
+	}
+	return counts, rows.Err()
+}
+
+func MarkDelivered(senderID, receiverID, upTo int64) (bool, error) {
+	return current.MarkDelivered(senderID, receiverID, upTo)
+}
+
+func MarkRead(senderID, receiverID, upTo int64) (bool, error) {
+	return current.MarkRead(senderID, receiverID, upTo)
+}
+
+func DeliverPending(receiverID int64) ([]Receipt, error) {
+	return current.DeliverPending(receiverID)
+}
+
+func UnreadCounts(receiverID int64) (map[string]int, error) {
+	return current.UnreadCounts(receiverID)
+}
@@ -0,0 +1,351 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Recipe is a member's dish made of foods from the database. Portions is
+// how many servings the whole recipe makes; PerPortion is worked out from
+// the foods as they are now.
+type Recipe struct {
+	ID         int64        `json:"id"`
+	Name       string       `json:"name"`
+	Portions   float64      `json:"portions"`
+	Items      []RecipeItem `json:"items"`
+	PerPortion Nutrients    `json:"perPortion"`
+	CreatedAt  time.Time    `json:"createdAt"`
+}
+
+// RecipeItem is an amount of a food in a recipe, with the nutrients of
+// that amount
+type RecipeItem struct {
+	FoodID   int64   `json:"foodId"`
+	Name     string  `json:"name"`
+	Serving  string  `json:"serving"`
+	Servings float64 `json:"servings"`
+	Nutrients
+}
+
+// FavouriteMeal is a saved set of meal items a member can log again in one
+// go
+type FavouriteMeal struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Items     []MealItem `json:"items"`
+	Total     Nutrients  `json:"total"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// ErrRecipeExists and ErrFavouriteExists are returned when the member
+// already has a recipe or favourite meal by that name
+var (
+	ErrRecipeExists    = errors.New("recipe already exists")
+	ErrFavouriteExists = errors.New("favourite meal already exists")
+)
+
+// ListRecipes returns the member's recipes by name
+func (s *SQLStore) ListRecipes(userID int64) ([]Recipe, error) {
+	return s.loadRecipes("r.user_id = ?", userID)
+}
+
+// GetRecipe returns one of the member's recipes
+func (s *SQLStore) GetRecipe(userID, id int64) (*Recipe, error) {
+	recipes, err := s.loadRecipes("r.user_id = ? AND r.id = ?", userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipes) == 0 {
+		return nil, ErrNotFound
+	}
+	return &recipes[0], nil
+}
+
+func (s *SQLStore) loadRecipes(where string, args ...any) ([]Recipe, error) {
+	rows, err := s.db.Query("SELECT r.id, r.name, r.portions, r.created_at FROM recipes r WHERE "+where+" ORDER BY r.name", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipes := []Recipe{}
+	index := map[int64]int{}
+	for rows.Next() {
+		r := Recipe{Items: []RecipeItem{}}
+		if err := rows.Scan(&r.ID, &r.Name, &r.Portions, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		index[r.ID] = len(recipes)
+		recipes = append(recipes, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(recipes) == 0 {
+		return recipes, nil
+	}
+
+	items, err := s.db.Query(`SELECT r.id, f.id, f.name, f.serving, i.servings,
+			f.calories, f.protein_g, f.carbs_g, f.fat_g, f.fibre_g
+		FROM recipe_items i
+		JOIN recipes r ON r.id = i.recipe_id
+		JOIN foods f ON f.id = i.food_id
+		WHERE `+where+` ORDER BY f.name`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer items.Close()
+
+	for items.Next() {
+		var recipeID int64
+		var item RecipeItem
+		var perServing Nutrients
+		err := items.Scan(&recipeID, &item.FoodID, &item.Name, &item.Serving, &item.Servings,
+			&perServing.Calories, &perServing.ProteinG, &perServing.CarbsG, &perServing.FatG, &perServing.FibreG)
+		if err != nil {
+			return nil, err
+		}
+		item.Nutrients = perServing.Scale(item.Servings)
+		r := &recipes[index[recipeID]]
+		r.Items = append(r.Items, item)
+		r.PerPortion = r.PerPortion.Add(item.Nutrients.Scale(1 / r.Portions))
+	}
+	return recipes, items.Err()
+}
+
+// CreateRecipe saves a new recipe from its name, portions and the food ID
+// and servings of each item
+func (s *SQLStore) CreateRecipe(userID int64, r Recipe) (*Recipe, error) {
+	if err := s.checkRecipeName(userID, 0, r.Name); err != nil {
+		return nil, err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO recipes (user_id, name, portions, created_at) VALUES (?, ?, ?, ?)",
+		userID, r.Name, r.Portions, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := insertRecipeItems(tx, id, r.Items); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.GetRecipe(userID, id)
+}
+
+func insertRecipeItems(tx *sql.Tx, recipeID int64, items []RecipeItem) error {
+	for _, item := range items {
+		_, err := tx.Exec("INSERT INTO recipe_items (recipe_id, food_id, servings) VALUES (?, ?, ?)",
+			recipeID, item.FoodID, item.Servings)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateRecipe replaces the name, portions and items of one of the
+// member's recipes. Entries already logged keep their nutrients.
+func (s *SQLStore) UpdateRecipe(userID, id int64, r Recipe) error {
+	if err := s.checkRecipeName(userID, id, r.Name); err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE recipes SET name = ?, portions = ? WHERE id = ? AND user_id = ?",
+		r.Name, r.Portions, id, userID)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(result); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM recipe_items WHERE recipe_id = ?", id); err != nil {
+		return err
+	}
+	if err := insertRecipeItems(tx, id, r.Items); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteRecipe removes one of the member's recipes
+func (s *SQLStore) DeleteRecipe(userID, id int64) error {
+	result, err := s.db.Exec("DELETE FROM recipes WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+func (s *SQLStore) checkRecipeName(userID, id int64, name string) error {
+	var other int64
+	err := s.db.QueryRow("SELECT id FROM recipes WHERE user_id = ? AND name = ? AND id <> ?", userID, name, id).Scan(&other)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return ErrRecipeExists
+}
+
+// ListFavouriteMeals returns the member's favourite meals by name
+func (s *SQLStore) ListFavouriteMeals(userID int64) ([]FavouriteMeal, error) {
+	rows, err := s.db.Query("SELECT id, name, created_at FROM favourite_meals WHERE user_id = ? ORDER BY name", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	favourites := []FavouriteMeal{}
+	index := map[int64]int{}
+	for rows.Next() {
+		f := FavouriteMeal{Items: []MealItem{}}
+		if err := rows.Scan(&f.ID, &f.Name, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		index[f.ID] = len(favourites)
+		favourites = append(favourites, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	items, err := s.db.Query(`SELECT i.favourite_id, i.food_id, i.recipe_id, i.name, i.servings,
+			i.calories, i.protein_g, i.carbs_g, i.fat_g, i.fibre_g
+		FROM favourite_meal_items i
+		JOIN favourite_meals f ON f.id = i.favourite_id
+		WHERE f.user_id = ? ORDER BY i.id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer items.Close()
+
+	for items.Next() {
+		var favouriteID int64
+		var item MealItem
+		var foodID, recipeID sql.NullInt64
+		err := items.Scan(&favouriteID, &foodID, &recipeID, &item.Name, &item.Servings,
+			&item.Calories, &item.ProteinG, &item.CarbsG, &item.FatG, &item.FibreG)
+		if err != nil {
+			return nil, err
+		}
+		item.FoodID, item.RecipeID = nullID(foodID), nullID(recipeID)
+		f := &favourites[index[favouriteID]]
+		f.Items = append(f.Items, item)
+		f.Total = f.Total.Add(item.Nutrients)
+	}
+	return favourites, items.Err()
+}
+
+// SaveFavouriteMeal copies what the member logged for a meal on a day
+// (YYYY-MM-DD) into a new favourite. It returns ErrNotFound when the meal
+// has no entries.
+func (s *SQLStore) SaveFavouriteMeal(userID int64, name, date, meal string) (*FavouriteMeal, error) {
+	var other int64
+	err := s.db.QueryRow("SELECT id FROM favourite_meals WHERE user_id = ? AND name = ?", userID, name).Scan(&other)
+	if err == nil {
+		return nil, ErrFavouriteExists
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	result, err := tx.Exec("INSERT INTO favourite_meals (user_id, name, created_at) VALUES (?, ?, ?)", userID, name, now)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	result, err = tx.Exec(`INSERT INTO favourite_meal_items (favourite_id, food_id, recipe_id, name, servings,
+			calories, protein_g, carbs_g, fat_g, fibre_g)
+		SELECT ?, food_id, recipe_id, name, servings, calories, protein_g, carbs_g, fat_g, fibre_g
+		FROM food_entries WHERE user_id = ? AND date = ? AND meal = ?
+		ORDER BY created_at, id`, id, userID, date, meal)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectRow(result); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	favourites, err := s.ListFavouriteMeals(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range favourites {
+		if f.ID == id {
+			return &f, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// DeleteFavouriteMeal removes one of the member's favourite meals
+func (s *SQLStore) DeleteFavouriteMeal(userID, id int64) error {
+	result, err := s.db.Exec("DELETE FROM favourite_meals WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+func ListRecipes(userID int64) ([]Recipe, error) {
+	return current.ListRecipes(userID)
+}
+
+func GetRecipe(userID, id int64) (*Recipe, error) {
+	return current.GetRecipe(userID, id)
+}
+
+func CreateRecipe(userID int64, r Recipe) (*Recipe, error) {
+	return current.CreateRecipe(userID, r)
+}
+
+func UpdateRecipe(userID, id int64, r Recipe) error {
+	return current.UpdateRecipe(userID, id, r)
+}
+
+func DeleteRecipe(userID, id int64) error {
+	return current.DeleteRecipe(userID, id)
+}
+
+func ListFavouriteMeals(userID int64) ([]FavouriteMeal, error) {
+	return current.ListFavouriteMeals(userID)
+}
+
+func SaveFavouriteMeal(userID int64, name, date, meal string) (*FavouriteMeal, error) {
+	return current.SaveFavouriteMeal(userID, name, date, meal)
+}
+
+func DeleteFavouriteMeal(userID, id int64) error {
+	return current.DeleteFavouriteMeal(userID, id)
+}
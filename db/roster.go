@@ -0,0 +1,194 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Coach–client link statuses
+const (
+	LinkPending = "pending"
+	LinkActive  = "active"
+)
+
+// Person is an account without its password
+type Person struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
+
+// RosterEntry is the other side of a coach–client link: a client on a
+// coach's roster or a coach on a member's. InitiatedBy is the role that
+// sent the invitation or request; the other side accepts it.
+type RosterEntry struct {
+	Username    string     `json:"username"`
+	FullName    string     `json:"fullName"`
+	Email       string     `json:"email"`
+	Status      string     `json:"status"`
+	InitiatedBy string     `json:"initiatedBy"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	AcceptedAt  *time.Time `json:"acceptedAt,omitempty"`
+}
+
+// ErrAlreadyLinked is returned when inviting someone already on the roster
+var ErrAlreadyLinked = errors.New("already linked")
+
+// RosterStore persists which members each coach works with
+type RosterStore interface {
+	FindPerson(usernameOrEmail string) (*Person, error)
+	LinkCoach(coachID, memberID int64, by string) (string, error)
+	AcceptLink(coachID, memberID int64, by string) error
+	RemoveLink(coachID, memberID int64) error
+	ListClients(coachID int64) ([]RosterEntry, error)
+	ListCoaches(memberID int64) ([]RosterEntry, error)
+	IsClient(coachID, memberID int64) (bool, error)
+}
+
+// FindPerson looks an account up by username or, failing that, by email
+func (s *SQLStore) FindPerson(usernameOrEmail string) (*Person, error) {
+	var p Person
+	err := s.db.QueryRow(`SELECT id, username, email, role FROM person WHERE username = ? OR LOWER(email) = ?
+		ORDER BY username = ? DESC LIMIT 1`,
+		usernameOrEmail, strings.ToLower(usernameOrEmail), usernameOrEmail).Scan(&p.ID, &p.Username, &p.Email, &p.Role)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// LinkCoach records an invitation (by "coach") or a request (by "member")
+// and returns the link's status. Asking someone who already asked you
+// accepts their invitation.
+func (s *SQLStore) LinkCoach(coachID, memberID int64, by string) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var status, initiatedBy string
+	err = tx.QueryRow("SELECT status, initiated_by FROM coach_clients WHERE coach_id = ? AND member_id = ?",
+		coachID, memberID).Scan(&status, &initiatedBy)
+	now := time.Now().UTC()
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.Exec(`INSERT INTO coach_clients (coach_id, member_id, status, initiated_by, created_at)
+			VALUES (?, ?, ?, ?, ?)`, coachID, memberID, LinkPending, by, now)
+		status = LinkPending
+	case err != nil:
+		return "", err
+	case status == LinkActive:
+		return status, ErrAlreadyLinked
+	case initiatedBy != by:
+		_, err = tx.Exec("UPDATE coach_clients SET status = ?, accepted_at = ? WHERE coach_id = ? AND member_id = ?",
+			LinkActive, now, coachID, memberID)
+		status = LinkActive
+	}
+	if err != nil {
+		return "", err
+	}
+	return status, tx.Commit()
+}
+
+// AcceptLink activates a pending link the other side initiated
+func (s *SQLStore) AcceptLink(coachID, memberID int64, by string) error {
+	result, err := s.db.Exec(`UPDATE coach_clients SET status = ?, accepted_at = ?
+		WHERE coach_id = ? AND member_id = ? AND status = ? AND initiated_by <> ?`,
+		LinkActive, time.Now().UTC(), coachID, memberID, LinkPending, by)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// RemoveLink ends a link, or declines or withdraws a pending one
+func (s *SQLStore) RemoveLink(coachID, memberID int64) error {
+	result, err := s.db.Exec("DELETE FROM coach_clients WHERE coach_id = ? AND member_id = ?", coachID, memberID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// ListClients returns a coach's clients and pending invitations
+func (s *SQLStore) ListClients(coachID int64) ([]RosterEntry, error) {
+	return s.roster("cc.member_id", "cc.coach_id", coachID)
+}
+
+// ListCoaches returns a member's coaches and pending requests
+func (s *SQLStore) ListCoaches(memberID int64) ([]RosterEntry, error) {
+	return s.roster("cc.coach_id", "cc.member_id", memberID)
+}
+
+// roster lists the people in the other column of the links where column
+// is id
+func (s *SQLStore) roster(other, column string, id int64) ([]RosterEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT p.username, COALESCE(ui.full_name, ''), p.email, cc.status, cc.initiated_by, cc.created_at, cc.accepted_at
+		FROM coach_clients cc
+		JOIN person p ON p.id = `+other+`
+		LEFT JOIN user_info ui ON ui.user_id = p.id
+		WHERE `+column+` = ?
+		ORDER BY cc.status, p.username`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []RosterEntry{}
+	for rows.Next() {
+		var e RosterEntry
+		var acceptedAt sql.NullTime
+		if err := rows.Scan(&e.Username, &e.FullName, &e.Email, &e.Status, &e.InitiatedBy, &e.CreatedAt, &acceptedAt); err != nil {
+			return nil, err
+		}
+		if acceptedAt.Valid {
+			e.AcceptedAt = &acceptedAt.Time
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// IsClient reports whether the member is on the coach's active roster
+func (s *SQLStore) IsClient(coachID, memberID int64) (bool, error) {
+	var n int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM coach_clients WHERE coach_id = ? AND member_id = ? AND status = ?",
+		coachID, memberID, LinkActive).Scan(&n)
+	return n > 0, err
+}
+
+func FindPerson(usernameOrEmail string) (*Person, error) {
+	return current.FindPerson(usernameOrEmail)
+}
+
+func LinkCoach(coachID, memberID int64, by string) (string, error) {
+	return current.LinkCoach(coachID, memberID, by)
+}
+
+func AcceptLink(coachID, memberID int64, by string) error {
+	return current.AcceptLink(coachID, memberID, by)
+}
+
+func RemoveLink(coachID, memberID int64) error {
+	return current.RemoveLink(coachID, memberID)
+}
+
+func ListClients(coachID int64) ([]RosterEntry, error) {
+	return current.ListClients(coachID)
+}
+
+func ListCoaches(memberID int64) ([]RosterEntry, error) {
+	return current.ListCoaches(memberID)
+}
+
+func IsClient(coachID, memberID int64) (bool, error) {
+	return current.IsClient(coachID, memberID)
+}
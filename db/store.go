@@ -0,0 +1,215 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fitnesscoach/config"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	_ "modernc.org/sqlite"
+)
+
+// Store is the persistence layer used by the package-level functions.
+// Handlers keep calling db.CreateUser and friends; tests swap the backend
+// with Use(NewMemoryStore()).
+type Store interface {
+	// Users
+	CreateUser(username, email, password, role string) (int64, error)
+	ValidateUser(username, password string) (bool, string, error)
+	GetUserIDByUsername(username string) (int64, error)
+
+	// Profiles
+	InsertUserInfo(userID int64, fullName string, age int, gender string, height, weight float64) error
+	SaveUserInfoByID(userID int64, fullName string, age int, gender string, height, weight float64) error
+	SaveUserInfo(username string, fullName string, age int, gender string, height, weight float64) error
+	GetUserInfoByUsername(username string) (*UserInfo, error)
+	GetAllUserInfo() ([]UserInfo, error)
+	GetClientInfo(coachID int64) ([]UserInfo, error)
+	SetFitnessGoal(userID int64, goal string) error
+
+	// Progress
+	SaveOrUpdateProgress(userID int64, date string, workout, meals bool) error
+
+	// Messages
+	SendMessage(senderID, receiverID int64, content string) (int64, time.Time, error)
+	GetMessagesBetweenUsers(senderID, receiverID int64, page HistoryPage) ([]Message, bool, error)
+
+	MeasurementStore
+	WorkoutStore
+	CardioStore
+	HabitStore
+	ConversationStore
+	ProgramStore
+	RosterStore
+	MemberStore
+	NoteStore
+	ReceiptStore
+	ExerciseStore
+	NutritionStore
+	WaterStore
+	MetricsStore
+	GoalStore
+
+	Close() error
+}
+
+var current Store
+
+// Use replaces the store behind the package-level functions
+func Use(s Store) {
+	current = s
+}
+
+// InitDB opens the configured store and makes it the current one
+func InitDB(cfg config.DatabaseConfig) error {
+	s, err := Open(cfg)
+	if err != nil {
+		return err
+	}
+	current = s
+	if cfg.AutoMigrate {
+		return s.MigrateUp()
+	}
+	return nil
+}
+
+// Open connects to the backend selected by cfg.Driver
+func Open(cfg config.DatabaseConfig) (*SQLStore, error) {
+	switch cfg.Driver {
+	case "mysql":
+		return NewMySQLStore(cfg)
+	case "sqlite":
+		return NewSQLiteStore(cfg.DSN)
+	case "memory":
+		return NewMemoryStore()
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+}
+
+// NewMySQLStore connects to MySQL using the DSN and pool settings in cfg
+func NewMySQLStore(cfg config.DatabaseConfig) (*SQLStore, error) {
+	// parseTime lets DATETIME columns scan straight into time.Time
+	dsn, err := mysql.ParseDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	dsn.ParseTime = true
+	// Report matched rather than changed rows so no-op updates aren't "not found"
+	dsn.ClientFoundRows = true
+
+	conn, err := sql.Open("mysql", dsn.FormatDSN())
+	if err != nil {
+		return nil, err
+	}
+	conn.SetMaxOpenConns(cfg.MaxOpenConns)
+	conn.SetMaxIdleConns(cfg.MaxIdleConns)
+	conn.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &SQLStore{db: conn, dialect: dialectMySQL}, nil
+}
+
+// NewSQLiteStore opens (or creates) the SQLite database file at path
+func NewSQLiteStore(path string) (*SQLStore, error) {
+	if path == "" {
+		return nil, errors.New("sqlite: database path is required")
+	}
+	return openSQLite("file:" + path)
+}
+
+var memoryStores atomic.Int64
+
+// NewMemoryStore returns a store backed by a private in-memory SQLite
+// database with every migration already applied. Nothing touches disk, so
+// it suits tests and offline development.
+func NewMemoryStore() (*SQLStore, error) {
+	name := fmt.Sprintf("file:memdb%d?mode=memory", memoryStores.Add(1))
+	s, err := openSQLite(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.MigrateUp(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func openSQLite(dsn string) (*SQLStore, error) {
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	conn, err := sql.Open("sqlite", dsn+sep+"_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection also keeps an
+	// in-memory database alive for the lifetime of the store.
+	conn.SetMaxOpenConns(1)
+	conn.SetConnMaxLifetime(0)
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &SQLStore{db: conn, dialect: dialectSQLite}, nil
+}
+
+func CreateUser(username, email, password, role string) (int64, error) {
+	return current.CreateUser(username, email, password, role)
+}
+
+func InsertUserInfo(userID int64, fullName string, age int, gender string, height, weight float64) error {
+	return current.InsertUserInfo(userID, fullName, age, gender, height, weight)
+}
+
+func SaveUserInfoByID(userID int64, fullName string, age int, gender string, height, weight float64) error {
+	return current.SaveUserInfoByID(userID, fullName, age, gender, height, weight)
+}
+
+func SaveUserInfo(username string, fullName string, age int, gender string, height, weight float64) error {
+	return current.SaveUserInfo(username, fullName, age, gender, height, weight)
+}
+
+func ValidateUser(username, password string) (bool, string, error) {
+	return current.ValidateUser(username, password)
+}
+
+func GetUserInfoByUsername(username string) (*UserInfo, error) {
+	return current.GetUserInfoByUsername(username)
+}
+
+func GetAllUserInfo() ([]UserInfo, error) {
+	return current.GetAllUserInfo()
+}
+
+func GetClientInfo(coachID int64) ([]UserInfo, error) {
+	return current.GetClientInfo(coachID)
+}
+
+func SetFitnessGoal(userID int64, goal string) error {
+	return current.SetFitnessGoal(userID, goal)
+}
+
+func SaveOrUpdateProgress(userID int64, date string, workout, meals bool) error {
+	return current.SaveOrUpdateProgress(userID, date, workout, meals)
+}
+
+func GetUserIDByUsername(username string) (int64, error) {
+	return current.GetUserIDByUsername(username)
+}
+
+func SendMessage(senderID, receiverID int64, content string) (int64, time.Time, error) {
+	return current.SendMessage(senderID, receiverID, content)
+}
+
+func GetMessagesBetweenUsers(senderID, receiverID int64, page HistoryPage) ([]Message, bool, error) {
+	return current.GetMessagesBetweenUsers(senderID, receiverID, page)
+}
@@ -0,0 +1,336 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// useMemoryStore makes a fresh in-memory store current for the test
+func useMemoryStore(t *testing.T) *SQLStore {
+	t.Helper()
+	s, err := NewMemoryStore()
+	if err != nil {
+		t.Fatalf("NewMemoryStore: %v", err)
+	}
+	previous := current
+	Use(s)
+	t.Cleanup(func() {
+		Use(previous)
+		s.Close()
+	})
+	return s
+}
+
+func mustCreateUser(t *testing.T, username, role string) int64 {
+	t.Helper()
+	id, err := CreateUser(username, username+"@example.com", "secret", role)
+	if err != nil {
+		t.Fatalf("CreateUser(%s): %v", username, err)
+	}
+	return id
+}
+
+func TestUsers(t *testing.T) {
+	useMemoryStore(t)
+	mustCreateUser(t, "al", "member")
+
+	if _, err := CreateUser("al", "other@example.com", "secret", "member"); err == nil {
+		t.Error("CreateUser accepted a duplicate username")
+	}
+
+	tests := []struct {
+		username, password string
+		ok                 bool
+		role               string
+	}{
+		{"al", "secret", true, "member"},
+		{"al", "wrong", false, ""},
+		{"nobody", "secret", false, ""},
+	}
+	for _, tt := range tests {
+		ok, role, err := ValidateUser(tt.username, tt.password)
+		if err != nil || ok != tt.ok || role != tt.role {
+			t.Errorf("ValidateUser(%s, %s) = %v, %q, %v; want %v, %q", tt.username, tt.password, ok, role, err, tt.ok, tt.role)
+		}
+	}
+
+	id, err := GetUserIDByUsername("al")
+	if err != nil || id == 0 {
+		t.Errorf("GetUserIDByUsername = %d, %v", id, err)
+	}
+	if _, err := GetUserIDByUsername("nobody"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserIDByUsername(nobody) err = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	useMemoryStore(t)
+	id := mustCreateUser(t, "al", "member")
+
+	if err := SaveUserInfoByID(id, "Al", 30, "Male", 180, 80); err != nil {
+		t.Fatalf("SaveUserInfoByID: %v", err)
+	}
+	if err := SaveUserInfo("al", "Al Smith", 31, "Male", 180, 78); err != nil {
+		t.Fatalf("SaveUserInfo: %v", err)
+	}
+	if err := SetFitnessGoal(id, "Run a 10k"); err != nil {
+		t.Fatalf("SetFitnessGoal: %v", err)
+	}
+
+	got, err := GetUserInfoByUsername("al")
+	if err != nil {
+		t.Fatalf("GetUserInfoByUsername: %v", err)
+	}
+	want := UserInfo{Username: "al", FullName: "Al Smith", Age: 31, Gender: "Male", Height: 180, Weight: 78, Goal: "Run a 10k"}
+	if *got != want {
+		t.Errorf("profile = %+v, want %+v", *got, want)
+	}
+	if _, err := GetUserInfoByUsername("nobody"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserInfoByUsername(nobody) err = %v, want sql.ErrNoRows", err)
+	}
+
+	all, err := GetAllUserInfo()
+	if err != nil || len(all) != 1 || all[0] != want {
+		t.Errorf("GetAllUserInfo = %+v, %v", all, err)
+	}
+
+	// Each saved weight is kept as a measurement and feeds the metrics
+	history, err := ListMeasurements(id, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("ListMeasurements: %v", err)
+	}
+	var weights []float64
+	for _, m := range history {
+		weights = append(weights, *m.WeightKG)
+	}
+	if !slices.Equal(weights, []float64{80, 78}) {
+		t.Errorf("weight history = %v, want [80 78]", weights)
+	}
+	m, err := GetMetrics(id)
+	if err != nil {
+		t.Fatalf("GetMetrics: %v", err)
+	}
+	if m.BMI != 24.1 {
+		t.Errorf("BMI = %v, want 24.1", m.BMI)
+	}
+
+	if err := SetFitnessGoal(id+1, "Nothing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SetFitnessGoal(unknown) err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMealsHabit(t *testing.T) {
+	useMemoryStore(t)
+	id := mustCreateUser(t, "al", "member")
+	const manual, logged = "2026-10-01", "2026-10-02"
+
+	mealsDone := func(date string) bool {
+		t.Helper()
+		checkIns, err := ListCheckIns(id, date, date)
+		if err != nil {
+			t.Fatalf("ListCheckIns: %v", err)
+		}
+		return slices.Contains(checkIns, CheckIn{Habit: HabitMeals, Date: date})
+	}
+
+	// Without food entries the habit is ticked by hand
+	if err := SaveOrUpdateProgress(id, manual, false, true); err != nil || !mealsDone(manual) {
+		t.Errorf("SaveOrUpdateProgress: err %v, meals done %v", err, mealsDone(manual))
+	}
+	if err := SetCheckIn(id, HabitMeals, manual, false); err != nil || mealsDone(manual) {
+		t.Errorf("SetCheckIn(false): err %v, meals done %v", err, mealsDone(manual))
+	}
+
+	// With entries it follows them
+	entries, err := AddFoodEntries(id, logged, MealLunch, []MealItem{{Name: "Apple", Servings: 1}})
+	if err != nil || !mealsDone(logged) {
+		t.Fatalf("AddFoodEntries: err %v, meals done %v", err, mealsDone(logged))
+	}
+	if err := SaveOrUpdateProgress(id, logged, true, false); err != nil || !mealsDone(logged) {
+		t.Errorf("SaveOrUpdateProgress cleared meals on a day with food: err %v, meals done %v", err, mealsDone(logged))
+	}
+	if err := SetCheckIn(id, HabitMeals, logged, false); !errors.Is(err, ErrDerivedHabit) || !mealsDone(logged) {
+		t.Errorf("SetCheckIn(false) on a day with food: err %v, meals done %v", err, mealsDone(logged))
+	}
+	if err := SetCheckIn(id, HabitMeals, logged, true); err != nil {
+		t.Errorf("SetCheckIn(true) on a day with food: %v", err)
+	}
+	if err := DeleteFoodEntry(id, entries[0].ID); err != nil || mealsDone(logged) {
+		t.Errorf("DeleteFoodEntry: err %v, meals done %v", err, mealsDone(logged))
+	}
+}
+
+func TestMessagePaging(t *testing.T) {
+	useMemoryStore(t)
+	al := mustCreateUser(t, "al", "member")
+	co := mustCreateUser(t, "co", "coach")
+	other := mustCreateUser(t, "other", "member")
+
+	// Five messages back and forth, with another chat in between
+	var ids []int64
+	sentAt := make(map[int64]time.Time)
+	for i := range 5 {
+		from, to := al, co
+		if i%2 == 1 {
+			from, to = co, al
+		}
+		id, at, err := SendMessage(from, to, "message")
+		if err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+		ids = append(ids, id)
+		sentAt[id] = at
+		if _, _, err := SendMessage(al, other, "elsewhere"); err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		page HistoryPage
+		want []int64
+		more bool
+	}{
+		{"newest", HistoryPage{Limit: 2}, ids[3:5], true},
+		{"before", HistoryPage{Before: ids[3], Limit: 2}, ids[1:3], true},
+		{"before the start", HistoryPage{Before: ids[1], Limit: 2}, ids[0:1], false},
+		{"after", HistoryPage{After: ids[0], Limit: 2}, ids[1:3], true},
+		{"after to the end", HistoryPage{After: ids[2], Limit: 2}, ids[3:5], false},
+		{"whole chat", HistoryPage{Limit: 50}, ids, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Either side of the chat reads the same history
+			for _, pair := range [][2]int64{{al, co}, {co, al}} {
+				messages, more, err := GetMessagesBetweenUsers(pair[0], pair[1], tt.page)
+				if err != nil {
+					t.Fatalf("GetMessagesBetweenUsers: %v", err)
+				}
+				var got []int64
+				for _, m := range messages {
+					got = append(got, m.ID)
+				}
+				if !slices.Equal(got, tt.want) || more != tt.more {
+					t.Errorf("page %+v = %v, %v; want %v, %v", tt.page, got, more, tt.want, tt.more)
+				}
+			}
+		})
+	}
+
+	messages, _, err := GetMessagesBetweenUsers(al, co, HistoryPage{Limit: 50})
+	if err != nil {
+		t.Fatalf("GetMessagesBetweenUsers: %v", err)
+	}
+	for i, m := range messages {
+		wantSender := "al"
+		if i%2 == 1 {
+			wantSender = "co"
+		}
+		if m.Sender != wantSender {
+			t.Errorf("message %d sender = %q, want %q", m.ID, m.Sender, wantSender)
+		}
+		// Live delivery broadcasts the timestamp SendMessage returned
+		if !m.Time.Equal(sentAt[m.ID]) {
+			t.Errorf("message %d time = %v, SendMessage returned %v", m.ID, m.Time, sentAt[m.ID])
+		}
+	}
+}
+
+// schema returns the definition of every table and index
+func schema(t *testing.T, s *SQLStore) []string {
+	t.Helper()
+	rows, err := s.db.Query(`SELECT type || ' ' || name || ': ' || COALESCE(sql, '') FROM sqlite_master
+		WHERE name NOT LIKE 'sqlite_%' ORDER BY type, name`)
+	if err != nil {
+		t.Fatalf("reading schema: %v", err)
+	}
+	defer rows.Close()
+	var objects []string
+	for rows.Next() {
+		var object string
+		if err := rows.Scan(&object); err != nil {
+			t.Fatalf("reading schema: %v", err)
+		}
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+func TestMigrationsRoundTrip(t *testing.T) {
+	s := useMemoryStore(t)
+	migrations, err := s.Migrations()
+	if err != nil {
+		t.Fatalf("Migrations: %v", err)
+	}
+	latest := migrations[len(migrations)-1].Version
+	full := schema(t, s)
+
+	// Step down one migration at a time, checking each rollback lets its
+	// migration apply again cleanly
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		before := schema(t, s)
+		if err := s.MigrateDown(); err != nil {
+			t.Fatalf("rolling back %04d_%s: %v", m.Version, m.Name, err)
+		}
+		if err := s.MigrateTo(m.Version); err != nil {
+			t.Fatalf("reapplying %04d_%s: %v", m.Version, m.Name, err)
+		}
+		if after := schema(t, s); !slices.Equal(before, after) {
+			t.Errorf("%04d_%s: schema differs after down and up:\nbefore %q\nafter  %q", m.Version, m.Name, before, after)
+		}
+		if err := s.MigrateDown(); err != nil {
+			t.Fatalf("rolling back %04d_%s: %v", m.Version, m.Name, err)
+		}
+	}
+
+	// Everything rolled back leaves only the bookkeeping table
+	if version, err := s.SchemaVersion(); err != nil || version != 0 {
+		t.Errorf("SchemaVersion after rolling back = %d, %v; want 0", version, err)
+	}
+	if objects := schema(t, s); len(objects) != 1 {
+		t.Errorf("objects left after rolling back: %q", objects)
+	}
+
+	if err := s.MigrateUp(); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	if version, err := s.SchemaVersion(); err != nil || version != latest {
+		t.Errorf("SchemaVersion = %d, %v; want %d", version, err, latest)
+	}
+	if after := schema(t, s); !slices.Equal(full, after) {
+		t.Errorf("schema differs after a full round trip:\nbefore %q\nafter  %q", full, after)
+	}
+	states, err := s.MigrationStatus()
+	if err != nil {
+		t.Fatalf("MigrationStatus: %v", err)
+	}
+	for _, state := range states {
+		if !state.Applied {
+			t.Errorf("%04d_%s not applied", state.Version, state.Name)
+		}
+	}
+}
+
+func TestMigrationsMatchAcrossDialects(t *testing.T) {
+	list := func(d dialect) []string {
+		migrations, err := (&SQLStore{dialect: d}).Migrations()
+		if err != nil {
+			t.Fatalf("Migrations(%s): %v", d, err)
+		}
+		var names []string
+		for _, m := range migrations {
+			if m.Up == "" || m.Down == "" {
+				t.Errorf("%s %04d_%s is missing a script", d, m.Version, m.Name)
+			}
+			names = append(names, m.Name)
+		}
+		return names
+	}
+	if mysql, sqlite := list(dialectMySQL), list(dialectSQLite); !slices.Equal(mysql, sqlite) {
+		t.Errorf("migrations differ:\nmysql  %v\nsqlite %v", mysql, sqlite)
+	}
+}
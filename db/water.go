@@ -0,0 +1,314 @@
+package db
+
+import (
+	"database/sql"
+	"math"
+	"time"
+)
+
+// Water targets: 35 ml per kg of body weight, rounded to 50 ml, or 2 litres
+// for members who haven't given their weight
+const (
+	WaterMLPerKG          = 35
+	DefaultWaterTargetML  = 2000
+	WaterTargetFromWeight = "weight"
+	WaterTargetCustom     = "custom"
+	WaterTargetDefault    = "default"
+)
+
+// DefaultWaterQuickAdds are the quick-add amounts (ml) until a member
+// chooses their own
+var DefaultWaterQuickAdds = []int{250, 500, 750}
+
+// WaterEntry is a drink a member logged
+type WaterEntry struct {
+	ID       int64     `json:"id"`
+	AmountML int       `json:"amountMl"`
+	LoggedAt time.Time `json:"loggedAt"`
+}
+
+// WaterDay is a member's water on a day (YYYY-MM-DD). Hourly holds the ml
+// drunk in each hour of the day, local time. Done is whether the total
+// reached the target.
+type WaterDay struct {
+	Date         string       `json:"date"`
+	Entries      []WaterEntry `json:"entries"`
+	TotalML      int          `json:"totalMl"`
+	TargetML     int          `json:"targetMl"`
+	TargetSource string       `json:"targetSource"`
+	Hourly       [24]int      `json:"hourly"`
+	Done         bool         `json:"done"`
+	QuickAdds    []int        `json:"quickAdds"`
+}
+
+// WaterSettings are a member's own water target, nil to follow their
+// weight, and quick-add amounts. WeightTargetML is what their weight
+// suggests and is ignored when saving.
+type WaterSettings struct {
+	TargetML       *int  `json:"targetMl"`
+	WeightTargetML int   `json:"weightTargetMl"`
+	QuickAdds      []int `json:"quickAdds"`
+}
+
+// WaterStore persists water intake. Adding or removing water, or changing
+// the target, keeps the day's water_done habit in step with the target.
+type WaterStore interface {
+	AddWater(userID int64, amountML int, at time.Time) (*WaterEntry, error)
+	DeleteWater(userID, id int64) error
+	GetWaterDay(userID int64, date string) (*WaterDay, error)
+	GetWaterSettings(userID int64) (*WaterSettings, error)
+	SetWaterSettings(userID int64, settings WaterSettings) error
+}
+
+// WaterTargetForWeight is the daily water target for a body weight in kg
+func WaterTargetForWeight(kg float64) int {
+	if kg <= 0 {
+		return DefaultWaterTargetML
+	}
+	return int(math.Round(kg*WaterMLPerKG/50)) * 50
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// waterTarget returns the member's daily target and where it comes from
+func waterTarget(q queryRower, userID int64) (int, string, error) {
+	var target int
+	err := q.QueryRow("SELECT target_ml FROM water_targets WHERE user_id = ?", userID).Scan(&target)
+	if err == nil {
+		return target, WaterTargetCustom, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, "", err
+	}
+
+	var weight sql.NullFloat64
+	err = q.QueryRow("SELECT weight_kg FROM user_info WHERE user_id = ?", userID).Scan(&weight)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, "", err
+	}
+	if !weight.Valid || weight.Float64 <= 0 {
+		return DefaultWaterTargetML, WaterTargetDefault, nil
+	}
+	return WaterTargetForWeight(weight.Float64), WaterTargetFromWeight, nil
+}
+
+// AddWater logs a drink at a time, on that time's local day
+func (s *SQLStore) AddWater(userID int64, amountML int, at time.Time) (*WaterEntry, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	date := at.In(time.Local).Format(dateLayout)
+	result, err := tx.Exec("INSERT INTO water_entries (user_id, date, logged_at, amount_ml) VALUES (?, ?, ?, ?)",
+		userID, date, at.UTC(), amountML)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.syncWaterDone(tx, userID, date); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.refreshGoalsAfter(userID)
+	return &WaterEntry{ID: id, AmountML: amountML, LoggedAt: at.UTC()}, nil
+}
+
+// DeleteWater removes one of the member's drinks
+func (s *SQLStore) DeleteWater(userID, id int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var date time.Time
+	err = tx.QueryRow("SELECT date FROM water_entries WHERE id = ? AND user_id = ?", id, userID).Scan(&date)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM water_entries WHERE id = ?", id); err != nil {
+		return err
+	}
+	if err := s.syncWaterDone(tx, userID, date.Format(dateLayout)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.refreshGoalsAfter(userID)
+	return nil
+}
+
+// syncWaterDone ticks the water habit for a day whose total reaches the
+// target and clears it otherwise
+func (s *SQLStore) syncWaterDone(tx *sql.Tx, userID int64, date string) error {
+	var total int
+	err := tx.QueryRow("SELECT COALESCE(SUM(amount_ml), 0) FROM water_entries WHERE user_id = ? AND date = ?",
+		userID, date).Scan(&total)
+	if err != nil {
+		return err
+	}
+	target, _, err := waterTarget(tx, userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO user_progress (user_id, date, water_done) VALUES (?, ?, ?)
+		`+s.dialect.upsert([]string{"user_id", "date"}, "water_done"), userID, date, total >= target)
+	return err
+}
+
+// GetWaterDay returns the member's drinks, total and target for a day
+func (s *SQLStore) GetWaterDay(userID int64, date string) (*WaterDay, error) {
+	rows, err := s.db.Query(`SELECT id, amount_ml, logged_at FROM water_entries
+		WHERE user_id = ? AND date = ? ORDER BY logged_at, id`, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	day := &WaterDay{Date: date, Entries: []WaterEntry{}}
+	for rows.Next() {
+		var e WaterEntry
+		if err := rows.Scan(&e.ID, &e.AmountML, &e.LoggedAt); err != nil {
+			return nil, err
+		}
+		day.Entries = append(day.Entries, e)
+		day.TotalML += e.AmountML
+		day.Hourly[e.LoggedAt.In(time.Local).Hour()] += e.AmountML
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if day.TargetML, day.TargetSource, err = waterTarget(s.db, userID); err != nil {
+		return nil, err
+	}
+	day.Done = day.TotalML >= day.TargetML
+	if day.QuickAdds, err = s.waterQuickAdds(userID); err != nil {
+		return nil, err
+	}
+	return day, nil
+}
+
+func (s *SQLStore) waterQuickAdds(userID int64) ([]int, error) {
+	rows, err := s.db.Query("SELECT amount_ml FROM water_quick_adds WHERE user_id = ? ORDER BY amount_ml", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var amounts []int
+	for rows.Next() {
+		var ml int
+		if err := rows.Scan(&ml); err != nil {
+			return nil, err
+		}
+		amounts = append(amounts, ml)
+	}
+	if len(amounts) == 0 {
+		amounts = append([]int{}, DefaultWaterQuickAdds...)
+	}
+	return amounts, rows.Err()
+}
+
+// GetWaterSettings returns the member's own target, if any, what their
+// weight suggests and their quick-add amounts
+func (s *SQLStore) GetWaterSettings(userID int64) (*WaterSettings, error) {
+	settings := &WaterSettings{}
+	target, source, err := waterTarget(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if source == WaterTargetCustom {
+		settings.TargetML = &target
+	}
+
+	weight, err := s.profileWeight(userID)
+	if err != nil {
+		return nil, err
+	}
+	settings.WeightTargetML = WaterTargetForWeight(weight.Float64)
+	if settings.QuickAdds, err = s.waterQuickAdds(userID); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetWaterSettings replaces the member's own target and quick-add amounts
+// and rechecks today against the target. An empty list restores the
+// default quick adds.
+func (s *SQLStore) SetWaterSettings(userID int64, settings WaterSettings) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if settings.TargetML == nil {
+		_, err = tx.Exec("DELETE FROM water_targets WHERE user_id = ?", userID)
+	} else {
+		_, err = tx.Exec("INSERT INTO water_targets (user_id, target_ml) VALUES (?, ?) "+
+			s.dialect.upsert([]string{"user_id"}, "target_ml"), userID, *settings.TargetML)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM water_quick_adds WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for _, ml := range settings.QuickAdds {
+		if _, err := tx.Exec("INSERT INTO water_quick_adds (user_id, amount_ml) VALUES (?, ?)", userID, ml); err != nil {
+			return err
+		}
+	}
+
+	// A day without water logged keeps whatever the member ticked by hand
+	var logged int
+	err = tx.QueryRow("SELECT COUNT(*) FROM water_entries WHERE user_id = ? AND date = ?", userID, today()).Scan(&logged)
+	if err != nil {
+		return err
+	}
+	if logged > 0 {
+		if err := s.syncWaterDone(tx, userID, today()); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.refreshGoalsAfter(userID)
+	return nil
+}
+
+func AddWater(userID int64, amountML int, at time.Time) (*WaterEntry, error) {
+	return current.AddWater(userID, amountML, at)
+}
+
+func DeleteWater(userID, id int64) error {
+	return current.DeleteWater(userID, id)
+}
+
+func GetWaterDay(userID int64, date string) (*WaterDay, error) {
+	return current.GetWaterDay(userID, date)
+}
+
+func GetWaterSettings(userID int64) (*WaterSettings, error) {
+	return current.GetWaterSettings(userID)
+}
+
+func SetWaterSettings(userID int64, settings WaterSettings) error {
+	return current.SetWaterSettings(userID, settings)
+}
@@ -0,0 +1,359 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// WorkoutSession is one strength training session: exercises, each with
+// the sets performed in order.
+type WorkoutSession struct {
+	ID         int64             `json:"id"`
+	Name       string            `json:"name"`
+	StartedAt  time.Time         `json:"startedAt"`
+	FinishedAt *time.Time        `json:"finishedAt,omitempty"`
+	Notes      string            `json:"notes"`
+	Exercises  []WorkoutExercise `json:"exercises"`
+}
+
+// WorkoutExercise groups the sets done for one exercise in a session
+type WorkoutExercise struct {
+	ID       int64        `json:"id"`
+	Name     string       `json:"name"`
+	Position int          `json:"position"`
+	Sets     []WorkoutSet `json:"sets"`
+}
+
+// WorkoutSet is a single set: reps at a load, with optional effort (RPE)
+// and the rest taken before it.
+type WorkoutSet struct {
+	ID          int64     `json:"id"`
+	SetNumber   int       `json:"setNumber"`
+	Reps        int       `json:"reps"`
+	LoadKG      float64   `json:"loadKg"`
+	RPE         *float64  `json:"rpe,omitempty"`
+	RestSeconds *int      `json:"restSeconds,omitempty"`
+	LoggedAt    time.Time `json:"loggedAt"`
+}
+
+// WorkoutSummary is a past session with totals, used for history lists
+type WorkoutSummary struct {
+	ID            int64      `json:"id"`
+	Name          string     `json:"name"`
+	StartedAt     time.Time  `json:"startedAt"`
+	FinishedAt    *time.Time `json:"finishedAt,omitempty"`
+	ExerciseCount int        `json:"exerciseCount"`
+	SetCount      int        `json:"setCount"`
+	VolumeKG      float64    `json:"volumeKg"`
+}
+
+// EstimatedMax is the best estimated one-rep max for an exercise in one
+// session
+type EstimatedMax struct {
+	StartedAt time.Time `json:"startedAt"`
+	KG        float64   `json:"kg"`
+}
+
+// WorkoutStore persists strength training sessions
+type WorkoutStore interface {
+	StartWorkout(userID int64, name string) (*WorkoutSession, error)
+	ActiveWorkout(userID int64) (*WorkoutSession, error)
+	LogSet(userID, sessionID int64, exercise string, set WorkoutSet) (*WorkoutSet, error)
+	DeleteSet(userID, setID int64) error
+	FinishWorkout(userID, sessionID int64, notes string) error
+	GetWorkout(userID, sessionID int64) (*WorkoutSession, error)
+	ListWorkouts(userID int64, limit int) ([]WorkoutSummary, error)
+	EstimatedMaxes(userID int64, exercise string, from time.Time) ([]EstimatedMax, error)
+}
+
+// ErrWorkoutFinished is returned when logging into a finished session
+var ErrWorkoutFinished = errors.New("workout already finished")
+
+// StartWorkout opens a new session, or returns the one still in progress
+func (s *SQLStore) StartWorkout(userID int64, name string) (*WorkoutSession, error) {
+	active, err := s.ActiveWorkout(userID)
+	if err != nil || active != nil {
+		return active, err
+	}
+
+	startedAt := time.Now().UTC()
+	result, err := s.db.Exec(`INSERT INTO workout_sessions (user_id, name, started_at, notes) VALUES (?, ?, ?, '')`,
+		userID, name, startedAt)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &WorkoutSession{ID: id, Name: name, StartedAt: startedAt, Exercises: []WorkoutExercise{}}, nil
+}
+
+// ActiveWorkout returns the user's unfinished session, or nil
+func (s *SQLStore) ActiveWorkout(userID int64) (*WorkoutSession, error) {
+	var id int64
+	err := s.db.QueryRow(`SELECT id FROM workout_sessions WHERE user_id = ? AND finished_at IS NULL
+		ORDER BY started_at DESC LIMIT 1`, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetWorkout(userID, id)
+}
+
+// LogSet appends a set for exercise to an open session, adding the
+// exercise to the session the first time it is used.
+func (s *SQLStore) LogSet(userID, sessionID int64, exercise string, set WorkoutSet) (*WorkoutSet, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var finishedAt sql.NullTime
+	err = tx.QueryRow("SELECT finished_at FROM workout_sessions WHERE id = ? AND user_id = ?", sessionID, userID).Scan(&finishedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if finishedAt.Valid {
+		return nil, ErrWorkoutFinished
+	}
+
+	var exerciseID int64
+	err = tx.QueryRow("SELECT id FROM workout_exercises WHERE session_id = ? AND exercise_name = ?", sessionID, exercise).Scan(&exerciseID)
+	if err == sql.ErrNoRows {
+		result, err := tx.Exec(`INSERT INTO workout_exercises (session_id, exercise_name, position)
+			SELECT ?, ?, COALESCE(MAX(position), 0) + 1 FROM workout_exercises WHERE session_id = ?`,
+			sessionID, exercise, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if exerciseID, err = result.LastInsertId(); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow("SELECT COALESCE(MAX(set_number), 0) + 1 FROM workout_sets WHERE exercise_id = ?", exerciseID).Scan(&set.SetNumber)
+	if err != nil {
+		return nil, err
+	}
+	set.LoggedAt = time.Now().UTC()
+	result, err := tx.Exec(`INSERT INTO workout_sets (exercise_id, set_number, reps, load_kg, rpe, rest_seconds, logged_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		exerciseID, set.SetNumber, set.Reps, set.LoadKG, set.RPE, set.RestSeconds, set.LoggedAt)
+	if err != nil {
+		return nil, err
+	}
+	if set.ID, err = result.LastInsertId(); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.refreshGoalsAfter(userID)
+	return &set, nil
+}
+
+// DeleteSet removes a mistakenly logged set from one of the user's sessions
+func (s *SQLStore) DeleteSet(userID, setID int64) error {
+	result, err := s.db.Exec(`DELETE FROM workout_sets WHERE id = ? AND exercise_id IN (
+			SELECT e.id FROM workout_exercises e
+			JOIN workout_sessions ws ON ws.id = e.session_id
+			WHERE ws.user_id = ?)`, setID, userID)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(result); err != nil {
+		return err
+	}
+	s.refreshGoalsAfter(userID)
+	return nil
+}
+
+// FinishWorkout closes an open session and stores the member's notes
+func (s *SQLStore) FinishWorkout(userID, sessionID int64, notes string) error {
+	result, err := s.db.Exec(`UPDATE workout_sessions SET finished_at = ?, notes = ?
+		WHERE id = ? AND user_id = ? AND finished_at IS NULL`,
+		time.Now().UTC(), notes, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	return expectRow(result)
+}
+
+// GetWorkout loads a session with all of its exercises and sets
+func (s *SQLStore) GetWorkout(userID, sessionID int64) (*WorkoutSession, error) {
+	session := WorkoutSession{ID: sessionID, Exercises: []WorkoutExercise{}}
+	var finishedAt sql.NullTime
+	err := s.db.QueryRow(`SELECT name, started_at, finished_at, notes FROM workout_sessions WHERE id = ? AND user_id = ?`,
+		sessionID, userID).Scan(&session.Name, &session.StartedAt, &finishedAt, &session.Notes)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if finishedAt.Valid {
+		session.FinishedAt = &finishedAt.Time
+	}
+
+	rows, err := s.db.Query(`
+		SELECT e.id, e.exercise_name, e.position, st.id, st.set_number, st.reps, st.load_kg, st.rpe, st.rest_seconds, st.logged_at
+		FROM workout_exercises e
+		LEFT JOIN workout_sets st ON st.exercise_id = e.id
+		WHERE e.session_id = ?
+		ORDER BY e.position, st.set_number`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ex WorkoutExercise
+		var setID, setNumber, reps, rest sql.NullInt64
+		var load, rpe sql.NullFloat64
+		var loggedAt sql.NullTime
+		err := rows.Scan(&ex.ID, &ex.Name, &ex.Position, &setID, &setNumber, &reps, &load, &rpe, &rest, &loggedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		n := len(session.Exercises)
+		if n == 0 || session.Exercises[n-1].ID != ex.ID {
+			ex.Sets = []WorkoutSet{}
+			session.Exercises = append(session.Exercises, ex)
+			n++
+		}
+		if !setID.Valid {
+			continue
+		}
+		set := WorkoutSet{
+			ID:        setID.Int64,
+			SetNumber: int(setNumber.Int64),
+			Reps:      int(reps.Int64),
+			LoadKG:    load.Float64,
+			RPE:       nullFloat(rpe),
+			LoggedAt:  loggedAt.Time,
+		}
+		if rest.Valid {
+			seconds := int(rest.Int64)
+			set.RestSeconds = &seconds
+		}
+		session.Exercises[n-1].Sets = append(session.Exercises[n-1].Sets, set)
+	}
+	return &session, rows.Err()
+}
+
+// ListWorkouts returns the user's most recent sessions with set totals
+func (s *SQLStore) ListWorkouts(userID int64, limit int) ([]WorkoutSummary, error) {
+	rows, err := s.db.Query(`
+		SELECT ws.id, ws.name, ws.started_at, ws.finished_at,
+			COUNT(DISTINCT e.id), COUNT(st.id), COALESCE(SUM(st.reps * st.load_kg), 0)
+		FROM workout_sessions ws
+		LEFT JOIN workout_exercises e ON e.session_id = ws.id
+		LEFT JOIN workout_sets st ON st.exercise_id = e.id
+		WHERE ws.user_id = ?
+		GROUP BY ws.id, ws.name, ws.started_at, ws.finished_at
+		ORDER BY ws.started_at DESC
+		LIMIT ?`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []WorkoutSummary{}
+	for rows.Next() {
+		var w WorkoutSummary
+		var finishedAt sql.NullTime
+		err := rows.Scan(&w.ID, &w.Name, &w.StartedAt, &finishedAt, &w.ExerciseCount, &w.SetCount, &w.VolumeKG)
+		if err != nil {
+			return nil, err
+		}
+		if finishedAt.Valid {
+			w.FinishedAt = &finishedAt.Time
+		}
+		summaries = append(summaries, w)
+	}
+	return summaries, rows.Err()
+}
+
+// EstimatedMaxes returns the best estimated one-rep max for the exercise,
+// matched case-insensitively, in each session started since from, oldest
+// first. Sets are estimated with the Epley formula; singles count as is.
+func (s *SQLStore) EstimatedMaxes(userID int64, exercise string, from time.Time) ([]EstimatedMax, error) {
+	rows, err := s.db.Query(`
+		SELECT ws.id, ws.started_at, st.reps, st.load_kg
+		FROM workout_sessions ws
+		JOIN workout_exercises e ON e.session_id = ws.id
+		JOIN workout_sets st ON st.exercise_id = e.id
+		WHERE ws.user_id = ? AND LOWER(e.exercise_name) = LOWER(?) AND ws.started_at >= ?
+			AND st.reps > 0 AND st.load_kg > 0
+		ORDER BY ws.started_at, ws.id`, userID, exercise, from.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	maxes := []EstimatedMax{}
+	var lastSession int64
+	for rows.Next() {
+		var sessionID int64
+		var startedAt time.Time
+		var reps int
+		var load float64
+		if err := rows.Scan(&sessionID, &startedAt, &reps, &load); err != nil {
+			return nil, err
+		}
+		estimate := load
+		if reps > 1 {
+			estimate = round1(load * (1 + float64(reps)/30))
+		}
+		if n := len(maxes); n > 0 && sessionID == lastSession {
+			maxes[n-1].KG = max(maxes[n-1].KG, estimate)
+			continue
+		}
+		lastSession = sessionID
+		maxes = append(maxes, EstimatedMax{StartedAt: startedAt, KG: estimate})
+	}
+	return maxes, rows.Err()
+}
+
+func StartWorkout(userID int64, name string) (*WorkoutSession, error) {
+	return current.StartWorkout(userID, name)
+}
+
+func ActiveWorkout(userID int64) (*WorkoutSession, error) {
+	return current.ActiveWorkout(userID)
+}
+
+func LogSet(userID, sessionID int64, exercise string, set WorkoutSet) (*WorkoutSet, error) {
+	return current.LogSet(userID, sessionID, exercise, set)
+}
+
+func DeleteSet(userID, setID int64) error {
+	return current.DeleteSet(userID, setID)
+}
+
+func FinishWorkout(userID, sessionID int64, notes string) error {
+	return current.FinishWorkout(userID, sessionID, notes)
+}
+
+func GetWorkout(userID, sessionID int64) (*WorkoutSession, error) {
+	return current.GetWorkout(userID, sessionID)
+}
+
+func ListWorkouts(userID int64, limit int) ([]WorkoutSummary, error) {
+	return current.ListWorkouts(userID, limit)
+}
+
+func EstimatedMaxes(userID int64, exercise string, from time.Time) ([]EstimatedMax, error) {
+	return current.EstimatedMaxes(userID, exercise, from)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"fitnesscoach/db"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// coachContext is the member data the AI context template can use
+type coachContext struct {
+	Today    string
+	Profile  *contextProfile
+	Weight   *contextWeight
+	Workouts []db.WorkoutSummary
+	Cardio   db.CardioWeek
+	Habits   []db.HabitStreak
+}
+
+type contextProfile struct {
+	FullName string
+	Age      int
+	Gender   string
+	HeightCM float64
+	WeightKG float64
+	BMI      float64
+	Goal     string
+}
+
+type contextWeight struct {
+	MovingAverage  float64
+	WeeklyDelta    float64
+	HasWeeklyDelta bool
+}
+
+var contextFuncs = template.FuncMap{
+	"minutes": func(seconds int) int { return seconds / 60 },
+}
+
+// buildCoachContext gathers what the AI coach gets to know about a member.
+// Missing pieces are left out rather than failing the chat.
+func buildCoachContext(user *CurrentUser) (*coachContext, error) {
+	c := &coachContext{Today: time.Now().Format("2006-01-02")}
+
+	info, err := db.GetUserInfoByUsername(user.Username)
+	if err != nil {
+		return nil, err
+	}
+	c.Profile = &contextProfile{
+		FullName: info.FullName,
+		Age:      info.Age,
+		Gender:   info.Gender,
+		HeightCM: info.Height,
+		WeightKG: info.Weight,
+		Goal:     info.Goal,
+	}
+	if info.Height > 0 && info.Weight > 0 {
+		m := info.Height / 100
+		c.Profile.BMI = info.Weight / (m * m)
+	}
+
+	if trend, err := weightTrend(user.ID); err == nil && trend.MovingAverage != nil {
+		c.Weight = &contextWeight{MovingAverage: *trend.MovingAverage}
+		if trend.WeeklyDelta != nil {
+			c.Weight.WeeklyDelta, c.Weight.HasWeeklyDelta = *trend.WeeklyDelta, true
+		}
+	}
+	if workouts, err := db.ListWorkouts(user.ID, 5); err == nil {
+		c.Workouts = workouts
+	}
+	weekAgo := time.Now().AddDate(0, 0, -7)
+	if sessions, err := db.ListCardioSessions(user.ID, weekAgo, time.Time{}, 0); err == nil {
+		if weeks := db.WeeklyCardioVolume(sessions, time.Now()); len(weeks) > 0 {
+			c.Cardio = weeks[len(weeks)-1]
+		}
+	}
+	if streaks, err := habitStreaks(user.ID); err == nil {
+		for _, s := range streaks {
+			if s.Total > 0 {
+				c.Habits = append(c.Habits, s)
+			}
+		}
+	}
+	return c, nil
+}
+
+// renderCoachContext turns the context into the system prompt using the
+// operator-editable template. The file is parsed on every call so edits
+// apply without a restart.
+func renderCoachContext(c *coachContext) (string, error) {
+	path := appConfig.AI.ContextTemplate
+	tmpl, err := template.New("").Funcs(contextFuncs).ParseFiles(path)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := tmpl.ExecuteTemplate(&b, filepath.Base(path), c); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
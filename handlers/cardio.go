@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"errors"
+	"fitnesscoach/db"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CardioSessionsHandler lists (GET), logs (POST), edits (PUT ?id=) and
+// deletes (DELETE ?id=) cardio sessions. Coaches may list a member's
+// sessions with ?username=.
+func CardioSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	if r.Method != http.MethodGet && user.Role != RoleMember {
+		writeJSONError(w, http.StatusForbidden, "only members can log cardio")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		userID, ok := memberID(w, r)
+		if !ok {
+			return
+		}
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit <= 0 || limit > 200 {
+			limit = 50
+		}
+		sessions, err := db.ListCardioSessions(userID, time.Time{}, time.Time{}, limit)
+		if err != nil {
+			log.Printf("❌ Failed to list cardio sessions: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load cardio sessions")
+			return
+		}
+		writeJSON(w, http.StatusOK, sessions)
+
+	case http.MethodPost:
+		var c db.CardioSession
+		if err := decodeCardio(w, r, &c); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		id, err := db.AddCardioSession(user.ID, c)
+		if err != nil {
+			log.Printf("❌ Failed to save cardio session: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to save cardio session")
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]int64{"id": id})
+
+	case http.MethodPut:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		var c db.CardioSession
+		if err := decodeCardio(w, r, &c); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if c.StartedAt.IsZero() {
+			writeJSONError(w, http.StatusBadRequest, "startedAt is required")
+			return
+		}
+		c.ID = id
+		err := db.UpdateCardioSession(user.ID, c)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "cardio session not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to update cardio session: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to update cardio session")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int64{"id": id})
+
+	case http.MethodDelete:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.DeleteCardioSession(user.ID, id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "cardio session not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to delete cardio session: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to delete cardio session")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// CardioBestsHandler returns personal bests per activity
+func CardioBestsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	userID, ok := memberID(w, r)
+	if !ok {
+		return
+	}
+
+	bests, err := db.CardioBests(userID)
+	if err != nil {
+		log.Printf("❌ Failed to load cardio bests: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load personal bests")
+		return
+	}
+	writeJSON(w, http.StatusOK, bests)
+}
+
+// CardioWeeklyHandler returns weekly cardio volume for the last ?weeks=
+// weeks (default 8, current week included).
+func CardioWeeklyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	userID, ok := memberID(w, r)
+	if !ok {
+		return
+	}
+
+	weeks, err := strconv.Atoi(r.URL.Query().Get("weeks"))
+	if err != nil || weeks <= 0 || weeks > 52 {
+		weeks = 8
+	}
+	from := time.Now().AddDate(0, 0, -7*(weeks-1))
+
+	sessions, err := db.ListCardioSessions(userID, from.AddDate(0, 0, -7), time.Time{}, 0)
+	if err != nil {
+		log.Printf("❌ Failed to load cardio sessions: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load weekly volume")
+		return
+	}
+	writeJSON(w, http.StatusOK, db.WeeklyCardioVolume(sessions, from))
+}
+
+// decodeCardio reads and validates a cardio session request body
+func decodeCardio(w http.ResponseWriter, r *http.Request, c *db.CardioSession) error {
+	if err := decodeJSON(w, r, c); err != nil {
+		return errors.New("invalid request body")
+	}
+	c.Activity = strings.ToLower(strings.TrimSpace(c.Activity))
+	c.Notes = strings.TrimSpace(c.Notes)
+	c.PaceSecondsPerKM, c.SpeedKMH = nil, nil
+
+	switch {
+	case !isCardioActivity(c.Activity):
+		return errors.New("activity must be a cardio exercise from the library or \"other\"")
+	case c.DurationSeconds <= 0 || c.DurationSeconds > 24*3600:
+		return errors.New("durationSeconds must be between 1 and 86400")
+	case c.DistanceKM != nil && *c.DistanceKM < 0:
+		return errors.New("distanceKm cannot be negative")
+	case c.AvgHR != nil && (*c.AvgHR < 30 || *c.AvgHR > 250):
+		return errors.New("avgHr must be between 30 and 250")
+	case c.MaxHR != nil && (*c.MaxHR < 30 || *c.MaxHR > 250):
+		return errors.New("maxHr must be between 30 and 250")
+	case c.AvgHR != nil && c.MaxHR != nil && *c.AvgHR > *c.MaxHR:
+		return errors.New("avgHr cannot exceed maxHr")
+	case c.Calories != nil && *c.Calories < 0:
+		return errors.New("calories cannot be negative")
+	case c.StartedAt.After(time.Now().Add(time.Hour)):
+		return errors.New("startedAt cannot be in the future")
+	}
+	return nil
+}
+
+// isCardioActivity reports whether key is "other" or a cardio exercise in
+// the library
+func isCardioActivity(key string) bool {
+	if key == db.CardioOther {
+		return true
+	}
+	e, err := db.GetExerciseBySlug(key)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		log.Printf("❌ Failed to look up activity %q: %v", key, err)
+	}
+	return err == nil && e.Kind == db.ExerciseCardio
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fitnesscoach/ai"
+	"fitnesscoach/db"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// ConversationsHandler lists (GET) and starts (POST) the user's AI
+// conversations, renames one (PUT ?id=) or deletes it (DELETE ?id=).
+func ConversationsHandler(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		conversations, err := db.ListConversations(user.ID)
+		if err != nil {
+			log.Printf("❌ Failed to list conversations: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load conversations")
+			return
+		}
+		writeJSON(w, http.StatusOK, conversations)
+
+	case http.MethodPost, http.MethodPut:
+		var body struct {
+			Title string `json:"title"`
+		}
+		if err := decodeJSON(w, r, &body); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		title := strings.TrimSpace(body.Title)
+		if utf8.RuneCountInString(title) > 120 {
+			writeJSONError(w, http.StatusBadRequest, "title must be at most 120 characters")
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			conversation, err := db.CreateConversation(user.ID, title)
+			if err != nil {
+				log.Printf("❌ Failed to create conversation: %v", err)
+				writeJSONError(w, http.StatusInternalServerError, "failed to create conversation")
+				return
+			}
+			writeJSON(w, http.StatusCreated, conversation)
+			return
+		}
+
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		if title == "" {
+			writeJSONError(w, http.StatusBadRequest, "title is required")
+			return
+		}
+		err := db.RenameConversation(user.ID, id, title)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "conversation not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to rename conversation: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to rename conversation")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int64{"id": id})
+
+	case http.MethodDelete:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.DeleteConversation(user.ID, id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "conversation not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to delete conversation: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to delete conversation")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// ConversationDetailHandler returns a conversation with all its messages
+func ConversationDetailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	id, ok := queryID(r, "id")
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	user := currentUser(r)
+	conversation, err := db.GetConversation(user.ID, id)
+	if errors.Is(err, db.ErrNotFound) {
+		writeJSONError(w, http.StatusNotFound, "conversation not found")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load conversation: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load conversation")
+		return
+	}
+	messages, err := db.ConversationMessages(user.ID, id, 0)
+	if err != nil {
+		log.Printf("❌ Failed to load conversation messages: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load conversation")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"conversation": conversation,
+		"messages":     messages,
+	})
+}
+
+// conversationMessage is the body of a new turn in a conversation
+type conversationMessage struct {
+	Content string `json:"content"`
+	Share   *bool  `json:"share"`
+}
+
+// ConversationMessagesHandler continues a conversation (POST ?id=): the
+// member's message is stored, answered with the conversation history and
+// the answer is stored too.
+func ConversationMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	id, ok := queryID(r, "id")
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	var body conversationMessage
+	if err := decodeJSON(w, r, &body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user := currentUser(r)
+	turn, status, err := prepareTurn(r.Context(), user, id, body)
+	if err != nil {
+		writeJSONError(w, status, err.Error())
+		return
+	}
+
+	reply, err := aiProvider.Chat(r.Context(), turn.prompt, ai.Options{})
+	if err != nil {
+		log.Printf("❌ %s request failed: %v", aiProvider.Name(), err)
+		writeJSONError(w, http.StatusBadGateway, "the AI coach is unavailable right now")
+		return
+	}
+	answer, err := db.AddConversationMessage(user.ID, id, ai.RoleAssistant, reply)
+	if err != nil {
+		log.Printf("❌ Failed to save AI reply: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to save reply")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"conversation": turn.conversation,
+		"message":      turn.message,
+		"reply":        answer,
+	})
+}
+
+// conversationTurn is a stored member message and the prompt to answer it
+type conversationTurn struct {
+	conversation *db.AIConversation
+	message      *db.AIMessage
+	prompt       []ai.Message
+}
+
+// prepareTurn stores the member's message and builds the prompt: the
+// profile context, a summary of older turns and the recent turns that fit
+// the history budget. Turns that fall out of the budget are folded into
+// the summary first. On failure it returns the HTTP status to answer with.
+func prepareTurn(ctx context.Context, user *CurrentUser, id int64, body conversationMessage) (*conversationTurn, int, error) {
+	content := strings.TrimSpace(body.Content)
+	if content == "" {
+		return nil, http.StatusBadRequest, errors.New("content is required")
+	}
+	if utf8.RuneCountInString(content) > 4000 {
+		return nil, http.StatusBadRequest, errors.New("content must be at most 4000 characters")
+	}
+
+	conversation, err := db.GetConversation(user.ID, id)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, http.StatusNotFound, errors.New("conversation not found")
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load conversation: %v", err)
+		return nil, http.StatusInternalServerError, errors.New("failed to load conversation")
+	}
+
+	message, err := db.AddConversationMessage(user.ID, id, ai.RoleUser, content)
+	if err != nil {
+		log.Printf("❌ Failed to save conversation message: %v", err)
+		return nil, http.StatusInternalServerError, errors.New("failed to save message")
+	}
+	if conversation.Title == "" {
+		conversation.Title = conversationTitle(content)
+		if err := db.RenameConversation(user.ID, id, conversation.Title); err != nil {
+			log.Printf("❌ Failed to title conversation: %v", err)
+		}
+	}
+
+	stored, err := db.ConversationMessages(user.ID, id, conversation.SummarizedThrough)
+	if err != nil {
+		log.Printf("❌ Failed to load conversation messages: %v", err)
+		return nil, http.StatusInternalServerError, errors.New("failed to load conversation")
+	}
+	history := make([]ai.Message, len(stored))
+	for i, m := range stored {
+		history[i] = ai.Message{Role: m.Role, Content: m.Content}
+	}
+
+	older, recent := ai.Window(history, appConfig.AI.HistoryTokens)
+	if len(older) > 0 {
+		summary, err := ai.Summarize(ctx, aiProvider, conversation.Summary, older)
+		through := stored[len(older)-1].ID
+		if err != nil {
+			// Answer anyway; the turns are summarised on the next message
+			log.Printf("❌ Failed to summarise conversation %d: %v", id, err)
+		} else if err := db.SaveConversationSummary(user.ID, id, summary, through); err != nil {
+			log.Printf("❌ Failed to save conversation summary: %v", err)
+		} else {
+			conversation.Summary, conversation.SummarizedThrough = summary, through
+		}
+	}
+
+	var system []string
+	if body.Share == nil || *body.Share {
+		if profile := coachSystemPrompt(user); profile != "" {
+			system = append(system, profile)
+		}
+	}
+	if conversation.Summary != "" {
+		system = append(system, "Summary of the earlier conversation:\n"+conversation.Summary)
+	}
+
+	var prompt []ai.Message
+	if len(system) > 0 {
+		prompt = append(prompt, ai.Message{Role: ai.RoleSystem, Content: strings.Join(system, "\n\n")})
+	}
+	prompt = append(prompt, recent...)
+	return &conversationTurn{conversation: conversation, message: message, prompt: prompt}, http.StatusOK, nil
+}
+
+// conversationTitle names a conversation after the start of its first message
+func conversationTitle(content string) string {
+	title := []rune(strings.Join(strings.Fields(content), " "))
+	if len(title) > 60 {
+		return string(title[:57]) + "..."
+	}
+	return string(title)
+}
@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"errors"
+	"fitnesscoach/db"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ExercisesHandler serves the exercise library. Anyone signed in can read
+// it; coaches and admins edit it:
+//
+//	GET ?id= or ?slug=                          one exercise
+//	GET ?q=&kind=&muscle=&equipment=&pattern=   search, ordered by name
+//	POST                                        add an exercise
+//	PUT ?id=                                    replace an exercise
+//	DELETE ?id=                                 remove an exercise
+func ExercisesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && currentUser(r).Role == RoleMember {
+		writeJSONError(w, http.StatusForbidden, "only coaches can edit the exercise library")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		if id, ok := queryID(r, "id"); ok {
+			writeExercise(w, func() (*db.Exercise, error) { return db.GetExercise(id) })
+			return
+		}
+		if slug := q.Get("slug"); slug != "" {
+			writeExercise(w, func() (*db.Exercise, error) { return db.GetExerciseBySlug(slug) })
+			return
+		}
+
+		f := db.ExerciseFilter{
+			Kind:      q.Get("kind"),
+			Search:    strings.TrimSpace(q.Get("q")),
+			Muscle:    strings.TrimSpace(q.Get("muscle")),
+			Equipment: strings.TrimSpace(q.Get("equipment")),
+			Pattern:   strings.TrimSpace(q.Get("pattern")),
+		}
+		if !validExerciseKind(f.Kind, true) {
+			writeJSONError(w, http.StatusBadRequest, `kind must be "strength" or "cardio"`)
+			return
+		}
+		exercises, err := db.ListExercises(f)
+		if err != nil {
+			log.Printf("❌ Failed to list exercises: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load exercises")
+			return
+		}
+		writeJSON(w, http.StatusOK, exercises)
+
+	case http.MethodPost, http.MethodPut:
+		var e db.Exercise
+		if err := decodeJSON(w, r, &e); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if msg := validateExercise(&e); msg != "" {
+			writeJSONError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			created, err := db.CreateExercise(e)
+			if errors.Is(err, db.ErrExerciseExists) {
+				writeJSONError(w, http.StatusConflict, "an exercise with that name already exists")
+				return
+			}
+			if err != nil {
+				log.Printf("❌ Failed to save exercise: %v", err)
+				writeJSONError(w, http.StatusInternalServerError, "failed to save exercise")
+				return
+			}
+			log.Printf("🏋️ %s added %q to the exercise library", currentUser(r).Username, created.Name)
+			writeJSON(w, http.StatusCreated, created)
+			return
+		}
+
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.UpdateExercise(id, e)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			writeJSONError(w, http.StatusNotFound, "exercise not found")
+		case errors.Is(err, db.ErrExerciseExists):
+			writeJSONError(w, http.StatusConflict, "an exercise with that name already exists")
+		case err != nil:
+			log.Printf("❌ Failed to update exercise: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to update exercise")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+	case http.MethodDelete:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.DeleteExercise(id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "exercise not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to delete exercise: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to delete exercise")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func writeExercise(w http.ResponseWriter, get func() (*db.Exercise, error)) {
+	e, err := get()
+	if errors.Is(err, db.ErrNotFound) {
+		writeJSONError(w, http.StatusNotFound, "exercise not found")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load exercise: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load exercise")
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+// ExerciseFacetsHandler lists the muscle groups, equipment and movement
+// patterns in the library, optionally for one ?kind=, to fill filters
+func ExerciseFacetsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	kind := r.URL.Query().Get("kind")
+	if !validExerciseKind(kind, true) {
+		writeJSONError(w, http.StatusBadRequest, `kind must be "strength" or "cardio"`)
+		return
+	}
+	facets, err := db.ListExerciseFacets(kind)
+	if err != nil {
+		log.Printf("❌ Failed to list exercise facets: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load filters")
+		return
+	}
+	writeJSON(w, http.StatusOK, facets)
+}
+
+func validExerciseKind(kind string, allowEmpty bool) bool {
+	return kind == db.ExerciseStrength || kind == db.ExerciseCardio || (allowEmpty && kind == "")
+}
+
+// validateExercise tidies an exercise in place: muscle groups, equipment
+// and movement pattern become lower case, muscle groups unique
+func validateExercise(e *db.Exercise) string {
+	e.Name = strings.Join(strings.Fields(e.Name), " ")
+	e.Kind = strings.ToLower(strings.TrimSpace(e.Kind))
+	e.Equipment = strings.ToLower(strings.TrimSpace(e.Equipment))
+	e.MovementPattern = strings.ToLower(strings.TrimSpace(e.MovementPattern))
+	e.Instructions = strings.TrimSpace(e.Instructions)
+	e.MediaURL = strings.TrimSpace(e.MediaURL)
+
+	muscles := []string{}
+	for _, muscle := range e.MuscleGroups {
+		muscle = strings.ToLower(strings.TrimSpace(muscle))
+		if muscle == "" || slices.Contains(muscles, muscle) {
+			continue
+		}
+		if utf8.RuneCountInString(muscle) > 40 {
+			return "muscle groups must be at most 40 characters"
+		}
+		muscles = append(muscles, muscle)
+	}
+	e.MuscleGroups = muscles
+
+	switch {
+	case e.Name == "" || utf8.RuneCountInString(e.Name) > 80:
+		return "name must be 1 to 80 characters"
+	case db.ExerciseSlug(e.Name) == "":
+		return "name must contain a letter or digit"
+	case !validExerciseKind(e.Kind, false):
+		return `kind must be "strength" or "cardio"`
+	case len(e.MuscleGroups) == 0 || len(e.MuscleGroups) > 8:
+		return "list between 1 and 8 muscle groups, primary first"
+	case utf8.RuneCountInString(e.Equipment) > 40:
+		return "equipment must be at most 40 characters"
+	case utf8.RuneCountInString(e.MovementPattern) > 40:
+		return "movementPattern must be at most 40 characters"
+	case utf8.RuneCountInString(e.Instructions) > 5000:
+		return "instructions must be at most 5000 characters"
+	case !validMediaURL(e.MediaURL):
+		return "mediaUrl must be a path on this site or an http(s) URL of at most 500 characters"
+	}
+	return ""
+}
+
+// validMediaURL accepts nothing, a path on this site or an http(s) URL
+func validMediaURL(s string) bool {
+	if s == "" {
+		return true
+	}
+	if len(s) > 500 {
+		return false
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" && u.Host == "" {
+		return strings.HasPrefix(u.Path, "/")
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// exerciseNames lists the names of the library's exercises of a kind.
+// Cardio exercises are listed by their activity key.
+func exerciseNames(library []db.Exercise, kind string) []string {
+	var names []string
+	for _, e := range library {
+		switch {
+		case e.Kind != kind:
+		case kind == db.ExerciseCardio:
+			names = append(names, e.Slug)
+		default:
+			names = append(names, e.Name)
+		}
+	}
+	return names
+}
+
+// muscleOrder is the order of the weight page sections; other muscle
+// groups follow alphabetically
+var muscleOrder = []string{"chest", "back", "biceps", "triceps", "shoulders", "legs"}
+
+// exerciseSection is a heading on the weight page with the exercises
+// whose primary muscle group it is
+type exerciseSection struct {
+	Title     string
+	Exercises []db.Exercise
+}
+
+// exercisePage is the data of the weight and cardio pages
+type exercisePage struct {
+	WebsiteTitle string
+	AIChat       string
+	Sections     []exerciseSection
+	Activities   []db.Exercise
+}
+
+// strengthSections groups strength exercises by primary muscle group
+func strengthSections(exercises []db.Exercise) []exerciseSection {
+	groups := map[string][]db.Exercise{}
+	var muscles []string
+	for _, e := range exercises {
+		muscle := "other"
+		if len(e.MuscleGroups) > 0 {
+			muscle = e.MuscleGroups[0]
+		}
+		if _, ok := groups[muscle]; !ok {
+			muscles = append(muscles, muscle)
+		}
+		groups[muscle] = append(groups[muscle], e)
+	}
+
+	rank := func(muscle string) int {
+		if i := slices.Index(muscleOrder, muscle); i >= 0 {
+			return i
+		}
+		return len(muscleOrder)
+	}
+	sort.Slice(muscles, func(i, j int) bool {
+		if ri, rj := rank(muscles[i]), rank(muscles[j]); ri != rj {
+			return ri < rj
+		}
+		return muscles[i] < muscles[j]
+	})
+
+	sections := make([]exerciseSection, 0, len(muscles))
+	for _, muscle := range muscles {
+		title := strings.ToUpper(muscle[:1]) + muscle[1:]
+		sections = append(sections, exerciseSection{Title: title, Exercises: groups[muscle]})
+	}
+	return sections
+}
+
+// renderExercisePage renders the weight or cardio page from the library
+func renderExercisePage(w http.ResponseWriter, title, kind, file string) {
+	exercises, err := db.ListExercises(db.ExerciseFilter{Kind: kind})
+	if err != nil {
+		log.Printf("❌ Failed to load the exercise library: %v", err)
+		http.Error(w, "Failed to load page", http.StatusInternalServerError)
+		return
+	}
+	page := exercisePage{WebsiteTitle: title, AIChat: aiChatFlag()}
+	if kind == db.ExerciseStrength {
+		page.Sections = strengthSections(exercises)
+	} else {
+		page.Activities = exercises
+	}
+
+	tmpl, err := template.ParseFiles("templates/" + file + ".html")
+	if err != nil {
+		http.Error(w, "Failed to load page", http.StatusInternalServerError)
+		return
+	}
+	tmpl.Execute(w, page)
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"errors"
+	"fitnesscoach/db"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// goalRequest creates or replaces a goal. StartValue defaults to the
+// current value; StartDate defaults to today.
+type goalRequest struct {
+	Kind        string   `json:"kind"`
+	Title       string   `json:"title"`
+	Exercise    string   `json:"exercise"`
+	Habit       string   `json:"habit"`
+	StartValue  *float64 `json:"startValue"`
+	TargetValue float64  `json:"targetValue"`
+	StartDate   string   `json:"startDate"`
+	Deadline    string   `json:"deadline"`
+}
+
+// GoalsHandler manages a member's goals. Coaches may read a client's with
+// ?username=:
+//
+//	GET           goals with progress, projected completion and status
+//	POST          create a goal
+//	PUT ?id=      replace a goal
+//	DELETE ?id=   remove a goal
+func GoalsHandler(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	if r.Method != http.MethodGet && user.Role != RoleMember {
+		writeJSONError(w, http.StatusForbidden, "only members can manage goals")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		userID, ok := memberID(w, r)
+		if !ok {
+			return
+		}
+		goals, err := db.ListGoals(userID)
+		if err != nil {
+			log.Printf("❌ Failed to list goals: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load goals")
+			return
+		}
+		writeJSON(w, http.StatusOK, goals)
+
+	case http.MethodPost, http.MethodPut:
+		var id int64
+		if r.Method == http.MethodPut {
+			var ok bool
+			if id, ok = queryID(r, "id"); !ok {
+				writeJSONError(w, http.StatusBadRequest, "id is required")
+				return
+			}
+		}
+		var req goalRequest
+		if err := decodeJSON(w, r, &req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		g, msg, err := validateGoal(user.ID, req)
+		if err != nil {
+			log.Printf("❌ Failed to check goal: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to save goal")
+			return
+		}
+		if msg != "" {
+			writeJSONError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			created, err := db.CreateGoal(user.ID, g)
+			if err != nil {
+				log.Printf("❌ Failed to create goal: %v", err)
+				writeJSONError(w, http.StatusInternalServerError, "failed to save goal")
+				return
+			}
+			writeJSON(w, http.StatusCreated, created)
+			return
+		}
+
+		g.ID = id
+		updated, err := db.UpdateGoal(user.ID, g)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "goal not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to update goal: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to save goal")
+			return
+		}
+		writeJSON(w, http.StatusOK, updated)
+
+	case http.MethodDelete:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.DeleteGoal(user.ID, id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "goal not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to delete goal: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to delete goal")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// validateGoal turns a request into a goal, filling in defaults. It
+// returns a message for the member when the request is invalid.
+func validateGoal(userID int64, req goalRequest) (db.Goal, string, error) {
+	g := db.Goal{
+		Kind:        req.Kind,
+		Title:       strings.TrimSpace(req.Title),
+		TargetValue: req.TargetValue,
+		StartDate:   req.StartDate,
+		Deadline:    req.Deadline,
+	}
+	if !slices.Contains(db.GoalKinds, g.Kind) {
+		return g, "kind must be one of weight, lift, cardio, habit", nil
+	}
+	if g.Title == "" || utf8.RuneCountInString(g.Title) > 100 {
+		return g, "title must be 1 to 100 characters", nil
+	}
+
+	switch g.Kind {
+	case db.GoalLift:
+		g.Exercise = strings.TrimSpace(req.Exercise)
+		if g.Exercise == "" || utf8.RuneCountInString(g.Exercise) > 80 {
+			return g, "exercise must be 1 to 80 characters", nil
+		}
+	case db.GoalHabit:
+		habits, err := db.ListHabits(userID)
+		if err != nil {
+			return g, "", err
+		}
+		if !slices.ContainsFunc(habits, func(h db.Habit) bool { return h.Key == req.Habit }) {
+			return g, "habit not found", nil
+		}
+		g.Habit = req.Habit
+	}
+
+	if g.StartDate == "" {
+		g.StartDate = time.Now().Format("2006-01-02")
+	}
+	start, err := time.ParseInLocation("2006-01-02", g.StartDate, time.Local)
+	if err != nil {
+		return g, "startDate must be YYYY-MM-DD", nil
+	}
+	deadline, err := time.ParseInLocation("2006-01-02", g.Deadline, time.Local)
+	if err != nil {
+		return g, "deadline must be YYYY-MM-DD", nil
+	}
+	if !deadline.After(start) {
+		return g, "deadline must be after the start date", nil
+	}
+
+	if req.StartValue != nil {
+		g.StartValue = *req.StartValue
+	} else {
+		current, points, err := db.GoalValues(userID, g)
+		if err != nil {
+			return g, "", err
+		}
+		if g.Kind == db.GoalCardio && len(points) > 0 {
+			// Start from the last whole week rather than the one under way
+			current = &points[len(points)-1].Value
+		}
+		if current == nil && (g.Kind == db.GoalWeight || g.Kind == db.GoalLift) {
+			return g, "startValue is required until there is logged data to start from", nil
+		}
+		if current != nil {
+			g.StartValue = *current
+		}
+	}
+
+	if g.TargetValue <= 0 || g.StartValue < 0 {
+		return g, "values must be positive", nil
+	}
+	if g.TargetValue == g.StartValue {
+		return g, "targetValue must differ from startValue", nil
+	}
+	if g.Kind != db.GoalWeight && g.TargetValue < g.StartValue {
+		return g, "targetValue must be above startValue", nil
+	}
+	return g, "", nil
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"errors"
+	"fitnesscoach/db"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// habitDay is the state of every habit on one day
+type habitDay struct {
+	Date   string        `json:"date"`
+	Habits []habitStatus `json:"habits"`
+}
+
+type habitStatus struct {
+	db.Habit
+	Done bool `json:"done"`
+}
+
+// HabitsHandler lists the member's habits (GET) and manages custom ones:
+// POST creates, PUT ?id= renames and DELETE ?id= removes a habit.
+func HabitsHandler(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	if r.Method != http.MethodGet && user.Role != RoleMember {
+		writeJSONError(w, http.StatusForbidden, "only members can manage habits")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		userID, ok := memberID(w, r)
+		if !ok {
+			return
+		}
+		habits, err := db.ListHabits(userID)
+		if err != nil {
+			log.Printf("❌ Failed to list habits: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load habits")
+			return
+		}
+		writeJSON(w, http.StatusOK, habits)
+
+	case http.MethodPost, http.MethodPut:
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := decodeJSON(w, r, &body); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		name := strings.TrimSpace(body.Name)
+		if name == "" || utf8.RuneCountInString(name) > 60 {
+			writeJSONError(w, http.StatusBadRequest, "name must be 1 to 60 characters")
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			habit, err := db.CreateHabit(user.ID, name)
+			if errors.Is(err, db.ErrHabitExists) {
+				writeJSONError(w, http.StatusConflict, err.Error())
+				return
+			}
+			if err != nil {
+				log.Printf("❌ Failed to create habit: %v", err)
+				writeJSONError(w, http.StatusInternalServerError, "failed to create habit")
+				return
+			}
+			writeJSON(w, http.StatusCreated, habit)
+			return
+		}
+
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.RenameHabit(user.ID, id, name)
+		switch {
+		case errors.Is(err, db.ErrHabitExists):
+			writeJSONError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, db.ErrNotFound):
+			writeJSONError(w, http.StatusNotFound, "habit not found")
+		case err != nil:
+			log.Printf("❌ Failed to rename habit: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to rename habit")
+		default:
+			writeJSON(w, http.StatusOK, map[string]int64{"id": id})
+		}
+
+	case http.MethodDelete:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.DeleteHabit(user.ID, id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "habit not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to delete habit: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to delete habit")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// CheckInsHandler returns every habit's state on ?date= (GET, default
+// today) and checks a habit in or out for today or a past date (POST).
+func CheckInsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		userID, ok := memberID(w, r)
+		if !ok {
+			return
+		}
+		date, err := checkInDate(r.URL.Query().Get("date"))
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		day, err := loadHabitDay(userID, date)
+		if err != nil {
+			log.Printf("❌ Failed to load check-ins: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load check-ins")
+			return
+		}
+		writeJSON(w, http.StatusOK, day)
+
+	case http.MethodPost:
+		user := currentUser(r)
+		if user.Role != RoleMember {
+			writeJSONError(w, http.StatusForbidden, "only members can check in")
+			return
+		}
+		var body struct {
+			Habit string `json:"habit"`
+			Date  string `json:"date"`
+			Done  bool   `json:"done"`
+		}
+		if err := decodeJSON(w, r, &body); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		date, err := checkInDate(body.Date)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		err = db.SetCheckIn(user.ID, body.Habit, date, body.Done)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "habit not found")
+			return
+		}
+		if errors.Is(err, db.ErrDerivedHabit) && body.Habit == db.HabitMeals {
+			writeJSONError(w, http.StatusBadRequest, "meals are ticked by the food logged that day; delete the entries instead")
+			return
+		}
+		if errors.Is(err, db.ErrDerivedHabit) {
+			writeJSONError(w, http.StatusBadRequest, "the water target is ticked from logged water")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to save check-in: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to save check-in")
+			return
+		}
+		day, err := loadHabitDay(user.ID, date)
+		if err != nil {
+			log.Printf("❌ Failed to load check-ins: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load check-ins")
+			return
+		}
+		writeJSON(w, http.StatusOK, day)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// ProgressHandler saves the workout and meals habits for a day in one
+// call. Water follows the logged intake and cannot be set here; meals stay
+// ticked on a day with logged food.
+func ProgressHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var body struct {
+		Date    string `json:"date"`
+		Workout bool   `json:"workout"`
+		Meals   bool   `json:"meals"`
+		Water   *bool  `json:"water"`
+	}
+	if err := decodeJSON(w, r, &body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if body.Water != nil {
+		writeJSONError(w, http.StatusBadRequest, "the water target is ticked from logged water")
+		return
+	}
+	date, err := checkInDate(body.Date)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user := currentUser(r)
+	if err := db.SaveOrUpdateProgress(user.ID, date, body.Workout, body.Meals); err != nil {
+		log.Printf("❌ Failed to save progress: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to save progress")
+		return
+	}
+	day, err := loadHabitDay(user.ID, date)
+	if err != nil {
+		log.Printf("❌ Failed to load check-ins: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load check-ins")
+		return
+	}
+	writeJSON(w, http.StatusOK, day)
+}
+
+// HabitCalendarHandler returns every day of ?month=YYYY-MM (default this
+// month) with the habits done on it
+func HabitCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	userID, ok := memberID(w, r)
+	if !ok {
+		return
+	}
+
+	month := time.Now()
+	if v := r.URL.Query().Get("month"); v != "" {
+		parsed, err := time.ParseInLocation("2006-01", v, time.Local)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "month must be YYYY-MM")
+			return
+		}
+		month = parsed
+	}
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.Local)
+	last := first.AddDate(0, 1, -1)
+
+	habits, err := db.ListHabits(userID)
+	if err != nil {
+		log.Printf("❌ Failed to list habits: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load calendar")
+		return
+	}
+	checkIns, err := db.ListCheckIns(userID, first.Format("2006-01-02"), last.Format("2006-01-02"))
+	if err != nil {
+		log.Printf("❌ Failed to list check-ins: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load calendar")
+		return
+	}
+
+	done := make(map[string][]string)
+	for _, c := range checkIns {
+		done[c.Date] = append(done[c.Date], c.Habit)
+	}
+	type calendarDay struct {
+		Date string   `json:"date"`
+		Done []string `json:"done"`
+	}
+	days := []calendarDay{}
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		keys := done[date]
+		if keys == nil {
+			keys = []string{}
+		}
+		days = append(days, calendarDay{Date: date, Done: keys})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"month":  first.Format("2006-01"),
+		"habits": habits,
+		"days":   days,
+	})
+}
+
+// HabitStreaksHandler returns the current and longest streak per habit
+func HabitStreaksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	userID, ok := memberID(w, r)
+	if !ok {
+		return
+	}
+
+	streaks, err := habitStreaks(userID)
+	if err != nil {
+		log.Printf("❌ Failed to compute streaks: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load streaks")
+		return
+	}
+	writeJSON(w, http.StatusOK, streaks)
+}
+
+func habitStreaks(userID int64) ([]db.HabitStreak, error) {
+	habits, err := db.ListHabits(userID)
+	if err != nil {
+		return nil, err
+	}
+	today := time.Now().Format("2006-01-02")
+	checkIns, err := db.ListCheckIns(userID, "", today)
+	if err != nil {
+		return nil, err
+	}
+	return db.ComputeHabitStreaks(habits, checkIns, today), nil
+}
+
+func loadHabitDay(userID int64, date string) (*habitDay, error) {
+	habits, err := db.ListHabits(userID)
+	if err != nil {
+		return nil, err
+	}
+	checkIns, err := db.ListCheckIns(userID, date, date)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(map[string]bool, len(checkIns))
+	for _, c := range checkIns {
+		done[c.Habit] = true
+	}
+	day := &habitDay{Date: date, Habits: make([]habitStatus, 0, len(habits))}
+	for _, h := range habits {
+		day.Habits = append(day.Habits, habitStatus{Habit: h, Done: done[h.Key]})
+	}
+	return day, nil
+}
+
+// checkInDate validates a YYYY-MM-DD check-in date, defaulting to today.
+// Future days cannot be checked in.
+func checkInDate(v string) (string, error) {
+	today := time.Now().Format("2006-01-02")
+	if v == "" {
+		return today, nil
+	}
+	d, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return "", errors.New("date must be YYYY-MM-DD")
+	}
+	if date := d.Format("2006-01-02"); date > today {
+		return "", errors.New("cannot check in for a future date")
+	}
+	return v, nil
+}
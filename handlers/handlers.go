@@ -1,24 +1,61 @@
 package handlers
 
 import (
-	"bytes"
-	"crypto/rand"
-	"encoding/hex"
+	"context"
 	"encoding/json"
+	"fitnesscoach/ai"
+	"fitnesscoach/config"
 	"fitnesscoach/db"
 	"fmt"
+	htmltemplate "html/template"
 	"io"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"text/template"
+	"time"
 
 	"github.com/gorilla/sessions"
 	"github.com/gorilla/websocket"
 )
 
-var store = sessions.NewCookieStore([]byte(generateRandomString(32)))
+// store signs and encrypts the session cookie; Init builds it from config
+var store *sessions.CookieStore
+
+const sessionName = "fitnesscoach.com"
+
+// appConfig is the configuration the handlers were initialised with
+var appConfig = &config.Config{}
+
+// aiProvider answers the AI chat when the feature is enabled
+var aiProvider ai.Provider
+
+// Init wires the handlers to the loaded configuration
+func Init(cfg *config.Config) error {
+	appConfig = cfg
+
+	if cfg.Features.AIChat {
+		provider, err := ai.New(cfg.AI)
+		if err != nil {
+			return err
+		}
+		aiProvider = provider
+		log.Printf("🤖 AI chat using the %s provider", provider.Name())
+	}
+
+	// The first key pair signs new cookies, older pairs still decode
+	// existing ones so keys can be rotated without logging everyone out.
+	store = sessions.NewCookieStore(cfg.Session.KeyPairs...)
+	store.Options = &sessions.Options{
+		Path:     "/",
+		Secure:   cfg.Session.Secure,
+		HttpOnly: cfg.Session.HttpOnly,
+		SameSite: cfg.Session.SameSite,
+	}
+	store.MaxAge(int(cfg.Session.MaxAge.Seconds()))
+	return nil
+}
 
 type WebPageData struct {
 	WebsiteTitle        string
@@ -64,6 +101,11 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		heightStr := r.FormValue("height_cm")
 		weightStr := r.FormValue("weight_kg")
 
+		// Admins are never self-registered
+		if role != RoleCoach {
+			role = RoleMember
+		}
+
 		age, _ := strconv.Atoi(ageStr)
 		height, _ := strconv.ParseFloat(heightStr, 64)
 		weight, _ := strconv.ParseFloat(weightStr, 64)
@@ -107,8 +149,16 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		session, _ := store.Get(r, "fitnesscoach.com")
+		userID, err := db.GetUserIDByUsername(username)
+		if err != nil {
+			webPageData.PostResponseMessage = "❌ Login failed. Please try again."
+			templateRender(w, webPageData, "login")
+			return
+		}
+
+		session, _ := store.Get(r, sessionName)
 		session.Values["authenticatedUser"] = true
+		session.Values["userID"] = userID
 		session.Values["username"] = username
 		session.Values["role"] = role
 		session.Save(r, w)
@@ -121,17 +171,10 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func HomePageHandler(w http.ResponseWriter, r *http.Request) {
-	session, _ := store.Get(r, "fitnesscoach.com")
-	isAuthenticated, ok := session.Values["authenticatedUser"].(bool)
-	if !ok || !isAuthenticated {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
-		return
-	}
+	user := currentUser(r)
+	username := user.Username
 
-	username := session.Values["username"].(string)
-	role := session.Values["role"].(string)
-
-	if role == "coach" {
+	if user.Role == RoleCoach {
 		data := map[string]string{
 			"WebsiteTitle":    "Coach Dashboard",
 			"HomePageHeading": "Welcome Coach",
@@ -160,8 +203,7 @@ func UserInfoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session, _ := store.Get(r, "fitnesscoach.com")
-	username := session.Values["username"].(string)
+	username := currentUser(r).Username
 
 	fullName := r.FormValue("full_name")
 	age, _ := strconv.Atoi(r.FormValue("age"))
@@ -184,7 +226,15 @@ func GetAllUserInfoHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	users, err := db.GetAllUserInfo()
+	// Coaches only see their own clients
+	user := currentUser(r)
+	var users []db.UserInfo
+	var err error
+	if user.Role == RoleAdmin {
+		users, err = db.GetAllUserInfo()
+	} else {
+		users, err = db.GetClientInfo(user.ID)
+	}
 	if err != nil {
 		log.Println("❌ Failed to fetch user info:", err)
 		http.Error(w, "Failed to fetch user info", http.StatusInternalServerError)
@@ -196,14 +246,7 @@ func GetAllUserInfoHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func UserDashHandler(w http.ResponseWriter, r *http.Request) {
-	session, _ := store.Get(r, "fitnesscoach.com")
-	isAuthenticated, ok := session.Values["authenticatedUser"].(bool)
-	if !ok || !isAuthenticated {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
-		return
-	}
-
-	username := session.Values["username"].(string)
+	username := currentUser(r).Username
 	userInfo, err := db.GetUserInfoByUsername(username)
 	if err != nil {
 		http.Error(w, "Unable to load dashboard", http.StatusInternalServerError)
@@ -226,6 +269,17 @@ func UserDashHandler(w http.ResponseWriter, r *http.Request) {
 		"Message":      message,
 	}
 
+	if trend, err := weightTrend(currentUser(r).ID); err != nil {
+		log.Printf("❌ Failed to compute weight trend: %v", err)
+	} else {
+		if trend.MovingAverage != nil {
+			data["WeightAvg7d"] = fmt.Sprintf("%.1f", *trend.MovingAverage)
+		}
+		if trend.WeeklyDelta != nil {
+			data["WeeklyDelta"] = fmt.Sprintf("%+.1f", *trend.WeeklyDelta)
+		}
+	}
+
 	templateRenderMap(w, data, "userdash")
 }
 
@@ -246,31 +300,19 @@ func CoachChatHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func WeightHandler(w http.ResponseWriter, r *http.Request) {
-	session, _ := store.Get(r, "fitnesscoach.com")
-	isAuthenticated, ok := session.Values["authenticatedUser"].(bool)
-	if !ok || !isAuthenticated {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
-		return
-	}
-
-	data := map[string]string{
-		"WebsiteTitle": "Weight Training Goals",
-	}
-	templateRenderMap(w, data, "weight")
+	renderExercisePage(w, "Weight Training Goals", db.ExerciseStrength, "weight")
 }
 
 func CardioHandler(w http.ResponseWriter, r *http.Request) {
-	session, _ := store.Get(r, "fitnesscoach.com")
-	isAuthenticated, ok := session.Values["authenticatedUser"].(bool)
-	if !ok || !isAuthenticated {
-		http.Redirect(w, r, "/login", http.StatusSeeOther)
-		return
-	}
+	renderExercisePage(w, "Cardio Training Goals", db.ExerciseCardio, "cardio")
+}
 
-	data := map[string]string{
-		"WebsiteTitle": "Cardio Training Goals",
+// aiChatFlag tells page templates whether AI features are switched on
+func aiChatFlag() string {
+	if aiProvider == nil {
+		return ""
 	}
-	templateRenderMap(w, data, "cardio")
+	return "true"
 }
 
 func UpdateProfilePageHandler(w http.ResponseWriter, r *http.Request) {
@@ -299,6 +341,7 @@ func UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
 		Gender   string  `json:"gender"`
 		Height   float64 `json:"height"`
 		Weight   float64 `json:"weight"`
+		Goal     *string `json:"goal"`
 	}
 
 	// Read the request body
@@ -323,18 +366,24 @@ func UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
 	// Log the decoded profile data
 	log.Printf("Decoded Profile Data: %+v", profileData)
 
-	session, _ := store.Get(r, "fitnesscoach.com")
-	username, ok := session.Values["username"].(string)
-	if !ok || username == "" {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
+	username := currentUser(r).Username
 
 	err = db.SaveUserInfo(username, profileData.FullName, profileData.Age, profileData.Gender, profileData.Height, profileData.Weight)
 	if err != nil {
 		http.Error(w, "Failed to update profile", http.StatusInternalServerError)
 		return
 	}
+	if profileData.Goal != nil {
+		goal := []rune(strings.TrimSpace(*profileData.Goal))
+		if len(goal) > 255 {
+			goal = goal[:255]
+		}
+		if err := db.SetFitnessGoal(currentUser(r).ID, string(goal)); err != nil {
+			log.Printf("❌ Failed to save fitness goal: %v", err)
+			http.Error(w, "Failed to update profile", http.StatusInternalServerError)
+			return
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Profile updated successfully"})
@@ -347,60 +396,72 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-var clients = make(map[string]*websocket.Conn)     // username -> connection
-var connections = make(map[*websocket.Conn]string) // connection -> username
-var broadcast = make(chan Message)
+var broadcast = make(chan Message, 256)
 
-// Message structure for WebSocket communication
+// Message structure for WebSocket communication. Chat messages have no
+// Type; "ai" frames ask for a streamed AI answer instead, and "read"
+// frames mark the messages from Receiver up to UpTo as read.
 type Message struct {
+	Type     string `json:"type,omitempty"`
+	ID       int64  `json:"id,omitempty"`
 	Sender   string `json:"sender"`
 	Receiver string `json:"receiver"`
 	Content  string `json:"content"`
+	Time     string `json:"time,omitempty"`
+	UpTo     int64  `json:"upTo,omitempty"`
 }
 
 // HandleConnections handles WebSocket connections for both coach and user
 func HandleConnections(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	username := user.Username
+
 	ws, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
 		return
 	}
 
-	// Retrieve username from session
-	session, _ := store.Get(r, "fitnesscoach.com")
-	username, ok := session.Values["username"].(string)
-	if !ok || username == "" {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		ws.Close()
-		return
-	}
-
-	// Register the connection
-	clients[username] = ws
-	connections[ws] = username
-	defer func() {
-		delete(clients, username)
-		delete(connections, ws)
-		ws.Close()
-	}()
+	// Cancels AI streams still running when the connection closes
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	// Listen for incoming messages
-	for {
+	client := hub.Register(ws, username)
+	go deliverPending(user)
+	client.readLoop(func(frame []byte) {
 		var msg Message
-		err := ws.ReadJSON(&msg)
-		if err != nil {
-			log.Printf("WebSocket read error: %v", err)
-			break
+		if err := json.Unmarshal(frame, &msg); err != nil {
+			log.Printf("WebSocket message from %s is not JSON: %v", username, err)
+			return
 		}
-		msg.Sender = username // Set the sender to the current user
-		broadcast <- msg      // Send the message to the broadcast channel
-	}
+		switch msg.Type {
+		case "ai":
+			if aiProvider == nil {
+				client.Send(map[string]string{"type": "ai.error", "error": "AI chat is disabled"})
+				return
+			}
+			go streamOverSocket(ctx, client, user, frame)
+		case "read":
+			markRead(user, msg)
+		default:
+			if !canChat(user, msg.Receiver) {
+				client.Send(map[string]string{"type": "error", "error": "you can only message your coach or your clients"})
+				return
+			}
+			msg.Sender = username // Set the sender to the current user
+			broadcast <- msg
+		}
+	})
 }
 
-// HandleMessages handles broadcasting messages to specific users
+// HandleMessages saves each chat message and delivers it to every
+// connection of the receiver, and of the sender so their other tabs and
+// devices stay in sync
 func HandleMessages() {
-	for {
-		msg := <-broadcast
+	for msg := range broadcast {
+		if msg.Receiver == "" || msg.Content == "" {
+			continue
+		}
 
 		// Get sender and receiver IDs
 		senderID, err1 := db.GetUserIDByUsername(msg.Sender)
@@ -412,35 +473,40 @@ func HandleMessages() {
 		}
 
 		// Save message to the database
-		err := db.SendMessage(senderID, receiverID, msg.Content)
+		id, sentAt, err := db.SendMessage(senderID, receiverID, msg.Content)
 		if err != nil {
 			log.Printf("❌ Failed to save message: %v", err)
+			sentAt = time.Now().UTC()
 		} else {
 			log.Printf("💬 Message saved to DB: %s -> %s", msg.Sender, msg.Receiver)
 		}
+		msg.ID = id
+		msg.Time = sentAt.Format(time.RFC3339Nano)
 
-		// Deliver message to the receiver if online
-		if receiverConn, ok := clients[msg.Receiver]; ok {
-			if err := receiverConn.WriteJSON(msg); err != nil {
-				log.Printf("WebSocket write error: %v", err)
-				receiverConn.Close()
-				delete(clients, msg.Receiver)
-			}
-		} else {
+		// The sender hears about delivery after their own echo
+		delivered := hub.SendTo(msg.Receiver, msg) > 0
+		if !delivered {
 			log.Printf("📭 %s is not connected", msg.Receiver)
 		}
+		if msg.Sender != msg.Receiver {
+			hub.SendTo(msg.Sender, msg)
+		}
+		if delivered && id != 0 {
+			if _, err := db.MarkDelivered(senderID, receiverID, id); err != nil {
+				log.Printf("❌ Failed to mark message delivered: %v", err)
+			} else {
+				sendReceipt(db.Receipt{Sender: msg.Sender, Reader: msg.Receiver, Status: db.ReceiptDelivered, UpTo: id, At: time.Now().UTC()})
+			}
+		}
 	}
 }
-func ChatHistoryHandler(w http.ResponseWriter, r *http.Request) {
-	session, _ := store.Get(r, "fitnesscoach.com")
-	currentUsername := session.Values["username"].(string)
 
-	senderID, err := db.GetUserIDByUsername(currentUsername)
-	if err != nil {
-		log.Printf("❌ Invalid sender: %v", err)
-		http.Error(w, "Invalid sender", http.StatusBadRequest)
-		return
-	}
+// ChatHistoryHandler returns a page of the chat with ?receiver=, oldest
+// first. ?before= and ?after= take a message ID to page older or newer
+// messages; ?limit= caps the page (default 50). hasMore says whether there
+// are more messages in the direction paged.
+func ChatHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	senderID := currentUser(r).ID
 
 	receiver := r.URL.Query().Get("receiver")
 	if receiver == "" {
@@ -455,96 +521,117 @@ func ChatHistoryHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid receiver", http.StatusBadRequest)
 		return
 	}
+	if !canChat(currentUser(r), receiver) {
+		http.Error(w, "You can only read chats with your coach or your clients", http.StatusForbidden)
+		return
+	}
 
-	log.Printf("🔍 Fetching messages between senderID: %d and receiverID: %d", senderID, receiverID)
+	page := db.HistoryPage{Limit: 50}
+	q := r.URL.Query()
+	if q.Get("before") != "" && q.Get("after") != "" {
+		http.Error(w, "Use either before or after", http.StatusBadRequest)
+		return
+	}
+	for key, dest := range map[string]*int64{"before": &page.Before, "after": &page.After} {
+		if q.Get(key) == "" {
+			continue
+		}
+		id, ok := queryID(r, key)
+		if !ok {
+			http.Error(w, key+" must be a message ID", http.StatusBadRequest)
+			return
+		}
+		*dest = id
+	}
+	if limit, err := strconv.Atoi(q.Get("limit")); err == nil && limit > 0 && limit <= 200 {
+		page.Limit = limit
+	}
 
-	messages, err := db.GetMessagesBetweenUsers(senderID, receiverID)
+	messages, more, err := db.GetMessagesBetweenUsers(senderID, receiverID, page)
 	if err != nil {
 		log.Printf("❌ Failed to fetch chat history: %v", err)
 		http.Error(w, "Failed to fetch chat history", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("✅ Messages fetched: %v", messages)
+	log.Printf("✅ %d messages fetched between %d and %d", len(messages), senderID, receiverID)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(messages)
-}
-
-const cohereAPIKey = "aflWffC10AK0waH2h7KkeMXkRoR8Igj8Y2ofiGKw"
-const cohereURL = "https://api.cohere.ai/v1/generate"
-
-type CohereRequest struct {
-	Model       string  `json:"model"`
-	Prompt      string  `json:"prompt"`
-	MaxTokens   int     `json:"max_tokens"`
-	Temperature float64 `json:"temperature"`
-}
-
-type CohereResponse struct {
-	Generations []struct {
-		Text string `json:"text"`
-	} `json:"generations"`
+	json.NewEncoder(w).Encode(map[string]any{"messages": messages, "hasMore": more})
 }
 
+// AiChatHandler serves the AI chat page and answers a single prompt. The
+// page uses html/template because the reply is untrusted model output.
+// Unless the member opts out, the prompt is sent with a system context
+// built from their profile, which the page shows back to them.
 func AiChatHandler(w http.ResponseWriter, r *http.Request) {
+	tmpl := htmltemplate.Must(htmltemplate.ParseFiles("templates/chat.html"))
+	user := currentUser(r)
+
 	if r.Method == http.MethodGet {
-		tmpl := template.Must(template.ParseFiles("templates/chat.html"))
-		tmpl.Execute(w, nil)
+		data := map[string]string{"Share": "on"}
+		data["Context"] = coachSystemPrompt(user)
+		tmpl.Execute(w, data)
 		return
 	}
 
 	if r.Method == http.MethodPost {
 		r.ParseForm()
-		prompt := r.FormValue("prompt")
-
-		reqBody := CohereRequest{
-			Model:       "command", // Or "command-nightly"
-			Prompt:      prompt,
-			MaxTokens:   100,
-			Temperature: 0.7,
+		prompt := strings.TrimSpace(r.FormValue("prompt"))
+		data := map[string]string{"Prompt": prompt, "Share": r.FormValue("share")}
+		if prompt == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			data["Error"] = "Please enter a message."
+			tmpl.Execute(w, data)
+			return
 		}
-		body, _ := json.Marshal(reqBody)
 
-		req, err := http.NewRequest("POST", cohereURL, bytes.NewBuffer(body))
-		if err != nil {
-			http.Error(w, "Failed to create request", http.StatusInternalServerError)
-			return
+		var messages []ai.Message
+		if data["Share"] == "on" {
+			data["Context"] = coachSystemPrompt(user)
+			if data["Context"] != "" {
+				messages = append(messages, ai.Message{Role: ai.RoleSystem, Content: data["Context"]})
+			}
 		}
-		req.Header.Set("Authorization", "Bearer "+cohereAPIKey)
-		req.Header.Set("Content-Type", "application/json")
+		messages = append(messages, ai.Message{Role: ai.RoleUser, Content: prompt})
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		reply, err := aiProvider.Chat(r.Context(), messages, ai.Options{})
 		if err != nil {
-			http.Error(w, "Cohere API request failed", http.StatusInternalServerError)
+			log.Printf("❌ %s request failed: %v", aiProvider.Name(), err)
+			w.WriteHeader(http.StatusBadGateway)
+			data["Error"] = "The AI coach is unavailable right now. Please try again in a moment."
+			tmpl.Execute(w, data)
 			return
 		}
-		defer resp.Body.Close()
 
-		respBody, _ := ioutil.ReadAll(resp.Body)
-		var cohereResp CohereResponse
-		json.Unmarshal(respBody, &cohereResp)
+		delete(data, "Prompt")
+		data["Response"] = reply
+		tmpl.Execute(w, data)
+	}
+}
 
-		tmpl := template.Must(template.ParseFiles("templates/chat.html"))
-		tmpl.Execute(w, map[string]string{
-			"Response": cohereResp.Generations[0].Text,
-		})
+// coachSystemPrompt renders the AI context for a member, or "" when there
+// is no profile to share or the template is broken
+func coachSystemPrompt(user *CurrentUser) string {
+	if user.Role != RoleMember {
+		return ""
+	}
+	c, err := buildCoachContext(user)
+	if err != nil {
+		log.Printf("❌ Failed to build AI context for %s: %v", user.Username, err)
+		return ""
 	}
+	prompt, err := renderCoachContext(c)
+	if err != nil {
+		log.Printf("❌ Failed to render AI context template: %v", err)
+		return ""
+	}
+	return prompt
 }
 
 func LogoutHandler(w http.ResponseWriter, r *http.Request) {
-	session, _ := store.Get(r, "fitnesscoach.com")
+	session, _ := store.Get(r, sessionName)
 	session.Options.MaxAge = -1
 	session.Save(r, w)
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
 }
-
-func generateRandomString(length int) string {
-	bytes := make([]byte, length)
-	_, err := rand.Read(bytes)
-	if err != nil {
-		return "fallbacksecret"
-	}
-	return hex.EncodeToString(bytes)
-}
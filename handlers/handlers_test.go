@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fitnesscoach/db"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChatHistoryPages(t *testing.T) {
+	useMemoryDB(t)
+	u := people(t, map[string]string{"co": RoleCoach, "al": RoleMember, "bo": RoleMember})
+	link(t, u["co"], u["al"])
+	link(t, u["co"], u["bo"])
+
+	// Seven messages between co and al, alternating, with bo's in between
+	var ids []int64
+	sentAt := map[int64]time.Time{}
+	for i := 1; i <= 7; i++ {
+		from, to := u["co"], u["al"]
+		if i%2 == 0 {
+			from, to = to, from
+		}
+		id, at, err := db.SendMessage(from.ID, to.ID, fmt.Sprintf("message %d", i))
+		if err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+		ids = append(ids, id)
+		sentAt[id] = at
+		if _, _, err := db.SendMessage(u["bo"].ID, u["co"].ID, "from bo"); err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []int64
+		more  bool
+	}{
+		{"newest", "limit=3", ids[4:], true},
+		{"before", fmt.Sprintf("limit=3&before=%d", ids[4]), ids[1:4], true},
+		{"oldest", fmt.Sprintf("limit=3&before=%d", ids[1]), ids[:1], false},
+		{"after", fmt.Sprintf("limit=3&after=%d", ids[1]), ids[2:5], true},
+		{"newer", fmt.Sprintf("limit=3&after=%d", ids[4]), ids[5:], false},
+		{"default limit", "", ids, false},
+		{"limit out of range", "limit=1000", ids, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := call(ChatHistoryHandler, u["al"], http.MethodGet, "/chat-history?receiver=co&"+tt.query, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body)
+			}
+			var body struct {
+				Messages []struct {
+					ID      int64
+					Sender  string
+					Content string
+					Time    string
+				}
+				HasMore bool
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding: %v", err)
+			}
+			var got []int64
+			for _, m := range body.Messages {
+				got = append(got, m.ID)
+				if at, err := time.Parse(time.RFC3339, m.Time); err != nil {
+					t.Errorf("message %d time %q is not RFC 3339", m.ID, m.Time)
+				} else if !at.Equal(sentAt[m.ID]) {
+					t.Errorf("message %d time %s, but it was sent at %s", m.ID, at, sentAt[m.ID])
+				}
+				if m.Sender != "co" && m.Sender != "al" {
+					t.Errorf("message %d from %s leaked into the conversation", m.ID, m.Sender)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) || body.HasMore != tt.more {
+				t.Errorf("messages %v, hasMore %v; want %v, %v", got, body.HasMore, tt.want, tt.more)
+			}
+		})
+	}
+
+	t.Run("rejected", func(t *testing.T) {
+		tests := []struct {
+			user, query string
+			status      int
+		}{
+			{"al", "receiver=co&before=5&after=2", http.StatusBadRequest},
+			{"al", "receiver=co&before=latest", http.StatusBadRequest},
+			{"al", "", http.StatusBadRequest},
+			{"al", "receiver=nobody", http.StatusBadRequest},
+			{"al", "receiver=bo", http.StatusForbidden},
+			{"bo", "receiver=al", http.StatusForbidden},
+		}
+		for _, tt := range tests {
+			rec := call(ChatHistoryHandler, u[tt.user], http.MethodGet, "/chat-history?"+tt.query, "")
+			if rec.Code != tt.status {
+				t.Errorf("%s %s: status %d, want %d", tt.user, tt.query, rec.Code, tt.status)
+			}
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Time allowed to write a frame to the peer
+	writeWait = 10 * time.Second
+	// A connection is dropped when no pong arrives within pongWait
+	pongWait = 60 * time.Second
+	// Pings are sent a little more often than pongWait
+	pingPeriod = pongWait * 9 / 10
+	// Largest message accepted from a client
+	maxMessageSize = 8 << 10
+	// Outgoing frames buffered per connection before it is dropped
+	sendBuffer = 32
+)
+
+// Hub tracks every open WebSocket connection by username. A user can have
+// several connections (tabs, devices) and each receives every message.
+type Hub struct {
+	mu      sync.RWMutex
+	clients map[string]map[*Client]struct{}
+}
+
+// Client is one WebSocket connection. Only its write goroutine writes to
+// conn; everyone else queues frames on send. done is closed when the
+// connection is unregistered.
+type Client struct {
+	hub      *Hub
+	conn     *websocket.Conn
+	username string
+	send     chan []byte
+	done     chan struct{}
+	once     sync.Once
+}
+
+// NewHub returns an empty hub
+func NewHub() *Hub {
+	return &Hub{clients: make(map[string]map[*Client]struct{})}
+}
+
+var hub = NewHub()
+
+// Register adds a connection for username and starts its write goroutine
+func (h *Hub) Register(conn *websocket.Conn, username string) *Client {
+	c := &Client{hub: h, conn: conn, username: username, send: make(chan []byte, sendBuffer), done: make(chan struct{})}
+
+	h.mu.Lock()
+	if h.clients[username] == nil {
+		h.clients[username] = make(map[*Client]struct{})
+	}
+	h.clients[username][c] = struct{}{}
+	n := len(h.clients[username])
+	h.mu.Unlock()
+
+	log.Printf("🔌 %s connected (%d open)", username, n)
+	go c.writePump()
+	return c
+}
+
+// Unregister removes a connection and stops its write goroutine. It is
+// safe to call more than once.
+func (h *Hub) Unregister(c *Client) {
+	c.once.Do(func() {
+		h.mu.Lock()
+		delete(h.clients[c.username], c)
+		n := len(h.clients[c.username])
+		if n == 0 {
+			delete(h.clients, c.username)
+		}
+		h.mu.Unlock()
+
+		close(c.done)
+		log.Printf("🔌 %s disconnected (%d open)", c.username, n)
+	})
+}
+
+// SendTo queues v for every connection username has open and returns how
+// many connections it was queued on. Connections whose buffer is full are
+// too slow to keep up and are dropped.
+func (h *Hub) SendTo(username string, v any) int {
+	frame, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("❌ Failed to encode WebSocket message: %v", err)
+		return 0
+	}
+
+	h.mu.RLock()
+	var delivered int
+	var slow []*Client
+	for c := range h.clients[username] {
+		select {
+		case c.send <- frame:
+			delivered++
+		default:
+			slow = append(slow, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range slow {
+		log.Printf("🐢 Dropping slow connection for %s", c.username)
+		h.Unregister(c)
+	}
+	return delivered
+}
+
+// Send queues v for this connection only. It reports false when the
+// connection is closed or its buffer is full.
+func (c *Client) Send(v any) bool {
+	frame, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("❌ Failed to encode WebSocket message: %v", err)
+		return false
+	}
+
+	c.hub.mu.RLock()
+	defer c.hub.mu.RUnlock()
+	if _, open := c.hub.clients[c.username][c]; !open {
+		return false
+	}
+	select {
+	case c.send <- frame:
+		return true
+	default:
+		return false
+	}
+}
+
+// SendContext queues v for this connection, waiting while its buffer is
+// full. It reports false when the connection closes or ctx ends first.
+func (c *Client) SendContext(ctx context.Context, v any) bool {
+	frame, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("❌ Failed to encode WebSocket message: %v", err)
+		return false
+	}
+
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+	select {
+	case c.send <- frame:
+		return true
+	case <-c.done:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Online reports whether username has at least one open connection
+func (h *Hub) Online(username string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients[username]) > 0
+}
+
+// readLoop hands every incoming frame to handle until the connection
+// fails or stops answering pings
+func (c *Client) readLoop(handle func(frame []byte)) {
+	defer func() {
+		c.hub.Unregister(c)
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, frame, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("WebSocket read error: %v", err)
+			}
+			return
+		}
+		handle(frame)
+	}
+}
+
+// writePump is the only writer of the connection: it sends queued frames
+// and periodic pings until the connection is unregistered
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+			return
+		case frame := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+				log.Printf("WebSocket write error: %v", err)
+				c.hub.Unregister(c)
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.hub.Unregister(c)
+				return
+			}
+		}
+	}
+}
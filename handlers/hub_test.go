@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// queuedClient is a registered connection without a socket whose buffer
+// holds one frame
+func queuedClient(h *Hub, username string) *Client {
+	c := &Client{hub: h, username: username, send: make(chan []byte, 1), done: make(chan struct{})}
+	h.clients[username] = map[*Client]struct{}{c: {}}
+	return c
+}
+
+func TestSendContext(t *testing.T) {
+	h := NewHub()
+	c := queuedClient(h, "al")
+	if !c.Send("first") {
+		t.Fatal("Send to an empty buffer failed")
+	}
+	if c.Send("dropped") {
+		t.Fatal("Send to a full buffer succeeded")
+	}
+
+	// A full buffer makes SendContext wait until the writer catches up
+	sent := make(chan bool)
+	go func() { sent <- c.SendContext(context.Background(), "second") }()
+	select {
+	case <-sent:
+		t.Fatal("SendContext returned while the buffer was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if frame := <-c.send; string(frame) != `"first"` {
+		t.Errorf("first frame = %s", frame)
+	}
+	if !<-sent {
+		t.Fatal("SendContext failed once there was room")
+	}
+
+	// and gives up when its context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if c.SendContext(ctx, "late") {
+		t.Error("SendContext succeeded on a full buffer after its context ended")
+	}
+
+	// or the connection closes
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		h.Unregister(c)
+	}()
+	if c.SendContext(context.Background(), "closed") {
+		t.Error("SendContext succeeded on a full buffer of a closed connection")
+	}
+	<-c.send
+	if c.SendContext(context.Background(), "after close") || c.Send("after close") {
+		t.Error("sending to a closed connection succeeded")
+	}
+}
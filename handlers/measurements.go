@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"fitnesscoach/db"
+	"log"
+	"net/http"
+	"time"
+)
+
+// MeasurementsHandler lists, adds, edits and deletes the member's body
+// measurements. PUT and DELETE take the entry ID in the "id" parameter.
+func MeasurementsHandler(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		from, err := queryDate(r, "from")
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "from must be YYYY-MM-DD")
+			return
+		}
+		to, err := queryDate(r, "to")
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "to must be YYYY-MM-DD")
+			return
+		}
+		if !to.IsZero() {
+			to = to.AddDate(0, 0, 1) // make the end date inclusive
+		}
+
+		measurements, err := db.ListMeasurements(user.ID, from, to)
+		if err != nil {
+			log.Printf("❌ Failed to list measurements: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load measurements")
+			return
+		}
+		if measurements == nil {
+			measurements = []db.BodyMeasurement{}
+		}
+		writeJSON(w, http.StatusOK, measurements)
+
+	case http.MethodPost:
+		var m db.BodyMeasurement
+		if err := decodeJSON(w, r, &m); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if msg := validateMeasurement(m); msg != "" {
+			writeJSONError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		id, err := db.AddMeasurement(user.ID, m)
+		if err != nil {
+			log.Printf("❌ Failed to save measurement: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to save measurement")
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]int64{"id": id})
+
+	case http.MethodPut:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		var m db.BodyMeasurement
+		if err := decodeJSON(w, r, &m); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if msg := validateMeasurement(m); msg != "" {
+			writeJSONError(w, http.StatusBadRequest, msg)
+			return
+		}
+		if m.MeasuredAt.IsZero() {
+			writeJSONError(w, http.StatusBadRequest, "measuredAt is required")
+			return
+		}
+		m.ID = id
+
+		err := db.UpdateMeasurement(user.ID, m)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "measurement not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to update measurement: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to update measurement")
+			return
+		}
+		writeJSON(w, http.StatusOK, m)
+
+	case http.MethodDelete:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.DeleteMeasurement(user.ID, id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "measurement not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to delete measurement: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to delete measurement")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// MeasurementTrendHandler returns the 7-day moving average and weekly change
+// of the member's weight over the last 90 days.
+func MeasurementTrendHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	trend, err := weightTrend(currentUser(r).ID)
+	if err != nil {
+		log.Printf("❌ Failed to compute weight trend: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load trend")
+		return
+	}
+	writeJSON(w, http.StatusOK, trend)
+}
+
+func weightTrend(userID int64) (db.WeightTrend, error) {
+	measurements, err := db.ListMeasurements(userID, time.Now().AddDate(0, 0, -90), time.Time{})
+	if err != nil {
+		return db.WeightTrend{}, err
+	}
+	return db.ComputeWeightTrend(measurements), nil
+}
+
+func validateMeasurement(m db.BodyMeasurement) string {
+	values := []*float64{m.WeightKG, m.BodyFatPct, m.WaistCM, m.ChestCM, m.HipsCM, m.ArmCM, m.ThighCM, m.NeckCM}
+	empty := true
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		if *v <= 0 {
+			return "measurements must be positive"
+		}
+		empty = false
+	}
+	if empty {
+		return "at least one measurement is required"
+	}
+	if m.BodyFatPct != nil && *m.BodyFatPct >= 100 {
+		return "bodyFatPct must be below 100"
+	}
+	if m.MeasuredAt.After(time.Now().Add(time.Hour)) {
+		return "measuredAt cannot be in the future"
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"fitnesscoach/db"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MembersHandler searches the members a coach works with, or every member
+// for admins. It replaces /all-user-info for the coach dashboard:
+//
+//	q                     part of the full name or username
+//	gender                exact gender, any case
+//	minAge, maxAge        age range in years
+//	minBmi, maxBmi        BMI range
+//	activeSince           YYYY-MM-DD of the earliest last activity
+//	sort                  name, username, age, bmi or lastActive
+//	order                 asc (default) or desc
+//	limit, cursor         page size (default 20, max 100) and nextCursor
+func MembersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	f := db.MemberFilter{
+		Search: strings.TrimSpace(q.Get("q")),
+		Gender: strings.TrimSpace(q.Get("gender")),
+		Sort:   q.Get("sort"),
+		Cursor: q.Get("cursor"),
+	}
+	if user := currentUser(r); user.Role != RoleAdmin {
+		f.CoachID = user.ID
+	}
+
+	var problems []string
+	for _, key := range []string{"minAge", "maxAge"} {
+		if v := q.Get(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				problems = append(problems, key+" must be a whole number of years")
+			}
+			if key == "minAge" {
+				f.MinAge = n
+			} else {
+				f.MaxAge = n
+			}
+		}
+	}
+	for _, key := range []string{"minBmi", "maxBmi"} {
+		if v := q.Get(key); v != "" {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil || n < 0 {
+				problems = append(problems, key+" must be a positive number")
+			}
+			if key == "minBmi" {
+				f.MinBMI = n
+			} else {
+				f.MaxBMI = n
+			}
+		}
+	}
+	if since, err := queryDate(r, "activeSince"); err != nil {
+		problems = append(problems, "activeSince must be YYYY-MM-DD")
+	} else if !since.IsZero() {
+		f.ActiveSince = since.Format("2006-01-02")
+	}
+	switch f.Sort {
+	case "", db.SortByName, db.SortByUsername, db.SortByAge, db.SortByBMI, db.SortByLastActive:
+	default:
+		problems = append(problems, "sort must be name, username, age, bmi or lastActive")
+	}
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		f.Desc = true
+	default:
+		problems = append(problems, "order must be asc or desc")
+	}
+	if len(problems) > 0 {
+		writeJSONError(w, http.StatusBadRequest, strings.Join(problems, "; "))
+		return
+	}
+
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	f.Limit = limit
+
+	page, err := db.SearchMembers(f)
+	if errors.Is(err, db.ErrBadCursor) {
+		writeJSONError(w, http.StatusBadRequest, "invalid cursor, start again from the first page")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to search members: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load members")
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fitnesscoach/db"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// searchMembers runs MembersHandler and returns the page's usernames
+func searchMembers(t *testing.T, user *CurrentUser, query string) ([]string, string, *http.Response) {
+	t.Helper()
+	rec := call(MembersHandler, user, http.MethodGet, "/members?"+query, "")
+	if rec.Code != http.StatusOK {
+		return nil, "", rec.Result()
+	}
+	var page db.MemberPage
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("decoding %s: %v", query, err)
+	}
+	usernames := []string{}
+	for _, m := range page.Members {
+		usernames = append(usernames, m.Username)
+	}
+	return usernames, page.NextCursor, rec.Result()
+}
+
+func TestMembersSearch(t *testing.T) {
+	useMemoryDB(t)
+	u := people(t, map[string]string{
+		"co": RoleCoach, "co2": RoleCoach, "ad": RoleAdmin,
+		"al": RoleMember, "bo": RoleMember, "cy": RoleMember, "di": RoleMember,
+	})
+	profiles := []struct {
+		username, name string
+		age            int
+		gender         string
+		height, weight float64
+	}{
+		{"al", "Alice Moss", 30, "Female", 165, 60},
+		{"bo", "Bob Stone", 45, "Male", 180, 90},
+		{"cy", "Cy 100% Alder", 22, "male", 0, 70},
+		{"di", "Di Park", 38, "female", 170, 80},
+	}
+	for _, p := range profiles {
+		if err := db.InsertUserInfo(u[p.username].ID, p.name, p.age, p.gender, p.height, p.weight); err != nil {
+			t.Fatalf("InsertUserInfo(%s): %v", p.username, err)
+		}
+	}
+	for _, member := range []string{"al", "bo", "cy"} {
+		link(t, u["co"], u[member])
+	}
+	// di has only been invited
+	if _, err := db.LinkCoach(u["co"].ID, u["di"].ID, RoleCoach); err != nil {
+		t.Fatalf("LinkCoach: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		user  string
+		query string
+		want  []string
+	}{
+		{"coach sees active clients", "co", "", []string{"al", "bo", "cy"}},
+		{"admin sees everyone", "ad", "", []string{"al", "bo", "cy", "di"}},
+		{"coach without clients", "co2", "", []string{}},
+		{"search name", "co", "q=stone", []string{"bo"}},
+		{"search username", "co", "q=BO", []string{"bo"}},
+		{"search escapes wildcards", "co", "q=" + url.QueryEscape("100%"), []string{"cy"}},
+		{"gender ignores case", "co", "gender=MALE", []string{"bo", "cy"}},
+		{"age range", "co", "minAge=25&maxAge=40", []string{"al"}},
+		{"bmi skips missing heights", "co", "minBmi=20", []string{"al", "bo"}},
+		{"sort by age descending", "co", "sort=age&order=desc", []string{"bo", "al", "cy"}},
+		{"sort by bmi", "co", "sort=bmi", []string{"cy", "al", "bo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, next, res := searchMembers(t, u[tt.user], tt.query)
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("status %d", res.StatusCode)
+			}
+			if !reflect.DeepEqual(got, tt.want) || next != "" {
+				t.Errorf("members = %v, next %q; want %v on one page", got, next, tt.want)
+			}
+		})
+	}
+
+	t.Run("pages", func(t *testing.T) {
+		for _, query := range []string{"sort=name", "sort=age&order=desc", "sort=bmi", "sort=username&order=desc"} {
+			all, _, res := searchMembers(t, u["ad"], query)
+			if res.StatusCode != http.StatusOK || len(all) != 4 {
+				t.Fatalf("%s: status %d, members %v", query, res.StatusCode, all)
+			}
+			var paged []string
+			cursor := ""
+			for i := 0; i < len(all); i++ {
+				got, next, res := searchMembers(t, u["ad"], query+"&limit=1&cursor="+url.QueryEscape(cursor))
+				if res.StatusCode != http.StatusOK || len(got) != 1 {
+					t.Fatalf("%s page %d: status %d, members %v", query, i+1, res.StatusCode, got)
+				}
+				paged = append(paged, got...)
+				cursor = next
+			}
+			if cursor != "" {
+				t.Errorf("%s: last page has a next cursor", query)
+			}
+			if !reflect.DeepEqual(paged, all) {
+				t.Errorf("%s: pages = %v, want %v", query, paged, all)
+			}
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		_, byAge, _ := searchMembers(t, u["co"], "sort=age&limit=1")
+		tests := []struct {
+			query string
+			err   string
+		}{
+			{"sort=weight", "sort must be"},
+			{"order=up", "order must be"},
+			{"minAge=old", "minAge must be"},
+			{"maxBmi=-1", "maxBmi must be"},
+			{"activeSince=yesterday", "activeSince must be"},
+			{"cursor=garbage", "invalid cursor"},
+			{"sort=name&cursor=" + byAge, "invalid cursor"},
+			{"sort=age&order=desc&cursor=" + byAge, "invalid cursor"},
+		}
+		for _, tt := range tests {
+			rec := call(MembersHandler, u["co"], http.MethodGet, "/members?"+tt.query, "")
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.err) {
+				t.Errorf("%s: status %d, body %s; want 400 %q", tt.query, rec.Code, rec.Body, tt.err)
+			}
+		}
+	})
+}
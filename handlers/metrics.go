@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"fitnesscoach/db"
+	"fitnesscoach/metrics"
+	"log"
+	"net/http"
+	"slices"
+)
+
+// MetricsHandler serves a member's BMI, BMR, TDEE and macro targets.
+// Coaches pass ?username= for their clients:
+//
+//	GET    the metrics, 404 while the profile lacks weight, height or age
+//	PUT    set {activity, goal} and recalculate
+func MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := memberID(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeMetrics(w, userID)
+
+	case http.MethodPut:
+		var req struct {
+			Activity string `json:"activity"`
+			Goal     string `json:"goal"`
+		}
+		if err := decodeJSON(w, r, &req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if !slices.Contains(metrics.ActivityLevels, req.Activity) {
+			writeJSONError(w, http.StatusBadRequest, "activity must be one of sedentary, light, moderate, active, very_active")
+			return
+		}
+		if !slices.Contains(metrics.Goals, req.Goal) {
+			writeJSONError(w, http.StatusBadRequest, "goal must be one of lose, maintain, gain")
+			return
+		}
+		err := db.SetMetricsSettings(userID, req.Activity, req.Goal)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "fill in the profile first")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to save metrics settings: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to save settings")
+			return
+		}
+		writeMetrics(w, userID)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// writeMetrics writes the member's metrics, working them out first for
+// profiles saved before the calculator existed
+func writeMetrics(w http.ResponseWriter, userID int64) {
+	m, err := db.GetMetrics(userID)
+	if errors.Is(err, db.ErrNotFound) {
+		if err = db.RecalculateMetrics(userID); err == nil {
+			m, err = db.GetMetrics(userID)
+		}
+	}
+	if errors.Is(err, db.ErrNotFound) {
+		writeJSONError(w, http.StatusNotFound, "add weight, height and age to the profile to see metrics")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load metrics: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load metrics")
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
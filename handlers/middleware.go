@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"fitnesscoach/db"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Roles a person can have
+const (
+	RoleMember = "member"
+	RoleCoach  = "coach"
+	RoleAdmin  = "admin"
+)
+
+// CurrentUser is the authenticated user loaded from the session
+type CurrentUser struct {
+	ID       int64
+	Username string
+	Role     string
+}
+
+// HasRole reports whether the user holds one of roles. Admins pass every
+// role check and an empty list accepts any authenticated user.
+func (u *CurrentUser) HasRole(roles ...string) bool {
+	if len(roles) == 0 || u.Role == RoleAdmin {
+		return true
+	}
+	for _, role := range roles {
+		if u.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey int
+
+const currentUserKey contextKey = iota
+
+// currentUser returns the user put in the request context by the auth
+// middleware, or nil on public routes.
+func currentUser(r *http.Request) *CurrentUser {
+	user, _ := r.Context().Value(currentUserKey).(*CurrentUser)
+	return user
+}
+
+// loadUser reads the logged-in user from the session cookie
+func loadUser(r *http.Request) *CurrentUser {
+	session, _ := store.Get(r, sessionName)
+	isAuthenticated, ok := session.Values["authenticatedUser"].(bool)
+	if !ok || !isAuthenticated {
+		return nil
+	}
+	username, _ := session.Values["username"].(string)
+	role, _ := session.Values["role"].(string)
+	if username == "" {
+		return nil
+	}
+
+	userID, ok := session.Values["userID"].(int64)
+	if !ok {
+		// Sessions created before user IDs were stored
+		id, err := db.GetUserIDByUsername(username)
+		if err != nil {
+			log.Printf("❌ Session user %s not found: %v", username, err)
+			return nil
+		}
+		userID = id
+	}
+	return &CurrentUser{ID: userID, Username: username, Role: role}
+}
+
+// RequirePage protects an HTML route: anonymous visitors are redirected to
+// the login page and users without one of roles get 403.
+func RequirePage(h http.HandlerFunc, roles ...string) http.HandlerFunc {
+	return requireRole(h, false, roles)
+}
+
+// RequireAPI protects a JSON route, answering 401 or 403 with a JSON body
+func RequireAPI(h http.HandlerFunc, roles ...string) http.HandlerFunc {
+	return requireRole(h, true, roles)
+}
+
+func requireRole(h http.HandlerFunc, api bool, roles []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		asJSON := api || wantsJSON(r)
+
+		user := loadUser(r)
+		if user == nil {
+			if asJSON {
+				writeJSONError(w, http.StatusUnauthorized, "authentication required")
+			} else {
+				http.Redirect(w, r, "/login", http.StatusSeeOther)
+			}
+			return
+		}
+		if !user.HasRole(roles...) {
+			log.Printf("⛔ %s (%s) denied %s", user.Username, user.Role, r.URL.Path)
+			if asJSON {
+				writeJSONError(w, http.StatusForbidden, "forbidden")
+			} else {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+			}
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), currentUserKey, user)
+		h(w, r.WithContext(ctx))
+	}
+}
+
+// wantsJSON detects fetch() calls on routes that also serve pages
+func wantsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json") ||
+		strings.Contains(r.Header.Get("Content-Type"), "application/json")
+}
+
+// memberID returns whose data a read-only request is about: members always
+// see their own, coaches name one of their clients and admins any member
+// with ?username=. It writes the error response and returns false when the
+// request is invalid.
+func memberID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	user := currentUser(r)
+	if user.Role == RoleMember {
+		return user.ID, true
+	}
+
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		writeJSONError(w, http.StatusBadRequest, "username is required")
+		return 0, false
+	}
+	id, err := db.GetUserIDByUsername(username)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "member not found")
+		return 0, false
+	}
+	if !coachesMember(user, id) {
+		writeJSONError(w, http.StatusForbidden, username+" is not one of your clients")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fitnesscoach/db"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// createUsers adds a user per role, all with the password "secret"
+func createUsers(t *testing.T, roles map[string]string) map[string]int64 {
+	t.Helper()
+	ids := make(map[string]int64, len(roles))
+	for username, role := range roles {
+		id, err := db.CreateUser(username, username+"@example.com", "secret", role)
+		if err != nil {
+			t.Fatalf("CreateUser(%s): %v", username, err)
+		}
+		ids[username] = id
+	}
+	return ids
+}
+
+// serve runs h for a request carrying the cookie, if any
+func serve(h http.HandlerFunc, method, target string, cookie *http.Cookie, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+func TestRequireRole(t *testing.T) {
+	useMemoryDB(t)
+	initSessions(keyPair(1, 2))
+	ids := createUsers(t, map[string]string{"al": RoleMember, "co": RoleCoach, "ad": RoleAdmin})
+	cookies := map[string]*http.Cookie{}
+	for username := range ids {
+		cookies[username] = login(t, username, "secret")
+	}
+	forged := &http.Cookie{Name: sessionName, Value: "forged"}
+
+	// The wrapped handler reports who it ran as
+	var seen *CurrentUser
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		seen = currentUser(r)
+		w.WriteHeader(http.StatusNoContent)
+	}
+	jsonHeader := map[string]string{"Accept": "application/json"}
+
+	tests := []struct {
+		name     string
+		h        http.HandlerFunc
+		cookie   *http.Cookie
+		header   map[string]string
+		status   int
+		location string
+		jsonErr  string
+		user     string
+	}{
+		{name: "page, anonymous", h: RequirePage(ok), status: http.StatusSeeOther, location: "/login"},
+		{name: "page, forged cookie", h: RequirePage(ok), cookie: forged, status: http.StatusSeeOther, location: "/login"},
+		{name: "page fetched as JSON, anonymous", h: RequirePage(ok), header: jsonHeader, status: http.StatusUnauthorized, jsonErr: "authentication required"},
+		{name: "api, anonymous", h: RequireAPI(ok), status: http.StatusUnauthorized, jsonErr: "authentication required"},
+		{name: "api, forged cookie", h: RequireAPI(ok), cookie: forged, status: http.StatusUnauthorized, jsonErr: "authentication required"},
+		{name: "any role", h: RequirePage(ok), cookie: cookies["al"], status: http.StatusNoContent, user: "al"},
+		{name: "member page", h: RequirePage(ok, RoleMember), cookie: cookies["al"], status: http.StatusNoContent, user: "al"},
+		{name: "member on coach page", h: RequirePage(ok, RoleCoach), cookie: cookies["al"], status: http.StatusForbidden},
+		{name: "member on coach api", h: RequireAPI(ok, RoleCoach), cookie: cookies["al"], status: http.StatusForbidden, jsonErr: "forbidden"},
+		{name: "coach on coach api", h: RequireAPI(ok, RoleCoach), cookie: cookies["co"], status: http.StatusNoContent, user: "co"},
+		{name: "coach on member page", h: RequirePage(ok, RoleMember), cookie: cookies["co"], status: http.StatusForbidden},
+		{name: "either role", h: RequireAPI(ok, RoleMember, RoleCoach), cookie: cookies["co"], status: http.StatusNoContent, user: "co"},
+		{name: "admin passes role checks", h: RequireAPI(ok, RoleCoach), cookie: cookies["ad"], status: http.StatusNoContent, user: "ad"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = nil
+			rec := serve(tt.h, http.MethodGet, "/", tt.cookie, tt.header)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if loc := rec.Header().Get("Location"); loc != tt.location {
+				t.Errorf("Location = %q, want %q", loc, tt.location)
+			}
+			if tt.jsonErr != "" {
+				var body struct{ Error string }
+				if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Error != tt.jsonErr {
+					t.Errorf("body error = %q, %v; want %q", body.Error, err, tt.jsonErr)
+				}
+			}
+			switch {
+			case tt.user == "" && seen != nil:
+				t.Errorf("handler ran as %+v", seen)
+			case tt.user != "" && (seen == nil || seen.Username != tt.user || seen.ID != ids[tt.user]):
+				t.Errorf("handler ran as %+v, want %s (%d)", seen, tt.user, ids[tt.user])
+			}
+		})
+	}
+}
+
+// legacyCookie is a session saved before user IDs were stored in it
+func legacyCookie(t *testing.T, username, role string) *http.Cookie {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	session, _ := store.Get(req, sessionName)
+	session.Values["authenticatedUser"] = true
+	session.Values["username"] = username
+	session.Values["role"] = role
+	if err := session.Save(req, rec); err != nil {
+		t.Fatalf("saving session: %v", err)
+	}
+	return rec.Result().Cookies()[0]
+}
+
+func TestRequireRoleLegacySession(t *testing.T) {
+	useMemoryDB(t)
+	initSessions(keyPair(1, 2))
+	ids := createUsers(t, map[string]string{"al": RoleMember})
+
+	var seen *CurrentUser
+	h := RequireAPI(func(w http.ResponseWriter, r *http.Request) {
+		seen = currentUser(r)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// The ID is looked up by username
+	rec := serve(h, http.MethodGet, "/", legacyCookie(t, "al", RoleMember), nil)
+	if rec.Code != http.StatusNoContent || seen == nil || seen.ID != ids["al"] {
+		t.Errorf("status %d, user %+v; want 204 as al (%d)", rec.Code, seen, ids["al"])
+	}
+
+	// and a username that does not exist is anonymous
+	seen = nil
+	rec = serve(h, http.MethodGet, "/", legacyCookie(t, "ghost", RoleMember), nil)
+	if rec.Code != http.StatusUnauthorized || seen != nil {
+		t.Errorf("status %d, user %+v; want 401", rec.Code, seen)
+	}
+}
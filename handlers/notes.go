@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"fitnesscoach/db"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// noteRequest is the editable part of a coach note
+type noteRequest struct {
+	Body   string   `json:"body"`
+	Tags   []string `json:"tags"`
+	Pinned bool     `json:"pinned"`
+}
+
+// NotesHandler manages the coach's private notes about a client:
+//
+//	GET ?username=&tag=   the notes, pinned first
+//	POST ?username=       add a note
+//	PUT ?id=              replace a note's body, tags and pin
+//	DELETE ?id=           delete a note
+func NotesHandler(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		clientID, ok := memberID(w, r)
+		if !ok {
+			return
+		}
+		tag := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("tag")))
+		notes, err := db.ListNotes(user.ID, clientID, tag)
+		if err != nil {
+			log.Printf("❌ Failed to list notes: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load notes")
+			return
+		}
+		writeJSON(w, http.StatusOK, notes)
+
+	case http.MethodPost, http.MethodPut:
+		var req noteRequest
+		if err := decodeJSON(w, r, &req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		note, msg := validateNote(req)
+		if msg != "" {
+			writeJSONError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			clientID, ok := memberID(w, r)
+			if !ok {
+				return
+			}
+			created, err := db.CreateNote(user.ID, clientID, note)
+			if err != nil {
+				log.Printf("❌ Failed to save note: %v", err)
+				writeJSONError(w, http.StatusInternalServerError, "failed to save note")
+				return
+			}
+			writeJSON(w, http.StatusCreated, created)
+			return
+		}
+
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.UpdateNote(user.ID, id, note)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "note not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to update note: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to update note")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.DeleteNote(user.ID, id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "note not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to delete note: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to delete note")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// validateNote trims the note and normalises its tags to unique lower case
+func validateNote(req noteRequest) (db.CoachNote, string) {
+	note := db.CoachNote{Body: strings.TrimSpace(req.Body), Pinned: req.Pinned, Tags: []string{}}
+	if note.Body == "" || utf8.RuneCountInString(note.Body) > 10000 {
+		return note, "body must be 1 to 10000 characters"
+	}
+	seen := map[string]bool{}
+	for _, tag := range req.Tags {
+		tag = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#")))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > 40 {
+			return note, "tags must be at most 40 characters"
+		}
+		seen[tag] = true
+		note.Tags = append(note.Tags, tag)
+	}
+	if len(note.Tags) > 10 {
+		return note, "a note can have at most 10 tags"
+	}
+	return note, ""
+}
+
+// TimelineHandler merges a client's history for their coach, newest first.
+// Pass the returned nextBefore as ?before= to load older items.
+func TimelineHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	clientID, ok := memberID(w, r)
+	if !ok {
+		return
+	}
+
+	var before time.Time
+	if v := r.URL.Query().Get("before"); v != "" {
+		var err error
+		if before, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "before must be an RFC 3339 time")
+			return
+		}
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 30
+	}
+
+	items, err := db.ClientTimeline(currentUser(r).ID, clientID, before, limit)
+	if err != nil {
+		log.Printf("❌ Failed to build timeline: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load timeline")
+		return
+	}
+	resp := map[string]any{"items": items}
+	if len(items) >= limit {
+		resp["nextBefore"] = items[len(items)-1].At.Format(time.RFC3339Nano)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,792 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fitnesscoach/db"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// NutritionPageHandler serves the member's meal log
+func NutritionPageHandler(w http.ResponseWriter, r *http.Request) {
+	data := map[string]string{
+		"WebsiteTitle": "Nutrition",
+		"Username":     currentUser(r).Username,
+	}
+	templateRenderMap(w, data, "nutrition")
+}
+
+// FoodsHandler serves the shared food database. Anyone signed in can
+// search it; coaches and admins edit it:
+//
+//	GET ?id=           one food
+//	GET ?q=&limit=     foods whose name contains q (limit default 20, max 100)
+//	POST               add a food
+//	PUT ?id=           replace a food
+//	DELETE ?id=        remove a food
+func FoodsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && currentUser(r).Role == RoleMember {
+		writeJSONError(w, http.StatusForbidden, "only coaches can edit the food database")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if id, ok := queryID(r, "id"); ok {
+			food, err := db.GetFood(id)
+			if errors.Is(err, db.ErrNotFound) {
+				writeJSONError(w, http.StatusNotFound, "food not found")
+				return
+			}
+			if err != nil {
+				log.Printf("❌ Failed to load food: %v", err)
+				writeJSONError(w, http.StatusInternalServerError, "failed to load food")
+				return
+			}
+			writeJSON(w, http.StatusOK, food)
+			return
+		}
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit <= 0 || limit > 100 {
+			limit = 20
+		}
+		foods, err := db.SearchFoods(strings.TrimSpace(r.URL.Query().Get("q")), limit)
+		if err != nil {
+			log.Printf("❌ Failed to search foods: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to search foods")
+			return
+		}
+		writeJSON(w, http.StatusOK, foods)
+
+	case http.MethodPost, http.MethodPut:
+		var f db.Food
+		if err := decodeJSON(w, r, &f); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if msg := validateFood(&f); msg != "" {
+			writeJSONError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			created, err := db.CreateFood(f)
+			if errors.Is(err, db.ErrFoodExists) {
+				writeJSONError(w, http.StatusConflict, "a food with that name already exists")
+				return
+			}
+			if err != nil {
+				log.Printf("❌ Failed to save food: %v", err)
+				writeJSONError(w, http.StatusInternalServerError, "failed to save food")
+				return
+			}
+			writeJSON(w, http.StatusCreated, created)
+			return
+		}
+
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.UpdateFood(id, f)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			writeJSONError(w, http.StatusNotFound, "food not found")
+		case errors.Is(err, db.ErrFoodExists):
+			writeJSONError(w, http.StatusConflict, "a food with that name already exists")
+		case err != nil:
+			log.Printf("❌ Failed to update food: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to update food")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+	case http.MethodDelete:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.DeleteFood(id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "food not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to delete food: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to delete food")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// validateFood tidies a food in place
+func validateFood(f *db.Food) string {
+	f.Name = strings.Join(strings.Fields(f.Name), " ")
+	f.Serving = strings.Join(strings.Fields(f.Serving), " ")
+	switch {
+	case f.Name == "" || utf8.RuneCountInString(f.Name) > 100:
+		return "name must be 1 to 100 characters"
+	case f.Serving == "" || utf8.RuneCountInString(f.Serving) > 40:
+		return `serving must be 1 to 40 characters, such as "100 g"`
+	}
+	return checkNutrients(f.Nutrients)
+}
+
+// checkNutrients rejects amounts no single serving has
+func checkNutrients(n db.Nutrients) string {
+	for _, v := range []float64{n.Calories, n.ProteinG, n.CarbsG, n.FatG, n.FibreG} {
+		if math.IsNaN(v) || v < 0 {
+			return "nutrients cannot be negative"
+		}
+	}
+	if n.Calories > 5000 {
+		return "calories must be at most 5000 per serving"
+	}
+	if n.ProteinG > 1000 || n.CarbsG > 1000 || n.FatG > 1000 || n.FibreG > 1000 {
+		return "macronutrients must be at most 1000 g per serving"
+	}
+	return ""
+}
+
+// importProblem is a CSV line that was skipped
+type importProblem struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// foodCSVColumns maps the CSV headers the import understands to the field
+// they fill
+var foodCSVColumns = map[string]string{
+	"name": "name", "food": "name",
+	"serving": "serving", "servingsize": "serving",
+	"calories": "calories", "kcal": "calories", "energy": "calories", "energykcal": "calories",
+	"protein": "protein", "proteing": "protein",
+	"carbs": "carbs", "carbsg": "carbs", "carbohydrate": "carbs", "carbohydrates": "carbs",
+	"fat": "fat", "fatg": "fat",
+	"fibre": "fibre", "fibreg": "fibre", "fiber": "fibre", "fiberg": "fibre",
+}
+
+// FoodImportHandler adds foods to the database from a CSV request body.
+// The header names the columns: name and calories are required; serving,
+// protein, carbs, fat and fibre are optional. Foods that already exist by
+// name are updated. Lines that don't parse are skipped and reported.
+func FoodImportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	foods, problems, err := parseFoodCSV(http.MaxBytesReader(w, r.Body, 5<<20))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(foods) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "no foods to import", "skipped": problems})
+		return
+	}
+
+	created, updated, err := db.ImportFoods(foods)
+	if err != nil {
+		log.Printf("❌ Failed to import foods: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to import foods")
+		return
+	}
+	log.Printf("🥗 %s imported %d new and %d updated foods", currentUser(r).Username, created, updated)
+	writeJSON(w, http.StatusOK, map[string]any{"created": created, "updated": updated, "skipped": problems})
+}
+
+// parseFoodCSV reads foods from CSV with a header line
+func parseFoodCSV(body io.Reader) ([]db.Food, []importProblem, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, errors.New("the CSV needs a header line")
+	}
+	columns := map[string]int{}
+	for i, h := range header {
+		key := strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' {
+				return r
+			}
+			return -1
+		}, strings.ToLower(strings.TrimPrefix(h, "\ufeff")))
+		if field, ok := foodCSVColumns[key]; ok {
+			columns[field] = i
+		}
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, nil, errors.New("the CSV header needs a name column")
+	}
+	if _, ok := columns["calories"]; !ok {
+		return nil, nil, errors.New("the CSV header needs a calories column")
+	}
+
+	foods := []db.Food{}
+	problems := []importProblem{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			problems = append(problems, importProblem{Line: parseErr.StartLine, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, nil, errors.New("the CSV could not be read, it may be over 5 MB")
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		f := db.Food{Name: field("name"), Serving: field("serving")}
+		if f.Serving == "" {
+			f.Serving = "1 serving"
+		}
+		var bad string
+		for _, c := range []struct {
+			name string
+			into *float64
+		}{
+			{"calories", &f.Calories}, {"protein", &f.ProteinG}, {"carbs", &f.CarbsG}, {"fat", &f.FatG}, {"fibre", &f.FibreG},
+		} {
+			v := field(c.name)
+			if v == "" && c.name != "calories" {
+				continue
+			}
+			n, err := strconv.ParseFloat(strings.ReplaceAll(v, ",", "."), 64)
+			if err != nil {
+				bad = fmt.Sprintf("%s %q is not a number", c.name, v)
+				break
+			}
+			*c.into = n
+		}
+		if bad == "" {
+			bad = validateFood(&f)
+		}
+		if bad != "" {
+			problems = append(problems, importProblem{Line: line, Error: bad})
+			continue
+		}
+		foods = append(foods, f)
+	}
+	return foods, problems, nil
+}
+
+// NutritionDayHandler returns a member's meals on ?date= (default today)
+// with totals against their targets. Coaches pass ?username=.
+func NutritionDayHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	userID, ok := memberID(w, r)
+	if !ok {
+		return
+	}
+	date, err := queryDate(r, "date")
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "date must be YYYY-MM-DD")
+		return
+	}
+	day := date.Format("2006-01-02")
+	if date.IsZero() {
+		day, _ = checkInDate("")
+	}
+
+	nutrition, err := db.GetNutritionDay(userID, day)
+	if err != nil {
+		log.Printf("❌ Failed to load nutrition day: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load meals")
+		return
+	}
+	writeJSON(w, http.StatusOK, nutrition)
+}
+
+// foodEntryRequest logs one item: a food or recipe from the database by
+// ID, or a custom item by name with nutrients per serving
+type foodEntryRequest struct {
+	Date     string  `json:"date"`
+	Meal     string  `json:"meal"`
+	FoodID   *int64  `json:"foodId"`
+	RecipeID *int64  `json:"recipeId"`
+	Name     string  `json:"name"`
+	Servings float64 `json:"servings"`
+	db.Nutrients
+}
+
+// FoodEntriesHandler edits the member's meal log:
+//
+//	POST          log a food, a recipe portion or a custom item
+//	PUT ?id=      change an entry's meal and servings
+//	DELETE ?id=   remove an entry
+func FoodEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	switch r.Method {
+	case http.MethodPost:
+		var req foodEntryRequest
+		if err := decodeJSON(w, r, &req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		date, meal, msg := checkMeal(req.Date, req.Meal)
+		if msg == "" {
+			msg = checkServings(&req.Servings)
+		}
+		if msg != "" {
+			writeJSONError(w, http.StatusBadRequest, msg)
+			return
+		}
+		item, status, msg := mealItem(user.ID, req)
+		if msg != "" {
+			writeJSONError(w, status, msg)
+			return
+		}
+
+		entries, err := db.AddFoodEntries(user.ID, date, meal, []db.MealItem{item})
+		if err != nil {
+			log.Printf("❌ Failed to log food: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to log food")
+			return
+		}
+		writeJSON(w, http.StatusCreated, entries[0])
+
+	case http.MethodPut:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		var req struct {
+			Meal     string  `json:"meal"`
+			Servings float64 `json:"servings"`
+		}
+		if err := decodeJSON(w, r, &req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		_, meal, msg := checkMeal("", req.Meal)
+		if msg == "" {
+			msg = checkServings(&req.Servings)
+		}
+		if msg != "" {
+			writeJSONError(w, http.StatusBadRequest, msg)
+			return
+		}
+		err := db.UpdateFoodEntry(user.ID, id, meal, req.Servings)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "entry not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to update food entry: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to update entry")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.DeleteFoodEntry(user.ID, id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "entry not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to delete food entry: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to delete entry")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// checkMeal validates the day (default today, never in the future) and
+// the meal of an entry
+func checkMeal(date, meal string) (string, string, string) {
+	date, err := checkInDate(date)
+	if err != nil {
+		return "", "", err.Error()
+	}
+	meal = strings.ToLower(strings.TrimSpace(meal))
+	if !slices.Contains(db.Meals, meal) {
+		return "", "", "meal must be one of " + strings.Join(db.Meals, ", ")
+	}
+	return date, meal, ""
+}
+
+// checkServings defaults servings to one
+func checkServings(servings *float64) string {
+	if *servings == 0 {
+		*servings = 1
+	}
+	if math.IsNaN(*servings) || *servings < 0 || *servings > 50 {
+		return "servings must be more than 0 and at most 50"
+	}
+	return ""
+}
+
+// mealItem works out what a logged item contains, returning an HTTP status
+// and message when it can't
+func mealItem(userID int64, req foodEntryRequest) (db.MealItem, int, string) {
+	item := db.MealItem{FoodID: req.FoodID, RecipeID: req.RecipeID, Servings: req.Servings}
+	switch {
+	case req.FoodID != nil && req.RecipeID != nil:
+		return item, http.StatusBadRequest, "give a foodId or a recipeId, not both"
+
+	case req.FoodID != nil:
+		food, err := db.GetFood(*req.FoodID)
+		if errors.Is(err, db.ErrNotFound) {
+			return item, http.StatusNotFound, "food not found"
+		}
+		if err != nil {
+			log.Printf("❌ Failed to load food: %v", err)
+			return item, http.StatusInternalServerError, "failed to load food"
+		}
+		item.Name, item.Nutrients = food.Name, food.Scale(req.Servings)
+
+	case req.RecipeID != nil:
+		recipe, err := db.GetRecipe(userID, *req.RecipeID)
+		if errors.Is(err, db.ErrNotFound) {
+			return item, http.StatusNotFound, "recipe not found"
+		}
+		if err != nil {
+			log.Printf("❌ Failed to load recipe: %v", err)
+			return item, http.StatusInternalServerError, "failed to load recipe"
+		}
+		item.Name, item.Nutrients = recipe.Name, recipe.PerPortion.Scale(req.Servings)
+
+	default:
+		item.Name = strings.Join(strings.Fields(req.Name), " ")
+		if item.Name == "" || utf8.RuneCountInString(item.Name) > 100 {
+			return item, http.StatusBadRequest, "name must be 1 to 100 characters, or give a foodId or recipeId"
+		}
+		if msg := checkNutrients(req.Nutrients); msg != "" {
+			return item, http.StatusBadRequest, msg
+		}
+		item.Nutrients = req.Nutrients.Scale(req.Servings)
+	}
+	return item, 0, ""
+}
+
+// NutritionTargetsHandler reads (GET) or replaces (PUT) a member's daily
+// targets. Coaches pass ?username= for their clients; null clears a target.
+func NutritionTargetsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := memberID(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		targets, err := db.GetNutritionTargets(userID)
+		if err != nil {
+			log.Printf("❌ Failed to load nutrition targets: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load targets")
+			return
+		}
+		writeJSON(w, http.StatusOK, targets)
+
+	case http.MethodPut:
+		var t db.NutritionTargets
+		if err := decodeJSON(w, r, &t); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if t.Calories != nil && (*t.Calories < 500 || *t.Calories > 10000) {
+			writeJSONError(w, http.StatusBadRequest, "calories must be between 500 and 10000")
+			return
+		}
+		for _, v := range []*float64{t.ProteinG, t.CarbsG, t.FatG, t.FibreG} {
+			if v != nil && (*v < 0 || *v > 1000) {
+				writeJSONError(w, http.StatusBadRequest, "macronutrient targets must be between 0 and 1000 g")
+				return
+			}
+		}
+		if err := db.SetNutritionTargets(userID, t); err != nil {
+			log.Printf("❌ Failed to save nutrition targets: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to save targets")
+			return
+		}
+		writeJSON(w, http.StatusOK, t)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// RecipesHandler manages the member's recipes. Coaches may list a
+// client's with ?username=:
+//
+//	GET           the recipes with nutrients per portion
+//	POST          add a recipe
+//	PUT ?id=      replace a recipe
+//	DELETE ?id=   delete a recipe
+func RecipesHandler(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	if r.Method != http.MethodGet && user.Role != RoleMember {
+		writeJSONError(w, http.StatusForbidden, "only members can edit their recipes")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		userID, ok := memberID(w, r)
+		if !ok {
+			return
+		}
+		recipes, err := db.ListRecipes(userID)
+		if err != nil {
+			log.Printf("❌ Failed to list recipes: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load recipes")
+			return
+		}
+		writeJSON(w, http.StatusOK, recipes)
+
+	case http.MethodPost, http.MethodPut:
+		var recipe db.Recipe
+		if err := decodeJSON(w, r, &recipe); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if msg := validateRecipe(&recipe); msg != "" {
+			writeJSONError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			created, err := db.CreateRecipe(user.ID, recipe)
+			if errors.Is(err, db.ErrRecipeExists) {
+				writeJSONError(w, http.StatusConflict, "you already have a recipe with that name")
+				return
+			}
+			if err != nil {
+				log.Printf("❌ Failed to save recipe: %v", err)
+				writeJSONError(w, http.StatusInternalServerError, "failed to save recipe")
+				return
+			}
+			writeJSON(w, http.StatusCreated, created)
+			return
+		}
+
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.UpdateRecipe(user.ID, id, recipe)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			writeJSONError(w, http.StatusNotFound, "recipe not found")
+		case errors.Is(err, db.ErrRecipeExists):
+			writeJSONError(w, http.StatusConflict, "you already have a recipe with that name")
+		case err != nil:
+			log.Printf("❌ Failed to update recipe: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to update recipe")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+	case http.MethodDelete:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.DeleteRecipe(user.ID, id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "recipe not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to delete recipe: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to delete recipe")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// validateRecipe checks the name, portions and items of a recipe, and that
+// every food exists
+func validateRecipe(recipe *db.Recipe) string {
+	recipe.Name = strings.Join(strings.Fields(recipe.Name), " ")
+	switch {
+	case recipe.Name == "" || utf8.RuneCountInString(recipe.Name) > 100:
+		return "name must be 1 to 100 characters"
+	case math.IsNaN(recipe.Portions) || recipe.Portions <= 0 || recipe.Portions > 100:
+		return "portions must be more than 0 and at most 100"
+	case len(recipe.Items) == 0 || len(recipe.Items) > 50:
+		return "a recipe needs between 1 and 50 foods"
+	}
+	seen := map[int64]bool{}
+	for _, item := range recipe.Items {
+		if seen[item.FoodID] {
+			return "list each food once"
+		}
+		seen[item.FoodID] = true
+		if math.IsNaN(item.Servings) || item.Servings <= 0 || item.Servings > 100 {
+			return "servings must be more than 0 and at most 100"
+		}
+		if _, err := db.GetFood(item.FoodID); err != nil {
+			return fmt.Sprintf("food %d not found", item.FoodID)
+		}
+	}
+	return ""
+}
+
+// FavouriteMealsHandler manages the member's favourite meals:
+//
+//	GET           the favourites with their items and totals
+//	POST          save what was logged for {date, meal} as {name}
+//	DELETE ?id=   delete a favourite
+func FavouriteMealsHandler(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		favourites, err := db.ListFavouriteMeals(user.ID)
+		if err != nil {
+			log.Printf("❌ Failed to list favourite meals: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load favourites")
+			return
+		}
+		writeJSON(w, http.StatusOK, favourites)
+
+	case http.MethodPost:
+		var req struct {
+			Name string `json:"name"`
+			Date string `json:"date"`
+			Meal string `json:"meal"`
+		}
+		if err := decodeJSON(w, r, &req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		name := strings.Join(strings.Fields(req.Name), " ")
+		if name == "" || utf8.RuneCountInString(name) > 100 {
+			writeJSONError(w, http.StatusBadRequest, "name must be 1 to 100 characters")
+			return
+		}
+		date, meal, msg := checkMeal(req.Date, req.Meal)
+		if msg != "" {
+			writeJSONError(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		favourite, err := db.SaveFavouriteMeal(user.ID, name, date, meal)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			writeJSONError(w, http.StatusBadRequest, "nothing was logged for that meal")
+		case errors.Is(err, db.ErrFavouriteExists):
+			writeJSONError(w, http.StatusConflict, "you already have a favourite with that name")
+		case err != nil:
+			log.Printf("❌ Failed to save favourite meal: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to save favourite")
+		default:
+			writeJSON(w, http.StatusCreated, favourite)
+		}
+
+	case http.MethodDelete:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.DeleteFavouriteMeal(user.ID, id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "favourite not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to delete favourite meal: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to delete favourite")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// LogFavouriteMealHandler logs every item of favourite ?id= for {date,
+// meal} in one go
+func LogFavouriteMealHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	id, ok := queryID(r, "id")
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	var req struct {
+		Date string `json:"date"`
+		Meal string `json:"meal"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	date, meal, msg := checkMeal(req.Date, req.Meal)
+	if msg != "" {
+		writeJSONError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	user := currentUser(r)
+	favourites, err := db.ListFavouriteMeals(user.ID)
+	if err != nil {
+		log.Printf("❌ Failed to list favourite meals: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load favourites")
+		return
+	}
+	i := slices.IndexFunc(favourites, func(f db.FavouriteMeal) bool { return f.ID == id })
+	if i < 0 {
+		writeJSONError(w, http.StatusNotFound, "favourite not found")
+		return
+	}
+
+	entries, err := db.AddFoodEntries(user.ID, date, meal, favourites[i].Items)
+	if err != nil {
+		log.Printf("❌ Failed to log favourite meal: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to log favourite")
+		return
+	}
+	writeJSON(w, http.StatusCreated, entries)
+}
@@ -0,0 +1,482 @@
+package handlers
+
+import (
+	"errors"
+	"fitnesscoach/ai"
+	"fitnesscoach/db"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ProgramsHandler lists (GET) the member's training programs, saves a
+// hand-written one (POST), replaces one (PUT ?id=) or deletes one (DELETE
+// ?id=). Coaches may list a member's programs with ?username=.
+func ProgramsHandler(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	if r.Method != http.MethodGet && user.Role != RoleMember {
+		writeJSONError(w, http.StatusForbidden, "only members can edit their program")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		userID, ok := memberID(w, r)
+		if !ok {
+			return
+		}
+		programs, err := db.ListPrograms(userID)
+		if err != nil {
+			log.Printf("❌ Failed to list programs: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load programs")
+			return
+		}
+		writeJSON(w, http.StatusOK, programs)
+
+	case http.MethodPost:
+		var p db.Program
+		if err := decodeProgram(w, r, &p); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		p.Source = db.ProgramFromMember
+		program, err := db.CreateProgram(user.ID, p)
+		if err != nil {
+			log.Printf("❌ Failed to save program: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to save program")
+			return
+		}
+		writeJSON(w, http.StatusCreated, program)
+
+	case http.MethodPut:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		var p db.Program
+		if err := decodeProgram(w, r, &p); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		err := db.UpdateProgram(user.ID, id, p)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "program not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to update program: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to update program")
+			return
+		}
+		program, err := db.GetProgram(user.ID, id)
+		if err != nil {
+			log.Printf("❌ Failed to load program: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load program")
+			return
+		}
+		writeJSON(w, http.StatusOK, program)
+
+	case http.MethodDelete:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.DeleteProgram(user.ID, id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "program not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to delete program: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to delete program")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// ProgramDetailHandler returns a program with its days: ?id=, or the
+// member's newest program (null when there is none) without it
+func ProgramDetailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	userID, ok := memberID(w, r)
+	if !ok {
+		return
+	}
+
+	var program *db.Program
+	var err error
+	if r.URL.Query().Has("id") {
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		program, err = db.GetProgram(userID, id)
+	} else {
+		program, err = db.CurrentProgram(userID)
+	}
+	if errors.Is(err, db.ErrNotFound) {
+		writeJSONError(w, http.StatusNotFound, "program not found")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load program: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load program")
+		return
+	}
+	writeJSON(w, http.StatusOK, program)
+}
+
+// PendingProgramsHandler lists the coach's clients' programs waiting for
+// approval
+func PendingProgramsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	user := currentUser(r)
+	coachID := user.ID
+	if user.Role == RoleAdmin {
+		coachID = 0
+	}
+	pending, err := db.PendingPrograms(coachID)
+	if err != nil {
+		log.Printf("❌ Failed to list pending programs: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load programs")
+		return
+	}
+	writeJSON(w, http.StatusOK, pending)
+}
+
+// ApproveProgramHandler lets a coach approve a member's program (POST ?id=)
+func ApproveProgramHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	id, ok := queryID(r, "id")
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	coach := currentUser(r)
+	owner, err := db.ProgramOwner(id)
+	if err == nil && !coachesMember(coach, owner) {
+		writeJSONError(w, http.StatusForbidden, "this program is not one of your clients'")
+		return
+	}
+	if err == nil {
+		err = db.ApproveProgram(id, coach.ID)
+	}
+	if errors.Is(err, db.ErrNotFound) {
+		writeJSONError(w, http.StatusNotFound, "program not found")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to approve program: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to approve program")
+		return
+	}
+	log.Printf("✅ %s approved program %d", coach.Username, id)
+	writeJSON(w, http.StatusOK, map[string]any{"id": id, "status": db.ProgramApproved})
+}
+
+// programAttempts is how often the AI may answer before generation fails
+const programAttempts = 3
+
+// maxProgramWeeks is the longest program, written or generated
+const maxProgramWeeks = 8
+
+// programExample is shown to the model as the shape to answer with
+const programExample = `{
+  "title": "Full body strength",
+  "weeks": 1,
+  "notes": "Warm up for 10 minutes before every session.",
+  "days": [
+    {"week": 1, "day": 1, "name": "Lower body", "exercises": [
+      {"type": "strength", "name": "Squat", "sets": 4, "reps": "6-8", "intensity": "RPE 7"},
+      {"type": "strength", "name": "Leg Curl", "sets": 3, "reps": "10-12", "intensity": "RPE 8"},
+      {"type": "cardio", "name": "cycling", "minutes": 15, "intensity": "easy pace"}
+    ]},
+    {"week": 1, "day": 3, "name": "Upper body", "exercises": [
+      {"type": "strength", "name": "Bench Press", "sets": 4, "reps": "6-8", "intensity": "RPE 7"},
+      {"type": "strength", "name": "Bent Over Row", "sets": 3, "reps": "8-10", "intensity": "RPE 7"},
+      {"type": "cardio", "name": "running", "minutes": 20, "intensity": "conversational pace"}
+    ]}
+  ]
+}`
+
+// GenerateProgramHandler asks the AI coach for a multi-week program and
+// saves it as a pending program the member can edit. Answers that break
+// the schema are sent back to the model to repair.
+func GenerateProgramHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var req struct {
+		Weeks       int    `json:"weeks"`
+		DaysPerWeek int    `json:"daysPerWeek"`
+		Focus       string `json:"focus"`
+		Share       *bool  `json:"share"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Weeks == 0 {
+		req.Weeks = 4
+	}
+	if req.DaysPerWeek == 0 {
+		req.DaysPerWeek = 3
+	}
+	req.Focus = strings.TrimSpace(req.Focus)
+	switch {
+	case req.Weeks < 1 || req.Weeks > maxProgramWeeks:
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("weeks must be between 1 and %d", maxProgramWeeks))
+		return
+	case req.DaysPerWeek < 1 || req.DaysPerWeek > 7:
+		writeJSONError(w, http.StatusBadRequest, "daysPerWeek must be between 1 and 7")
+		return
+	case utf8.RuneCountInString(req.Focus) > 200:
+		writeJSONError(w, http.StatusBadRequest, "focus must be at most 200 characters")
+		return
+	}
+
+	library, err := db.ListExercises(db.ExerciseFilter{})
+	if err != nil {
+		log.Printf("❌ Failed to load the exercise library: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load the exercise library")
+		return
+	}
+
+	user := currentUser(r)
+	system := programInstructions(library)
+	if req.Share == nil || *req.Share {
+		if profile := coachSystemPrompt(user); profile != "" {
+			system += "\n\n" + profile
+		}
+	}
+	ask := fmt.Sprintf("Write a %d-week program with %d training days per week.", req.Weeks, req.DaysPerWeek)
+	if req.Focus != "" {
+		ask += " Focus: " + req.Focus
+	}
+
+	temperature := 0.3
+	plan, err := ai.ChatJSON(r.Context(), aiProvider, []ai.Message{
+		{Role: ai.RoleSystem, Content: system},
+		{Role: ai.RoleUser, Content: ask},
+	}, ai.Options{MaxTokens: 6000, Temperature: &temperature}, programAttempts, func(p *db.Program) []string {
+		problems := checkProgram(p, library)
+		if p.Weeks != req.Weeks {
+			problems = append(problems, fmt.Sprintf("weeks must be %d, as asked", req.Weeks))
+		}
+		perWeek := map[int]int{}
+		for _, d := range p.Days {
+			perWeek[d.Week]++
+		}
+		for week := 1; week <= req.Weeks; week++ {
+			if perWeek[week] > req.DaysPerWeek {
+				problems = append(problems, fmt.Sprintf("week %d has %d training days, at most %d were asked for",
+					week, perWeek[week], req.DaysPerWeek))
+			}
+		}
+		return problems
+	})
+	if err != nil {
+		log.Printf("❌ %s could not generate a program for %s: %v", aiProvider.Name(), user.Username, err)
+		writeJSONError(w, http.StatusBadGateway, "the AI coach could not write a valid program, please try again")
+		return
+	}
+
+	plan.Source = db.ProgramFromAI
+	program, err := db.CreateProgram(user.ID, *plan)
+	if err != nil {
+		log.Printf("❌ Failed to save program: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to save program")
+		return
+	}
+	log.Printf("🤖 Generated a %d-week program for %s", program.Weeks, user.Username)
+	writeJSON(w, http.StatusCreated, program)
+}
+
+// programInstructions tells the model the schema and the exercise names
+// in the library
+func programInstructions(library []db.Exercise) string {
+	return `You are a certified strength and conditioning coach writing a training
+program for a member of a fitness club. Answer with one JSON object and
+nothing else, shaped like this example:
+
+` + programExample + `
+
+Rules:
+- "weeks" is the program length. Every week from 1 to "weeks" lists its own
+  training days, numbered 1 (Monday) to 7 (Sunday).
+- "type" is "strength" or "cardio".
+- Strength exercises must be one of: ` + strings.Join(exerciseNames(library, db.ExerciseStrength), ", ") + `.
+  Give "sets" (1-10) and "reps" as a number or a range such as "8-12".
+- Cardio exercises must be one of: ` + strings.Join(append(exerciseNames(library, db.ExerciseCardio), db.CardioOther), ", ") + `.
+  Give "minutes" (1-240).
+- "intensity" is short, such as "RPE 7", "70% 1RM" or "easy pace".
+- Progress load or volume from week to week and keep every session under
+  90 minutes.`
+}
+
+// decodeProgram reads and validates a hand-written program
+func decodeProgram(w http.ResponseWriter, r *http.Request, p *db.Program) error {
+	if err := decodeJSON(w, r, p); err != nil {
+		return errors.New("invalid request body")
+	}
+	library, err := db.ListExercises(db.ExerciseFilter{})
+	if err != nil {
+		log.Printf("❌ Failed to load the exercise library: %v", err)
+		return errors.New("failed to load the exercise library")
+	}
+	if problems := checkProgram(p, library); len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+var repsPattern = regexp.MustCompile(`^[1-9][0-9]?(-[1-9][0-9]?)?$`)
+
+// checkProgram tidies a program in place, mapping exercise names to the
+// spelling the app uses, and returns everything still wrong with it
+func checkProgram(p *db.Program, library []db.Exercise) []string {
+	var problems []string
+	p.Title = strings.TrimSpace(p.Title)
+	p.Notes = strings.TrimSpace(p.Notes)
+
+	switch {
+	case p.Title == "":
+		problems = append(problems, "title is required")
+	case utf8.RuneCountInString(p.Title) > 100:
+		problems = append(problems, "title must be at most 100 characters")
+	}
+	if utf8.RuneCountInString(p.Notes) > 2000 {
+		problems = append(problems, "notes must be at most 2000 characters")
+	}
+	if p.Weeks < 1 || p.Weeks > maxProgramWeeks {
+		problems = append(problems, fmt.Sprintf("weeks must be between 1 and %d", maxProgramWeeks))
+		return problems
+	}
+	if len(p.Days) == 0 {
+		return append(problems, "days must list at least one training day")
+	}
+
+	seen := map[[2]int]bool{}
+	trained := make([]bool, p.Weeks+1)
+	for i := range p.Days {
+		d := &p.Days[i]
+		d.Name = strings.TrimSpace(d.Name)
+		where := fmt.Sprintf("week %d day %d", d.Week, d.Day)
+
+		switch {
+		case d.Week < 1 || d.Week > p.Weeks:
+			problems = append(problems, fmt.Sprintf("%s: week must be between 1 and %d", where, p.Weeks))
+			continue
+		case d.Day < 1 || d.Day > 7:
+			problems = append(problems, where+": day must be between 1 and 7")
+			continue
+		case seen[[2]int{d.Week, d.Day}]:
+			problems = append(problems, where+": listed twice")
+			continue
+		}
+		seen[[2]int{d.Week, d.Day}] = true
+		trained[d.Week] = true
+
+		if utf8.RuneCountInString(d.Name) > 100 {
+			problems = append(problems, where+": name must be at most 100 characters")
+		}
+		if len(d.Exercises) == 0 || len(d.Exercises) > 12 {
+			problems = append(problems, where+": needs between 1 and 12 exercises")
+		}
+		for j := range d.Exercises {
+			if problem := checkProgramExercise(&d.Exercises[j], library); problem != "" {
+				problems = append(problems, fmt.Sprintf("%s exercise %d: %s", where, j+1, problem))
+			}
+		}
+	}
+	for week := 1; week <= p.Weeks; week++ {
+		if !trained[week] {
+			problems = append(problems, fmt.Sprintf("week %d has no training days", week))
+		}
+	}
+	return problems
+}
+
+func checkProgramExercise(e *db.ProgramExercise, library []db.Exercise) string {
+	e.Type = strings.ToLower(strings.TrimSpace(e.Type))
+	e.Reps = strings.ReplaceAll(strings.TrimSpace(e.Reps), " ", "")
+	e.Intensity = strings.TrimSpace(e.Intensity)
+	if utf8.RuneCountInString(e.Intensity) > 100 {
+		return "intensity must be at most 100 characters"
+	}
+
+	switch e.Type {
+	case db.ProgramStrength:
+		name, ok := strengthExercise(library, e.Name)
+		if !ok {
+			return fmt.Sprintf("%q is not one of the strength exercises", e.Name)
+		}
+		e.Name, e.Minutes = name, 0
+		switch {
+		case e.Sets < 1 || e.Sets > 10:
+			return "sets must be between 1 and 10"
+		case !repsPattern.MatchString(e.Reps):
+			return `reps must be a number or a range such as "8-12"`
+		}
+	case db.ProgramCardio:
+		name, ok := cardioActivity(library, e.Name)
+		if !ok {
+			return fmt.Sprintf("%q is not one of the cardio activities", e.Name)
+		}
+		e.Name, e.Sets, e.Reps = name, 0, ""
+		if e.Minutes < 1 || e.Minutes > 240 {
+			return "minutes must be between 1 and 240"
+		}
+	default:
+		return `type must be "strength" or "cardio"`
+	}
+	return ""
+}
+
+// strengthExercise matches name to a strength exercise in the library,
+// ignoring case
+func strengthExercise(library []db.Exercise, name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	for _, e := range library {
+		if e.Kind == db.ExerciseStrength && strings.EqualFold(e.Name, name) {
+			return e.Name, true
+		}
+	}
+	return "", false
+}
+
+// cardioActivity matches an activity key or its display name ("Jump Rope")
+// to a cardio exercise in the library
+func cardioActivity(library []db.Exercise, name string) (string, bool) {
+	key := db.ExerciseSlug(name)
+	if key == db.CardioOther {
+		return key, true
+	}
+	for _, e := range library {
+		if e.Kind == db.ExerciseCardio && (e.Slug == key || strings.EqualFold(e.Name, strings.TrimSpace(name))) {
+			return e.Slug, true
+		}
+	}
+	return "", false
+}
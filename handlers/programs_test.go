@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fitnesscoach/ai"
+	"fitnesscoach/db"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// programJSON is an answer with the given number of training days in each week
+func programJSON(daysPerWeek ...int) string {
+	var days []string
+	for week, n := range daysPerWeek {
+		for day := 1; day <= n; day++ {
+			days = append(days, fmt.Sprintf(`{"week": %d, "day": %d, "name": "Full body", "exercises": [
+				{"type": "strength", "name": "Squat", "sets": 3, "reps": "8", "intensity": "RPE 7"}]}`, week+1, day))
+		}
+	}
+	return fmt.Sprintf(`{"title": "Test plan", "weeks": %d, "days": [%s]}`, len(daysPerWeek), strings.Join(days, ","))
+}
+
+// useFakeAI answers AI requests with each reply in turn
+func useFakeAI(t *testing.T, replies ...string) *ai.Fake {
+	t.Helper()
+	n := 0
+	fake := &ai.Fake{Record: true, Reply: func([]ai.Message) string {
+		reply := replies[min(n, len(replies)-1)]
+		n++
+		return reply
+	}}
+	previous := aiProvider
+	aiProvider = fake
+	t.Cleanup(func() { aiProvider = previous })
+	return fake
+}
+
+func TestGenerateProgramShape(t *testing.T) {
+	useMemoryDB(t)
+	u := people(t, map[string]string{"al": RoleMember})
+	body := `{"weeks": 2, "daysPerWeek": 2, "share": false}`
+
+	t.Run("repaired", func(t *testing.T) {
+		fake := useFakeAI(t, programJSON(2), programJSON(2, 3), programJSON(2, 1))
+		rec := call(GenerateProgramHandler, u["al"], http.MethodPost, "/programs/generate", body)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body)
+		}
+		calls := fake.Calls()
+		if len(calls) != 3 {
+			t.Fatalf("%d calls, want 3", len(calls))
+		}
+		for i, want := range []string{"weeks must be 2", "week 2 has 3 training days, at most 2"} {
+			repair := calls[i+1][len(calls[i+1])-1].Content
+			if !strings.Contains(repair, want) {
+				t.Errorf("repair %d = %q, want it to mention %q", i+1, repair, want)
+			}
+		}
+	})
+
+	t.Run("never fits", func(t *testing.T) {
+		useFakeAI(t, programJSON(2, 2, 2))
+		rec := call(GenerateProgramHandler, u["al"], http.MethodPost, "/programs/generate", body)
+		if rec.Code != http.StatusBadGateway {
+			t.Errorf("status %d, want 502", rec.Code)
+		}
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		useFakeAI(t, programJSON(1))
+		tooLong := fmt.Sprintf(`{"weeks": %d}`, maxProgramWeeks+1)
+		rec := call(GenerateProgramHandler, u["al"], http.MethodPost, "/programs/generate", tooLong)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("generating: status %d, want 400", rec.Code)
+		}
+
+		library, err := db.ListExercises(db.ExerciseFilter{})
+		if err != nil {
+			t.Fatalf("ListExercises: %v", err)
+		}
+		weeks := make([]int, maxProgramWeeks+1)
+		for i := range weeks {
+			weeks[i] = 1
+		}
+		var p db.Program
+		if err := json.Unmarshal([]byte(programJSON(weeks...)), &p); err != nil {
+			t.Fatal(err)
+		}
+		if problems := checkProgram(&p, library); len(problems) == 0 {
+			t.Errorf("a %d-week written program passed the check", len(weeks))
+		}
+	})
+}
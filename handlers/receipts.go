@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"fitnesscoach/db"
+	"log"
+	"net/http"
+	"time"
+)
+
+// receiptFrame tells a sender, and the reader's other connections, how far
+// the reader got through the sender's messages
+type receiptFrame struct {
+	Type string `json:"type"`
+	db.Receipt
+}
+
+func sendReceipt(r db.Receipt) {
+	frame := receiptFrame{Type: "receipt", Receipt: r}
+	hub.SendTo(r.Sender, frame)
+	if r.Status == db.ReceiptRead {
+		hub.SendTo(r.Reader, frame)
+	}
+}
+
+// deliverPending marks the messages that arrived while user was offline
+// as delivered now that they have a connection
+func deliverPending(user *CurrentUser) {
+	receipts, err := db.DeliverPending(user.ID)
+	if err != nil {
+		log.Printf("❌ Failed to mark messages to %s delivered: %v", user.Username, err)
+		return
+	}
+	for _, r := range receipts {
+		r.Reader = user.Username
+		sendReceipt(r)
+	}
+}
+
+// markRead handles a "read" frame: user has read the messages msg.Receiver
+// sent them up to msg.UpTo
+func markRead(user *CurrentUser, msg Message) {
+	senderID, err := db.GetUserIDByUsername(msg.Receiver)
+	if err != nil || msg.UpTo <= 0 {
+		return
+	}
+	changed, err := db.MarkRead(senderID, user.ID, msg.UpTo)
+	if err != nil {
+		log.Printf("❌ Failed to mark messages read: %v", err)
+		return
+	}
+	if changed {
+		sendReceipt(db.Receipt{Sender: msg.Receiver, Reader: user.Username, Status: db.ReceiptRead, UpTo: msg.UpTo, At: time.Now().UTC()})
+	}
+}
+
+// UnreadCountsHandler returns the user's unread messages per sender
+func UnreadCountsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	counts, err := db.UnreadCounts(currentUser(r).ID)
+	if err != nil {
+		log.Printf("❌ Failed to count unread messages: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to count unread messages")
+		return
+	}
+	writeJSON(w, http.StatusOK, counts)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"fitnesscoach/db"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// RosterHandler manages coach–client links from either side. Coaches see
+// and invite members, members see and ask coaches:
+//
+//	GET                   the links, pending ones included
+//	POST {"user": "..."}  invite or ask someone by username or email
+//	PUT ?username=        accept their invitation or request
+//	DELETE ?username=     end the link, or decline or withdraw it
+func RosterHandler(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	if user.Role != RoleCoach && user.Role != RoleMember {
+		writeJSONError(w, http.StatusForbidden, "only coaches and members have rosters")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		var entries []db.RosterEntry
+		var err error
+		if user.Role == RoleCoach {
+			entries, err = db.ListClients(user.ID)
+		} else {
+			entries, err = db.ListCoaches(user.ID)
+		}
+		if err != nil {
+			log.Printf("❌ Failed to load roster: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load roster")
+			return
+		}
+		writeJSON(w, http.StatusOK, entries)
+
+	case http.MethodPost:
+		var req struct {
+			User string `json:"user"`
+		}
+		if err := decodeJSON(w, r, &req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		other, err := db.FindPerson(strings.TrimSpace(req.User))
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "no account with that username or email")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to look up %q: %v", req.User, err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to update roster")
+			return
+		}
+		if want := counterpartRole(user); other.Role != want {
+			writeJSONError(w, http.StatusBadRequest, other.Username+" is not a "+want)
+			return
+		}
+
+		coachID, memberID := rosterPair(user, other.ID)
+		status, err := db.LinkCoach(coachID, memberID, user.Role)
+		if errors.Is(err, db.ErrAlreadyLinked) {
+			writeJSONError(w, http.StatusConflict, "already linked with "+other.Username)
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to link %s and %s: %v", user.Username, other.Username, err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to update roster")
+			return
+		}
+		log.Printf("🤝 %s asked %s to link (%s)", user.Username, other.Username, status)
+		writeJSON(w, http.StatusCreated, map[string]string{"username": other.Username, "status": status})
+
+	case http.MethodPut, http.MethodDelete:
+		username := r.URL.Query().Get("username")
+		otherID, err := db.GetUserIDByUsername(username)
+		if username == "" || err != nil {
+			writeJSONError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		coachID, memberID := rosterPair(user, otherID)
+
+		if r.Method == http.MethodPut {
+			err = db.AcceptLink(coachID, memberID, user.Role)
+		} else {
+			err = db.RemoveLink(coachID, memberID)
+		}
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "nothing pending with "+username)
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to update roster: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to update roster")
+			return
+		}
+		if r.Method == http.MethodPut {
+			log.Printf("🤝 %s and %s are linked", user.Username, username)
+			writeJSON(w, http.StatusOK, map[string]string{"username": username, "status": db.LinkActive})
+			return
+		}
+		log.Printf("👋 %s unlinked %s", user.Username, username)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// counterpartRole is the role on the other side of user's roster
+func counterpartRole(user *CurrentUser) string {
+	if user.Role == RoleCoach {
+		return RoleMember
+	}
+	return RoleCoach
+}
+
+// rosterPair orders user and the other party as (coach, member)
+func rosterPair(user *CurrentUser, otherID int64) (coachID, memberID int64) {
+	if user.Role == RoleCoach {
+		return user.ID, otherID
+	}
+	return otherID, user.ID
+}
+
+// coachesMember reports whether user may see and act on a member's data:
+// the member themselves, admins, and coaches with the member on their
+// active roster
+func coachesMember(user *CurrentUser, memberID int64) bool {
+	switch {
+	case user.Role == RoleAdmin || user.ID == memberID:
+		return true
+	case user.Role != RoleCoach:
+		return false
+	}
+	ok, err := db.IsClient(user.ID, memberID)
+	if err != nil {
+		log.Printf("❌ Failed to check roster of %s: %v", user.Username, err)
+	}
+	return ok
+}
+
+// canChat reports whether user may message username: coaches and their
+// clients may message each other, admins anyone
+func canChat(user *CurrentUser, username string) bool {
+	if user.Role == RoleAdmin {
+		return true
+	}
+	otherID, err := db.GetUserIDByUsername(username)
+	if err != nil {
+		return false
+	}
+	coachID, memberID := user.ID, otherID
+	if user.Role != RoleCoach {
+		coachID, memberID = otherID, user.ID
+	}
+	ok, err := db.IsClient(coachID, memberID)
+	if err != nil {
+		log.Printf("❌ Failed to check roster of %s: %v", user.Username, err)
+	}
+	return ok
+}
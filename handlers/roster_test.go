@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fitnesscoach/db"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// call runs h for user with an optional JSON body
+func call(h http.HandlerFunc, user *CurrentUser, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if user != nil {
+		req = req.WithContext(context.WithValue(req.Context(), currentUserKey, user))
+	}
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+// people creates users by role and returns them as request users
+func people(t *testing.T, roles map[string]string) map[string]*CurrentUser {
+	t.Helper()
+	users := make(map[string]*CurrentUser, len(roles))
+	for username, id := range createUsers(t, roles) {
+		users[username] = &CurrentUser{ID: id, Username: username, Role: roles[username]}
+	}
+	return users
+}
+
+// link makes member an active client of coach
+func link(t *testing.T, coach, member *CurrentUser) {
+	t.Helper()
+	if _, err := db.LinkCoach(coach.ID, member.ID, RoleCoach); err != nil {
+		t.Fatalf("LinkCoach: %v", err)
+	}
+	if err := db.AcceptLink(coach.ID, member.ID, RoleMember); err != nil {
+		t.Fatalf("AcceptLink: %v", err)
+	}
+}
+
+func TestRosterInvitation(t *testing.T) {
+	useMemoryDB(t)
+	u := people(t, map[string]string{"co": RoleCoach, "al": RoleMember, "co2": RoleCoach})
+
+	steps := []struct {
+		name   string
+		user   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"coach invites member", "co", http.MethodPost, "/roster", `{"user":"al"}`, http.StatusCreated},
+		{"invite again while pending", "co", http.MethodPost, "/roster", `{"user":"al"}`, http.StatusCreated},
+		{"coach cannot accept own invitation", "co", http.MethodPut, "/roster?username=al", "", http.StatusNotFound},
+		{"not yet a client", "co", http.MethodGet, "/roster", "", http.StatusOK},
+		{"member accepts", "al", http.MethodPut, "/roster?username=co", "", http.StatusOK},
+		{"invite an active client", "co", http.MethodPost, "/roster", `{"user":"al"}`, http.StatusConflict},
+		{"coach cannot invite a coach", "co", http.MethodPost, "/roster", `{"user":"co2"}`, http.StatusBadRequest},
+		{"member asks by email", "al", http.MethodPost, "/roster", `{"user":"CO2@example.com"}`, http.StatusCreated},
+		{"unknown account", "al", http.MethodPost, "/roster", `{"user":"nobody"}`, http.StatusNotFound},
+		{"member withdraws request", "al", http.MethodDelete, "/roster?username=co2", "", http.StatusNoContent},
+		{"nothing left to withdraw", "al", http.MethodDelete, "/roster?username=co2", "", http.StatusNotFound},
+	}
+	for _, step := range steps {
+		rec := call(RosterHandler, u[step.user], step.method, step.target, step.body)
+		if rec.Code != step.status {
+			t.Fatalf("%s: status %d, want %d: %s", step.name, rec.Code, step.status, rec.Body)
+		}
+	}
+
+	rec := call(RosterHandler, u["co"], http.MethodGet, "/roster", "")
+	var roster []db.RosterEntry
+	if err := json.NewDecoder(rec.Body).Decode(&roster); err != nil {
+		t.Fatalf("decoding roster: %v", err)
+	}
+	if len(roster) != 1 || roster[0].Username != "al" || roster[0].Status != db.LinkActive {
+		t.Errorf("coach roster = %+v, want al active", roster)
+	}
+	if ok, _ := db.IsClient(u["co2"].ID, u["al"].ID); ok {
+		t.Error("withdrawn request still links al to co2")
+	}
+}
+
+func TestScoping(t *testing.T) {
+	useMemoryDB(t)
+	u := people(t, map[string]string{
+		"co": RoleCoach, "co2": RoleCoach, "al": RoleMember, "bo": RoleMember, "ad": RoleAdmin,
+	})
+	link(t, u["co"], u["al"])
+	// co2 only has a pending invitation to bo
+	if _, err := db.LinkCoach(u["co2"].ID, u["bo"].ID, RoleCoach); err != nil {
+		t.Fatalf("LinkCoach: %v", err)
+	}
+
+	t.Run("coachesMember", func(t *testing.T) {
+		tests := []struct {
+			user, member string
+			want         bool
+		}{
+			{"co", "al", true},
+			{"co", "bo", false},
+			{"co2", "bo", false},
+			{"al", "al", true},
+			{"al", "bo", false},
+			{"ad", "bo", true},
+		}
+		for _, tt := range tests {
+			if got := coachesMember(u[tt.user], u[tt.member].ID); got != tt.want {
+				t.Errorf("coachesMember(%s, %s) = %v, want %v", tt.user, tt.member, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("canChat", func(t *testing.T) {
+		tests := []struct {
+			user, other string
+			want        bool
+		}{
+			{"co", "al", true},
+			{"al", "co", true},
+			{"co", "bo", false},
+			{"al", "bo", false},
+			{"al", "co2", false},
+			{"bo", "co2", false},
+			{"co", "co2", false},
+			{"ad", "bo", true},
+			{"al", "nobody", false},
+		}
+		for _, tt := range tests {
+			if got := canChat(u[tt.user], tt.other); got != tt.want {
+				t.Errorf("canChat(%s, %s) = %v, want %v", tt.user, tt.other, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("memberID", func(t *testing.T) {
+		tests := []struct {
+			user, target string
+			want         string
+			status       int
+		}{
+			{user: "al", target: "/", want: "al"},
+			{user: "al", target: "/?username=bo", want: "al"},
+			{user: "co", target: "/?username=al", want: "al"},
+			{user: "co", target: "/", status: http.StatusBadRequest},
+			{user: "co", target: "/?username=nobody", status: http.StatusNotFound},
+			{user: "co", target: "/?username=bo", status: http.StatusForbidden},
+			{user: "co2", target: "/?username=bo", status: http.StatusForbidden},
+			{user: "ad", target: "/?username=bo", want: "bo"},
+		}
+		for _, tt := range tests {
+			var got int64
+			var ok bool
+			rec := call(func(w http.ResponseWriter, r *http.Request) {
+				got, ok = memberID(w, r)
+			}, u[tt.user], http.MethodGet, tt.target, "")
+			switch {
+			case tt.want != "" && (!ok || got != u[tt.want].ID):
+				t.Errorf("%s %s: memberID = %d, %v; want %s", tt.user, tt.target, got, ok, tt.want)
+			case tt.want == "" && (ok || rec.Code != tt.status):
+				t.Errorf("%s %s: ok %v, status %d; want %d", tt.user, tt.target, ok, rec.Code, tt.status)
+			}
+		}
+	})
+
+	t.Run("chat history", func(t *testing.T) {
+		tests := []struct {
+			user, receiver string
+			status         int
+		}{
+			{"co", "al", http.StatusOK},
+			{"al", "co", http.StatusOK},
+			{"co", "bo", http.StatusForbidden},
+			{"bo", "co2", http.StatusForbidden},
+		}
+		for _, tt := range tests {
+			rec := call(ChatHistoryHandler, u[tt.user], http.MethodGet, "/chat-history?receiver="+tt.receiver, "")
+			if rec.Code != tt.status {
+				t.Errorf("%s reading chat with %s: status %d, want %d", tt.user, tt.receiver, rec.Code, tt.status)
+			}
+		}
+	})
+}
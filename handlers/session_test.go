@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"fitnesscoach/config"
+	"fitnesscoach/db"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useMemoryDB makes a fresh in-memory store current for the test
+func useMemoryDB(t *testing.T) {
+	t.Helper()
+	s, err := db.NewMemoryStore()
+	if err != nil {
+		t.Fatalf("NewMemoryStore: %v", err)
+	}
+	db.Use(s)
+	t.Cleanup(func() { s.Close() })
+}
+
+func keyPair(hash, block byte) [][]byte {
+	return [][]byte{bytes.Repeat([]byte{hash}, 32), bytes.Repeat([]byte{block}, 32)}
+}
+
+func initSessions(keyPairs ...[][]byte) {
+	var keys [][]byte
+	for _, pair := range keyPairs {
+		keys = append(keys, pair...)
+	}
+	Init(&config.Config{Session: config.SessionConfig{
+		KeyPairs: keys,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   time.Hour,
+	}})
+}
+
+// login posts the login form and returns the session cookie
+func login(t *testing.T, username, password string) *http.Cookie {
+	t.Helper()
+	form := url.Values{"username": {username}, "password": {password}}
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	LoginHandler(rec, req)
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("login as %s: status %d", username, rec.Code)
+	}
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == sessionName {
+			return c
+		}
+	}
+	t.Fatalf("login as %s set no session cookie", username)
+	return nil
+}
+
+// sessionUser reads the username the cookie's session belongs to
+func sessionUser(cookie *http.Cookie) string {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	session, _ := store.Get(req, sessionName)
+	username, _ := session.Values["username"].(string)
+	return username
+}
+
+func TestSessionCookie(t *testing.T) {
+	useMemoryDB(t)
+	if _, err := db.CreateUser("al", "al@example.com", "secret", "member"); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	initSessions(keyPair(1, 2))
+	cookie := login(t, "al", "secret")
+
+	if !cookie.Secure || !cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode || cookie.MaxAge != 3600 {
+		t.Errorf("cookie options = %+v", cookie)
+	}
+	if got := sessionUser(cookie); got != "al" {
+		t.Errorf("session user = %q, want al", got)
+	}
+
+	// A new pair signs from now on while the old one still reads cookies
+	initSessions(keyPair(3, 4), keyPair(1, 2))
+	if got := sessionUser(cookie); got != "al" {
+		t.Errorf("after rotation, session user = %q, want al", got)
+	}
+	if got := sessionUser(login(t, "al", "secret")); got != "al" {
+		t.Errorf("new cookie session user = %q, want al", got)
+	}
+
+	// Once the old pair is dropped its cookies are rejected
+	initSessions(keyPair(3, 4))
+	if got := sessionUser(cookie); got != "" {
+		t.Errorf("cookie signed with a dropped key still reads as %q", got)
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fitnesscoach/ai"
+	"fitnesscoach/db"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// ConversationStreamHandler continues a conversation like
+// ConversationMessagesHandler but relays the answer as Server-Sent Events
+// while the provider produces it:
+//
+//	event: message  the stored member message
+//	event: delta    {"text": "..."} for each piece of the answer
+//	event: done     the stored answer
+//	event: error    {"error": "..."}
+//
+// Closing the request cancels the provider call; whatever was received so
+// far is still saved.
+func ConversationStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	id, ok := queryID(r, "id")
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+	var body conversationMessage
+	if err := decodeJSON(w, r, &body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user := currentUser(r)
+	turn, status, err := prepareTurn(r.Context(), user, id, body)
+	if err != nil {
+		writeJSONError(w, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // keep proxies from buffering
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event string, v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	send("message", turn.message)
+	answer, err := streamTurn(r.Context(), user, id, turn, func(delta string) error {
+		return send("delta", map[string]string{"text": delta})
+	})
+	if err != nil {
+		send("error", map[string]string{"error": "the AI coach is unavailable right now"})
+		return
+	}
+	send("done", answer)
+}
+
+// streamTurn streams the answer to a prepared turn through onDelta and
+// stores it. A cancelled stream stores the partial answer.
+func streamTurn(ctx context.Context, user *CurrentUser, id int64, turn *conversationTurn, onDelta func(string) error) (*db.AIMessage, error) {
+	reply, err := aiProvider.Stream(ctx, turn.prompt, ai.Options{}, onDelta)
+	if err != nil {
+		if ctx.Err() != nil {
+			log.Printf("✋ %s cancelled the AI reply in conversation %d", user.Username, id)
+		} else {
+			log.Printf("❌ %s stream failed: %v", aiProvider.Name(), err)
+		}
+		if reply == "" {
+			return nil, err
+		}
+	}
+
+	answer, saveErr := db.AddConversationMessage(user.ID, id, ai.RoleAssistant, reply)
+	if saveErr != nil {
+		log.Printf("❌ Failed to save AI reply: %v", saveErr)
+		return nil, saveErr
+	}
+	return answer, err
+}
+
+// socketAIRequest asks for an AI answer over the chat WebSocket:
+// {"type": "ai", "conversationId": 1, "content": "...", "share": true}
+type socketAIRequest struct {
+	ConversationID int64  `json:"conversationId"`
+	Content        string `json:"content"`
+	Share          *bool  `json:"share"`
+}
+
+// streamOverSocket answers an "ai" WebSocket frame, sending ai.message,
+// ai.delta, ai.done or ai.error frames back to the requesting connection
+// only. Frames wait for room in the connection's buffer rather than being
+// dropped; the stream stops when the connection closes.
+func streamOverSocket(ctx context.Context, client *Client, user *CurrentUser, raw json.RawMessage) {
+	var req socketAIRequest
+	if err := json.Unmarshal(raw, &req); err != nil || req.ConversationID <= 0 {
+		client.Send(map[string]any{"type": "ai.error", "error": "conversationId and content are required"})
+		return
+	}
+	frame := func(kind string, fields map[string]any) error {
+		fields["type"] = kind
+		fields["conversationId"] = req.ConversationID
+		if !client.SendContext(ctx, fields) {
+			return errors.New("connection closed")
+		}
+		return nil
+	}
+
+	turn, _, err := prepareTurn(ctx, user, req.ConversationID, conversationMessage{Content: req.Content, Share: req.Share})
+	if err != nil {
+		frame("ai.error", map[string]any{"error": err.Error()})
+		return
+	}
+	frame("ai.message", map[string]any{"message": turn.message})
+
+	answer, err := streamTurn(ctx, user, req.ConversationID, turn, func(delta string) error {
+		return frame("ai.delta", map[string]any{"text": delta})
+	})
+	if err != nil {
+		frame("ai.error", map[string]any{"error": "the AI coach is unavailable right now"})
+		return
+	}
+	frame("ai.done", map[string]any{"message": answer})
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"fitnesscoach/db"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// WaterHandler tracks a member's water intake. Coaches may read a
+// client's day with ?username=:
+//
+//	GET ?date=    drinks, total, target and hourly split for a day (default today)
+//	POST          log {amountMl, loggedAt}; loggedAt defaults to now
+//	DELETE ?id=   remove a drink
+func WaterHandler(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	if r.Method != http.MethodGet && user.Role != RoleMember {
+		writeJSONError(w, http.StatusForbidden, "only members can log water")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		userID, ok := memberID(w, r)
+		if !ok {
+			return
+		}
+		date, err := queryDate(r, "date")
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "date must be YYYY-MM-DD")
+			return
+		}
+		if date.IsZero() {
+			date = time.Now()
+		}
+		day, err := db.GetWaterDay(userID, date.Format("2006-01-02"))
+		if err != nil {
+			log.Printf("❌ Failed to load water day: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load water")
+			return
+		}
+		writeJSON(w, http.StatusOK, day)
+
+	case http.MethodPost:
+		var req struct {
+			AmountML int        `json:"amountMl"`
+			LoggedAt *time.Time `json:"loggedAt"`
+		}
+		if err := decodeJSON(w, r, &req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if req.AmountML < 10 || req.AmountML > 5000 {
+			writeJSONError(w, http.StatusBadRequest, "amountMl must be between 10 and 5000")
+			return
+		}
+		at := time.Now()
+		if req.LoggedAt != nil {
+			at = *req.LoggedAt
+		}
+		if at.After(time.Now().Add(time.Minute)) {
+			writeJSONError(w, http.StatusBadRequest, "loggedAt cannot be in the future")
+			return
+		}
+
+		entry, err := db.AddWater(user.ID, req.AmountML, at)
+		if err != nil {
+			log.Printf("❌ Failed to log water: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to log water")
+			return
+		}
+		writeJSON(w, http.StatusCreated, entry)
+
+	case http.MethodDelete:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.DeleteWater(user.ID, id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "drink not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to delete water: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to delete drink")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// WaterSettingsHandler reads (GET) or replaces (PUT) a member's own water
+// target and quick-add amounts. A null target follows their weight again.
+// Coaches pass ?username= for their clients.
+func WaterSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := memberID(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		settings, err := db.GetWaterSettings(userID)
+		if err != nil {
+			log.Printf("❌ Failed to load water settings: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load water settings")
+			return
+		}
+		writeJSON(w, http.StatusOK, settings)
+
+	case http.MethodPut:
+		var settings db.WaterSettings
+		if err := decodeJSON(w, r, &settings); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if t := settings.TargetML; t != nil && (*t < 500 || *t > 10000) {
+			writeJSONError(w, http.StatusBadRequest, "targetMl must be between 500 and 10000")
+			return
+		}
+		if len(settings.QuickAdds) > 6 {
+			writeJSONError(w, http.StatusBadRequest, "choose at most 6 quick-add amounts")
+			return
+		}
+		for _, ml := range settings.QuickAdds {
+			if ml < 10 || ml > 5000 {
+				writeJSONError(w, http.StatusBadRequest, "quick-add amounts must be between 10 and 5000 ml")
+				return
+			}
+		}
+		slices.Sort(settings.QuickAdds)
+		settings.QuickAdds = slices.Compact(settings.QuickAdds)
+
+		if err := db.SetWaterSettings(userID, settings); err != nil {
+			log.Printf("❌ Failed to save water settings: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to save water settings")
+			return
+		}
+		saved, err := db.GetWaterSettings(userID)
+		if err != nil {
+			log.Printf("❌ Failed to load water settings: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to load water settings")
+			return
+		}
+		writeJSON(w, http.StatusOK, saved)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
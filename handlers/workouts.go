@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"errors"
+	"fitnesscoach/db"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// WorkoutsHandler lists recent workout sessions. Coaches pass ?username=
+// to review a member's history.
+func WorkoutsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	userID, ok := memberID(w, r)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	workouts, err := db.ListWorkouts(userID, limit)
+	if err != nil {
+		log.Printf("❌ Failed to list workouts: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load workouts")
+		return
+	}
+	writeJSON(w, http.StatusOK, workouts)
+}
+
+// WorkoutDetailHandler returns one session with every exercise and set
+func WorkoutDetailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	userID, ok := memberID(w, r)
+	if !ok {
+		return
+	}
+	id, ok := queryID(r, "id")
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	workout, err := db.GetWorkout(userID, id)
+	if errors.Is(err, db.ErrNotFound) {
+		writeJSONError(w, http.StatusNotFound, "workout not found")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to load workout: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load workout")
+		return
+	}
+	writeJSON(w, http.StatusOK, workout)
+}
+
+// ActiveWorkoutHandler returns the member's session in progress, or null
+func ActiveWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	workout, err := db.ActiveWorkout(currentUser(r).ID)
+	if err != nil {
+		log.Printf("❌ Failed to load active workout: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to load workout")
+		return
+	}
+	writeJSON(w, http.StatusOK, workout)
+}
+
+// StartWorkoutHandler opens a session; an unfinished one is resumed instead
+func StartWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "Weight training"
+	}
+
+	workout, err := db.StartWorkout(currentUser(r).ID, name)
+	if err != nil {
+		log.Printf("❌ Failed to start workout: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to start workout")
+		return
+	}
+	writeJSON(w, http.StatusOK, workout)
+}
+
+// LogSetHandler records a set (POST) or removes one by ?id= (DELETE)
+func LogSetHandler(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+
+	switch r.Method {
+	case http.MethodPost:
+		var req struct {
+			SessionID   int64    `json:"sessionId"`
+			Exercise    string   `json:"exercise"`
+			Reps        int      `json:"reps"`
+			LoadKG      float64  `json:"loadKg"`
+			RPE         *float64 `json:"rpe"`
+			RestSeconds *int     `json:"restSeconds"`
+		}
+		if err := decodeJSON(w, r, &req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		req.Exercise = strings.TrimSpace(req.Exercise)
+		switch {
+		case req.Exercise == "" || len(req.Exercise) > 100:
+			writeJSONError(w, http.StatusBadRequest, "exercise is required")
+			return
+		case req.Reps <= 0 || req.LoadKG < 0:
+			writeJSONError(w, http.StatusBadRequest, "reps must be positive and load cannot be negative")
+			return
+		case req.RPE != nil && (*req.RPE < 1 || *req.RPE > 10):
+			writeJSONError(w, http.StatusBadRequest, "rpe must be between 1 and 10")
+			return
+		case req.RestSeconds != nil && *req.RestSeconds < 0:
+			writeJSONError(w, http.StatusBadRequest, "restSeconds cannot be negative")
+			return
+		}
+
+		set, err := db.LogSet(user.ID, req.SessionID, req.Exercise, db.WorkoutSet{
+			Reps:        req.Reps,
+			LoadKG:      req.LoadKG,
+			RPE:         req.RPE,
+			RestSeconds: req.RestSeconds,
+		})
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			writeJSONError(w, http.StatusNotFound, "workout not found")
+		case errors.Is(err, db.ErrWorkoutFinished):
+			writeJSONError(w, http.StatusConflict, "workout already finished")
+		case err != nil:
+			log.Printf("❌ Failed to log set: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to log set")
+		default:
+			writeJSON(w, http.StatusCreated, set)
+		}
+
+	case http.MethodDelete:
+		id, ok := queryID(r, "id")
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "id is required")
+			return
+		}
+		err := db.DeleteSet(user.ID, id)
+		if errors.Is(err, db.ErrNotFound) {
+			writeJSONError(w, http.StatusNotFound, "set not found")
+			return
+		}
+		if err != nil {
+			log.Printf("❌ Failed to delete set: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to delete set")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// FinishWorkoutHandler closes the session with optional notes
+func FinishWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req struct {
+		SessionID int64  `json:"sessionId"`
+		Notes     string `json:"notes"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	err := db.FinishWorkout(currentUser(r).ID, req.SessionID, strings.TrimSpace(req.Notes))
+	if errors.Is(err, db.ErrNotFound) {
+		writeJSONError(w, http.StatusNotFound, "no open workout with that id")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to finish workout: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to finish workout")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Workout saved"})
+}
@@ -1,23 +1,48 @@
 package main
 
 import (
+	"fitnesscoach/config"
 	"fitnesscoach/db"
 	"fitnesscoach/handlers"
 	"fmt"
 	"log"
 	"net/http"
-
-	"github.com/joho/godotenv"
+	"os"
 )
 
 func main() {
-	// Load environment variables from .env file
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("❌ Error loading .env file")
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "migrate" {
+		// Migrations only need the database settings
+		cfg, err := config.LoadDatabase(args[1:])
+		if err != nil {
+			log.Fatal("❌ Invalid configuration:\n", err)
+		}
+		cfg.Database.AutoMigrate = false
+		if err := db.InitDB(cfg.Database); err != nil {
+			log.Fatal("❌ Database connection failed:", err)
+		}
+		if err := runMigrate(cfg.Args); err != nil {
+			log.Fatal("❌ Migration failed: ", err)
+		}
+		return
+	}
+
+	// Load configuration from .env, the environment and flags
+	cfg, err := config.Load(args)
+	if err != nil {
+		log.Fatal("❌ Invalid configuration:\n", err)
+	}
+	if cfg.Session.Ephemeral {
+		log.Println("⚠️ SESSION_KEYS is not set; using random keys, so sessions end on restart")
+	}
+
+	if err := handlers.Init(cfg); err != nil {
+		log.Fatal("❌ Handler setup failed: ", err)
 	}
 
 	// Initialize DB
-	if err := db.InitDB(); err != nil {
+	if err := db.InitDB(cfg.Database); err != nil {
 		log.Fatal("❌ Database connection failed:", err)
 	}
 
@@ -26,24 +51,84 @@ func main() {
 	http.Handle("/resources/", http.StripPrefix("/resources/", fs))
 	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
 
-	// Routes
+	// Public routes
 	http.HandleFunc("/login", handlers.LoginHandler)
 	http.HandleFunc("/register", handlers.RegisterHandler)
-	http.HandleFunc("/home", handlers.HomePageHandler)
 	http.HandleFunc("/logout", handlers.LogoutHandler)
-	http.HandleFunc("/userinfo", handlers.UserInfoHandler)
-	http.HandleFunc("/userdash", handlers.UserDashHandler)
-	http.HandleFunc("/weight", handlers.WeightHandler)
-	http.HandleFunc("/cardio", handlers.CardioHandler)
-	http.HandleFunc("/coachdash", handlers.HandleConnections)
-	http.HandleFunc("/coachchat", handlers.CoachChatHandler)
-	http.HandleFunc("/ws", handlers.HandleConnections)
-	go handlers.HandleMessages()
-	http.HandleFunc("/update-profile", handlers.UpdateProfilePageHandler)
-	http.HandleFunc("/all-user-info", handlers.GetAllUserInfoHandler)
-	http.HandleFunc("/chat-history", handlers.ChatHistoryHandler)
-	http.HandleFunc("/ai-chat", handlers.AiChatHandler)
-
-	fmt.Println("✅ Server running at http://localhost:8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+
+	// Any signed-in user
+	http.HandleFunc("/home", handlers.RequirePage(handlers.HomePageHandler))
+
+	// Member routes
+	http.HandleFunc("/userinfo", handlers.RequirePage(handlers.UserInfoHandler, handlers.RoleMember))
+	http.HandleFunc("/userdash", handlers.RequirePage(handlers.UserDashHandler, handlers.RoleMember))
+	http.HandleFunc("/weight", handlers.RequirePage(handlers.WeightHandler, handlers.RoleMember))
+	http.HandleFunc("/cardio", handlers.RequirePage(handlers.CardioHandler, handlers.RoleMember))
+	http.HandleFunc("/update-profile", handlers.RequirePage(handlers.UpdateProfilePageHandler, handlers.RoleMember))
+	http.HandleFunc("/measurements", handlers.RequireAPI(handlers.MeasurementsHandler, handlers.RoleMember))
+	http.HandleFunc("/measurements/trend", handlers.RequireAPI(handlers.MeasurementTrendHandler, handlers.RoleMember))
+	http.HandleFunc("/workouts/active", handlers.RequireAPI(handlers.ActiveWorkoutHandler, handlers.RoleMember))
+	http.HandleFunc("/workouts/start", handlers.RequireAPI(handlers.StartWorkoutHandler, handlers.RoleMember))
+	http.HandleFunc("/workouts/log", handlers.RequireAPI(handlers.LogSetHandler, handlers.RoleMember))
+	http.HandleFunc("/workouts/finish", handlers.RequireAPI(handlers.FinishWorkoutHandler, handlers.RoleMember))
+	http.HandleFunc("/nutrition", handlers.RequirePage(handlers.NutritionPageHandler, handlers.RoleMember))
+	http.HandleFunc("/nutrition/entries", handlers.RequireAPI(handlers.FoodEntriesHandler, handlers.RoleMember))
+	http.HandleFunc("/nutrition/favourites", handlers.RequireAPI(handlers.FavouriteMealsHandler, handlers.RoleMember))
+	http.HandleFunc("/nutrition/favourites/log", handlers.RequireAPI(handlers.LogFavouriteMealHandler, handlers.RoleMember))
+
+	// Member history, also reviewed by coaches with ?username=
+	http.HandleFunc("/workouts", handlers.RequireAPI(handlers.WorkoutsHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/workouts/detail", handlers.RequireAPI(handlers.WorkoutDetailHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/cardio/sessions", handlers.RequireAPI(handlers.CardioSessionsHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/cardio/bests", handlers.RequireAPI(handlers.CardioBestsHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/cardio/weekly", handlers.RequireAPI(handlers.CardioWeeklyHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/habits", handlers.RequireAPI(handlers.HabitsHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/habits/checkins", handlers.RequireAPI(handlers.CheckInsHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/habits/calendar", handlers.RequireAPI(handlers.HabitCalendarHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/habits/streaks", handlers.RequireAPI(handlers.HabitStreaksHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/programs", handlers.RequireAPI(handlers.ProgramsHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/programs/detail", handlers.RequireAPI(handlers.ProgramDetailHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/programs/pending", handlers.RequireAPI(handlers.PendingProgramsHandler, handlers.RoleCoach))
+	http.HandleFunc("/programs/approve", handlers.RequireAPI(handlers.ApproveProgramHandler, handlers.RoleCoach))
+	http.HandleFunc("/exercises", handlers.RequireAPI(handlers.ExercisesHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/exercises/filters", handlers.RequireAPI(handlers.ExerciseFacetsHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/foods", handlers.RequireAPI(handlers.FoodsHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/nutrition/day", handlers.RequireAPI(handlers.NutritionDayHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/nutrition/targets", handlers.RequireAPI(handlers.NutritionTargetsHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/water", handlers.RequireAPI(handlers.WaterHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/water/settings", handlers.RequireAPI(handlers.WaterSettingsHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/metrics", handlers.RequireAPI(handlers.MetricsHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/recipes", handlers.RequireAPI(handlers.RecipesHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/goals", handlers.RequireAPI(handlers.GoalsHandler, handlers.RoleMember, handlers.RoleCoach))
+	http.HandleFunc("/progress", handlers.RequireAPI(handlers.ProgressHandler, handlers.RoleMember))
+
+	// Coach–client links, managed from either side
+	http.HandleFunc("/roster", handlers.RequireAPI(handlers.RosterHandler, handlers.RoleMember, handlers.RoleCoach))
+
+	// Coach routes
+	http.HandleFunc("/all-user-info", handlers.RequireAPI(handlers.GetAllUserInfoHandler, handlers.RoleCoach))
+	http.HandleFunc("/members", handlers.RequireAPI(handlers.MembersHandler, handlers.RoleCoach))
+	http.HandleFunc("/foods/import", handlers.RequireAPI(handlers.FoodImportHandler, handlers.RoleCoach))
+	http.HandleFunc("/notes", handlers.RequireAPI(handlers.NotesHandler, handlers.RoleCoach))
+	http.HandleFunc("/timeline", handlers.RequireAPI(handlers.TimelineHandler, handlers.RoleCoach))
+
+	if cfg.Features.CoachChat {
+		http.HandleFunc("/coachdash", handlers.RequireAPI(handlers.HandleConnections, handlers.RoleCoach))
+		http.HandleFunc("/coachchat", handlers.RequirePage(handlers.CoachChatHandler, handlers.RoleCoach))
+		http.HandleFunc("/ws", handlers.RequireAPI(handlers.HandleConnections))
+		http.HandleFunc("/chat-history", handlers.RequireAPI(handlers.ChatHistoryHandler))
+		http.HandleFunc("/chat/unread", handlers.RequireAPI(handlers.UnreadCountsHandler))
+		go handlers.HandleMessages()
+	}
+	if cfg.Features.AIChat {
+		http.HandleFunc("/ai-chat", handlers.RequirePage(handlers.AiChatHandler))
+		http.HandleFunc("/ai/conversations", handlers.RequireAPI(handlers.ConversationsHandler))
+		http.HandleFunc("/ai/conversations/detail", handlers.RequireAPI(handlers.ConversationDetailHandler))
+		http.HandleFunc("/ai/conversations/messages", handlers.RequireAPI(handlers.ConversationMessagesHandler))
+		http.HandleFunc("/ai/conversations/stream", handlers.RequireAPI(handlers.ConversationStreamHandler))
+		http.HandleFunc("/programs/generate", handlers.RequireAPI(handlers.GenerateProgramHandler, handlers.RoleMember))
+	}
+
+	fmt.Printf("✅ Server running at %s\n", cfg.ListenAddr)
+	log.Fatal(http.ListenAndServe(cfg.ListenAddr, nil))
 }
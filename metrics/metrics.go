@@ -0,0 +1,198 @@
+// Package metrics works out body metrics and daily energy needs from a
+// member's profile: BMI, basal metabolic rate, total daily energy
+// expenditure and macronutrient targets for their goal.
+package metrics
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// Activity levels and the factor each multiplies the BMR by
+const (
+	ActivitySedentary  = "sedentary"
+	ActivityLight      = "light"
+	ActivityModerate   = "moderate"
+	ActivityActive     = "active"
+	ActivityVeryActive = "very_active"
+)
+
+// ActivityFactors are the usual Harris-Benedict activity multipliers
+var ActivityFactors = map[string]float64{
+	ActivitySedentary:  1.2,
+	ActivityLight:      1.375,
+	ActivityModerate:   1.55,
+	ActivityActive:     1.725,
+	ActivityVeryActive: 1.9,
+}
+
+// ActivityLevels lists the activity levels from least to most active
+var ActivityLevels = []string{ActivitySedentary, ActivityLight, ActivityModerate, ActivityActive, ActivityVeryActive}
+
+// Weight goals that decide the calorie target
+const (
+	GoalLose     = "lose"
+	GoalMaintain = "maintain"
+	GoalGain     = "gain"
+)
+
+// Goals lists the weight goals
+var Goals = []string{GoalLose, GoalMaintain, GoalGain}
+
+// BMR formulas
+const (
+	FormulaMifflinStJeor = "mifflin_st_jeor"
+	FormulaKatchMcArdle  = "katch_mcardle"
+)
+
+// ErrIncomplete is returned when the profile lacks the weight, height or
+// age the calculations need
+var ErrIncomplete = errors.New("weight, height and age are needed")
+
+// Profile is what the calculations start from. BodyFatPct is optional; when
+// known, Katch-McArdle gives the BMR used for energy needs.
+type Profile struct {
+	WeightKG   float64
+	HeightCM   float64
+	Age        int
+	Gender     string
+	BodyFatPct *float64
+	Activity   string
+	Goal       string
+}
+
+// Targets are daily energy (kcal) and macronutrient (g) targets
+type Targets struct {
+	Calories float64 `json:"calories"`
+	ProteinG float64 `json:"proteinG"`
+	CarbsG   float64 `json:"carbsG"`
+	FatG     float64 `json:"fatG"`
+	FibreG   float64 `json:"fibreG"`
+}
+
+// Result is everything worked out for a profile. BMR is the value used for
+// TDEE, from BMRFormula.
+type Result struct {
+	BMI         float64  `json:"bmi"`
+	BMICategory string   `json:"bmiCategory"`
+	BMRMifflin  float64  `json:"bmrMifflin"`
+	BMRKatch    *float64 `json:"bmrKatch"`
+	BMR         float64  `json:"bmr"`
+	BMRFormula  string   `json:"bmrFormula"`
+	TDEE        float64  `json:"tdee"`
+	Activity    string   `json:"activity"`
+	Goal        string   `json:"goal"`
+	Targets     Targets  `json:"targets"`
+}
+
+// BMI is weight over height squared, in kg/m²
+func BMI(weightKG, heightCM float64) float64 {
+	m := heightCM / 100
+	return weightKG / (m * m)
+}
+
+// BMICategory is the WHO adult category of a BMI
+func BMICategory(bmi float64) string {
+	switch {
+	case bmi < 18.5:
+		return "underweight"
+	case bmi < 25:
+		return "healthy"
+	case bmi < 30:
+		return "overweight"
+	default:
+		return "obese"
+	}
+}
+
+// MifflinStJeor is the BMR in kcal/day from weight, height, age and gender.
+// Genders other than male and female use the midpoint of the two
+// constants.
+func MifflinStJeor(weightKG, heightCM float64, age int, gender string) float64 {
+	bmr := 10*weightKG + 6.25*heightCM - 5*float64(age)
+	switch strings.ToLower(gender) {
+	case "male":
+		return bmr + 5
+	case "female":
+		return bmr - 161
+	default:
+		return bmr - 78
+	}
+}
+
+// KatchMcArdle is the BMR in kcal/day from lean body mass
+func KatchMcArdle(weightKG, bodyFatPct float64) float64 {
+	lean := weightKG * (1 - bodyFatPct/100)
+	return 370 + 21.6*lean
+}
+
+// TDEE is the BMR times the activity factor, moderate if unknown
+func TDEE(bmr float64, activity string) float64 {
+	factor, ok := ActivityFactors[activity]
+	if !ok {
+		factor = ActivityFactors[ActivityModerate]
+	}
+	return bmr * factor
+}
+
+// MacroTargets splits a day's energy for a goal. Losing takes 20% off the
+// TDEE but never goes below the BMR, gaining adds 10%. Protein is set per
+// kg of body weight, fat is a quarter of the calories, carbs make up the
+// rest and fibre is 14 g per 1000 kcal.
+func MacroTargets(tdee, bmr, weightKG float64, goal string) Targets {
+	calories, proteinPerKG := tdee, 1.6
+	switch goal {
+	case GoalLose:
+		calories, proteinPerKG = math.Max(tdee*0.8, bmr), 2.0
+	case GoalGain:
+		calories, proteinPerKG = tdee*1.1, 1.8
+	}
+
+	protein := proteinPerKG * weightKG
+	fat := calories * 0.25 / 9
+	carbs := math.Max(0, (calories-protein*4-fat*9)/4)
+	return Targets{
+		Calories: math.Round(calories),
+		ProteinG: math.Round(protein),
+		CarbsG:   math.Round(carbs),
+		FatG:     math.Round(fat),
+		FibreG:   math.Round(calories / 1000 * 14),
+	}
+}
+
+// Calculate works out every metric for a profile. Unknown activity levels
+// count as moderate and unknown goals as maintain.
+func Calculate(p Profile) (*Result, error) {
+	if p.WeightKG <= 0 || p.HeightCM <= 0 || p.Age <= 0 {
+		return nil, ErrIncomplete
+	}
+	if _, ok := ActivityFactors[p.Activity]; !ok {
+		p.Activity = ActivityModerate
+	}
+	if p.Goal != GoalLose && p.Goal != GoalGain {
+		p.Goal = GoalMaintain
+	}
+
+	bmi := BMI(p.WeightKG, p.HeightCM)
+	r := &Result{
+		BMI:         round1(bmi),
+		BMICategory: BMICategory(bmi),
+		BMRMifflin:  math.Round(MifflinStJeor(p.WeightKG, p.HeightCM, p.Age, p.Gender)),
+		Activity:    p.Activity,
+		Goal:        p.Goal,
+	}
+	r.BMR, r.BMRFormula = r.BMRMifflin, FormulaMifflinStJeor
+	if p.BodyFatPct != nil && *p.BodyFatPct > 0 && *p.BodyFatPct < 100 {
+		katch := math.Round(KatchMcArdle(p.WeightKG, *p.BodyFatPct))
+		r.BMRKatch = &katch
+		r.BMR, r.BMRFormula = katch, FormulaKatchMcArdle
+	}
+	r.TDEE = math.Round(TDEE(r.BMR, p.Activity))
+	r.Targets = MacroTargets(r.TDEE, r.BMR, p.WeightKG, p.Goal)
+	return r, nil
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,249 @@
+package metrics
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestBMI(t *testing.T) {
+	tests := []struct {
+		weight, height float64
+		want           float64
+		category       string
+	}{
+		{80, 180, 24.69, "healthy"},
+		{50, 175, 16.33, "underweight"},
+		{90, 175, 29.39, "overweight"},
+		{120, 170, 41.52, "obese"},
+	}
+	for _, tt := range tests {
+		got := BMI(tt.weight, tt.height)
+		if math.Abs(got-tt.want) > 0.005 {
+			t.Errorf("BMI(%v, %v) = %.2f, want %.2f", tt.weight, tt.height, got, tt.want)
+		}
+		if c := BMICategory(got); c != tt.category {
+			t.Errorf("BMICategory(%.2f) = %q, want %q", got, c, tt.category)
+		}
+	}
+}
+
+func TestBMICategoryBoundaries(t *testing.T) {
+	tests := []struct {
+		bmi  float64
+		want string
+	}{
+		{18.49, "underweight"},
+		{18.5, "healthy"},
+		{24.99, "healthy"},
+		{25, "overweight"},
+		{29.99, "overweight"},
+		{30, "obese"},
+	}
+	for _, tt := range tests {
+		if got := BMICategory(tt.bmi); got != tt.want {
+			t.Errorf("BMICategory(%v) = %q, want %q", tt.bmi, got, tt.want)
+		}
+	}
+}
+
+func TestMifflinStJeor(t *testing.T) {
+	tests := []struct {
+		weight, height float64
+		age            int
+		gender         string
+		want           float64
+	}{
+		{80, 180, 30, "Male", 1780},
+		{80, 180, 30, "male", 1780},
+		{60, 165, 25, "Female", 1345.25},
+		{80, 180, 30, "Other", 1697},
+		{80, 180, 30, "", 1697},
+	}
+	for _, tt := range tests {
+		if got := MifflinStJeor(tt.weight, tt.height, tt.age, tt.gender); !near(got, tt.want) {
+			t.Errorf("MifflinStJeor(%v, %v, %v, %q) = %v, want %v", tt.weight, tt.height, tt.age, tt.gender, got, tt.want)
+		}
+	}
+}
+
+func TestKatchMcArdle(t *testing.T) {
+	tests := []struct {
+		weight, fat float64
+		want        float64
+	}{
+		{80, 20, 1752.4},
+		{60, 30, 1277.2},
+		{100, 0, 2530},
+	}
+	for _, tt := range tests {
+		if got := KatchMcArdle(tt.weight, tt.fat); !near(got, tt.want) {
+			t.Errorf("KatchMcArdle(%v, %v) = %v, want %v", tt.weight, tt.fat, got, tt.want)
+		}
+	}
+}
+
+func TestTDEE(t *testing.T) {
+	tests := []struct {
+		activity string
+		want     float64
+	}{
+		{ActivitySedentary, 2136},
+		{ActivityLight, 2447.5},
+		{ActivityModerate, 2759},
+		{ActivityActive, 3070.5},
+		{ActivityVeryActive, 3382},
+		{"couch", 2759},
+		{"", 2759},
+	}
+	if len(ActivityLevels) != len(ActivityFactors) {
+		t.Fatalf("%d activity levels but %d factors", len(ActivityLevels), len(ActivityFactors))
+	}
+	for _, tt := range tests {
+		if got := TDEE(1780, tt.activity); !near(got, tt.want) {
+			t.Errorf("TDEE(1780, %q) = %v, want %v", tt.activity, got, tt.want)
+		}
+	}
+}
+
+func TestMacroTargets(t *testing.T) {
+	tests := []struct {
+		name                string
+		tdee, bmr, weightKG float64
+		goal                string
+		want                Targets
+	}{
+		{"maintain", 2759, 1780, 80, GoalMaintain, Targets{Calories: 2759, ProteinG: 128, CarbsG: 389, FatG: 77, FibreG: 39}},
+		{"lose", 2759, 1780, 80, GoalLose, Targets{Calories: 2207, ProteinG: 160, CarbsG: 254, FatG: 61, FibreG: 31}},
+		{"gain", 2759, 1780, 80, GoalGain, Targets{Calories: 3035, ProteinG: 144, CarbsG: 425, FatG: 84, FibreG: 42}},
+		{"unknown goal maintains", 2759, 1780, 80, "bulk", Targets{Calories: 2759, ProteinG: 128, CarbsG: 389, FatG: 77, FibreG: 39}},
+		// 20% off a sedentary TDEE would go below the BMR
+		{"lose never below BMR", 2136, 1780, 80, GoalLose, Targets{Calories: 1780, ProteinG: 160, CarbsG: 174, FatG: 49, FibreG: 25}},
+		// Protein alone exceeds the calories left after fat
+		{"carbs never negative", 1000, 900, 200, GoalLose, Targets{Calories: 900, ProteinG: 400, CarbsG: 0, FatG: 25, FibreG: 13}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MacroTargets(tt.tdee, tt.bmr, tt.weightKG, tt.goal); got != tt.want {
+				t.Errorf("MacroTargets = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	base := Profile{WeightKG: 80, HeightCM: 180, Age: 30, Gender: "Male", Activity: ActivityModerate, Goal: GoalMaintain}
+	with := func(change func(*Profile)) Profile {
+		p := base
+		change(&p)
+		return p
+	}
+
+	tests := []struct {
+		name    string
+		profile Profile
+		want    Result
+	}{
+		{
+			name:    "reference profile",
+			profile: base,
+			want: Result{BMI: 24.7, BMICategory: "healthy", BMRMifflin: 1780, BMR: 1780, BMRFormula: FormulaMifflinStJeor,
+				TDEE: 2759, Activity: ActivityModerate, Goal: GoalMaintain,
+				Targets: Targets{Calories: 2759, ProteinG: 128, CarbsG: 389, FatG: 77, FibreG: 39}},
+		},
+		{
+			name:    "body fat uses Katch-McArdle",
+			profile: with(func(p *Profile) { p.BodyFatPct = ptr(20) }),
+			want: Result{BMI: 24.7, BMICategory: "healthy", BMRMifflin: 1780, BMRKatch: ptr(1752), BMR: 1752, BMRFormula: FormulaKatchMcArdle,
+				TDEE: 2716, Activity: ActivityModerate, Goal: GoalMaintain,
+				Targets: Targets{Calories: 2716, ProteinG: 128, CarbsG: 381, FatG: 75, FibreG: 38}},
+		},
+		{
+			name:    "out of range body fat is ignored",
+			profile: with(func(p *Profile) { p.BodyFatPct = ptr(100) }),
+			want: Result{BMI: 24.7, BMICategory: "healthy", BMRMifflin: 1780, BMR: 1780, BMRFormula: FormulaMifflinStJeor,
+				TDEE: 2759, Activity: ActivityModerate, Goal: GoalMaintain,
+				Targets: Targets{Calories: 2759, ProteinG: 128, CarbsG: 389, FatG: 77, FibreG: 39}},
+		},
+		{
+			name:    "unknown activity and goal fall back",
+			profile: with(func(p *Profile) { p.Activity, p.Goal = "", "" }),
+			want: Result{BMI: 24.7, BMICategory: "healthy", BMRMifflin: 1780, BMR: 1780, BMRFormula: FormulaMifflinStJeor,
+				TDEE: 2759, Activity: ActivityModerate, Goal: GoalMaintain,
+				Targets: Targets{Calories: 2759, ProteinG: 128, CarbsG: 389, FatG: 77, FibreG: 39}},
+		},
+		{
+			name:    "sedentary female losing",
+			profile: Profile{WeightKG: 60, HeightCM: 165, Age: 25, Gender: "Female", Activity: ActivitySedentary, Goal: GoalLose},
+			want: Result{BMI: 22.0, BMICategory: "healthy", BMRMifflin: 1345, BMR: 1345, BMRFormula: FormulaMifflinStJeor,
+				TDEE: 1614, Activity: ActivitySedentary, Goal: GoalLose,
+				Targets: Targets{Calories: 1345, ProteinG: 120, CarbsG: 132, FatG: 37, FibreG: 19}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Calculate(tt.profile)
+			if err != nil {
+				t.Fatalf("Calculate: %v", err)
+			}
+			if (got.BMRKatch == nil) != (tt.want.BMRKatch == nil) || (got.BMRKatch != nil && *got.BMRKatch != *tt.want.BMRKatch) {
+				t.Errorf("BMRKatch = %v, want %v", got.BMRKatch, tt.want.BMRKatch)
+			}
+			got.BMRKatch, tt.want.BMRKatch = nil, nil
+			if *got != tt.want {
+				t.Errorf("Calculate =\n%+v\nwant\n%+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateIncomplete(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+	}{
+		{"zero height", Profile{WeightKG: 80, Age: 30}},
+		{"zero weight", Profile{HeightCM: 180, Age: 30}},
+		{"zero age", Profile{WeightKG: 80, HeightCM: 180}},
+		{"negative height", Profile{WeightKG: 80, HeightCM: -180, Age: 30}},
+		{"empty", Profile{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if r, err := Calculate(tt.profile); !errors.Is(err, ErrIncomplete) {
+				t.Errorf("Calculate = %+v, %v; want ErrIncomplete", r, err)
+			}
+		})
+	}
+}
+
+// Every activity level and goal combination gives sane targets
+func TestCalculateEveryActivityAndGoal(t *testing.T) {
+	previous := 0.0
+	for _, activity := range ActivityLevels {
+		for _, goal := range Goals {
+			r, err := Calculate(Profile{WeightKG: 80, HeightCM: 180, Age: 30, Gender: "Male", Activity: activity, Goal: goal})
+			if err != nil {
+				t.Fatalf("Calculate(%s, %s): %v", activity, goal, err)
+			}
+			if r.Activity != activity || r.Goal != goal {
+				t.Errorf("Calculate(%s, %s) reports %s, %s", activity, goal, r.Activity, r.Goal)
+			}
+			if r.Targets.Calories < r.BMR {
+				t.Errorf("%s/%s: %v kcal is below the BMR %v", activity, goal, r.Targets.Calories, r.BMR)
+			}
+			if energy := r.Targets.ProteinG*4 + r.Targets.CarbsG*4 + r.Targets.FatG*9; math.Abs(energy-r.Targets.Calories) > 10 {
+				t.Errorf("%s/%s: macros add up to %v kcal, target is %v", activity, goal, energy, r.Targets.Calories)
+			}
+		}
+		r, _ := Calculate(Profile{WeightKG: 80, HeightCM: 180, Age: 30, Gender: "Male", Activity: activity})
+		if r.TDEE <= previous {
+			t.Errorf("%s TDEE %v is not above the previous level's %v", activity, r.TDEE, previous)
+		}
+		previous = r.TDEE
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"fitnesscoach/db"
+	"fmt"
+	"strconv"
+)
+
+const migrateUsage = "usage: fitnesscoach migrate [flags] up | down | status | to <version>"
+
+// runMigrate implements the "migrate" subcommand
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		return db.MigrateUp()
+	case "down":
+		return db.MigrateDown()
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		return db.MigrateTo(version)
+	case "status":
+		states, err := db.MigrationStatus()
+		if err != nil {
+			return err
+		}
+		for _, s := range states {
+			if s.Applied {
+				fmt.Printf("✅ %04d_%s (applied %s)\n", s.Version, s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Printf("⏳ %04d_%s (pending)\n", s.Version, s.Name)
+			}
+		}
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}